@@ -0,0 +1,93 @@
+package xlog
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AccessLogFormat is a line format of HTTPMiddlewareOptions.AccessLog.
+type AccessLogFormat int
+
+const (
+	// CommonLog is Apache's Common Log Format:
+	//
+	//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a.gif HTTP/1.0" 200 2326
+	CommonLog AccessLogFormat = iota
+	// CombinedLog is Apache's Combined Log Format, the Common Log Format
+	// followed by the quoted Referer and User-Agent headers.
+	CombinedLog
+)
+
+// appendAccessLog appends the line describing a request served from
+// start, as Apache's mod_log_config writes it.
+func appendAccessLog(buf []byte, f AccessLogFormat, r *http.Request, sw *statusWriter, start time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	buf = appendAccessField(buf, host)
+	buf = append(buf, " - "...)
+	user, _, _ := r.BasicAuth()
+	buf = appendAccessField(buf, user)
+	buf = append(buf, " ["...)
+	buf = start.AppendFormat(buf, "02/Jan/2006:15:04:05 -0700")
+	buf = append(buf, "] "...)
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	buf = appendAccessQuoted(buf, r.Method+" "+uri+" "+r.Proto)
+	buf = append(buf, ' ')
+	buf = strconv.AppendInt(buf, int64(sw.status()), 10)
+	buf = append(buf, ' ')
+	if sw.size == 0 {
+		buf = append(buf, '-')
+	} else {
+		buf = strconv.AppendInt(buf, sw.size, 10)
+	}
+	if f == CombinedLog {
+		buf = append(buf, ' ')
+		buf = appendAccessQuoted(buf, r.Referer())
+		buf = append(buf, ' ')
+		buf = appendAccessQuoted(buf, r.UserAgent())
+	}
+	return append(buf, '\n')
+}
+
+// appendAccessField appends an unquoted field, or "-" if s is empty.
+func appendAccessField(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	return appendAccessEscaped(buf, s)
+}
+
+// appendAccessQuoted appends s in double quotes, or "-" unquoted if s is
+// empty, as Apache writes the request line and headers.
+func appendAccessQuoted(buf []byte, s string) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+	buf = append(buf, '"')
+	buf = appendAccessEscaped(buf, s)
+	return append(buf, '"')
+}
+
+// appendAccessEscaped appends s with quotes, backslashes and control
+// characters escaped as Apache does, so a field cannot forge another.
+func appendAccessEscaped(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			buf = append(buf, '\\', c)
+		case c < ' ' || c == 0x7f:
+			buf = append(buf, '\\', 'x', hex[c>>4], hex[c&15])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,52 @@
+package xlog_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestAccessLog(t *testing.T) {
+	var records bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&records))
+
+	items := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("item"))
+	})
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/items?id=42", nil)
+		r.SetBasicAuth("frank", "secret")
+		r.Header.Set("Referer", "https://example.com/")
+		r.Header.Set("User-Agent", `curl "8.0"`)
+		return r
+	}
+
+	tests := []struct {
+		format xlog.AccessLogFormat
+		want   string
+	}{
+		{xlog.CommonLog, `^192\.0\.2\.1 - frank \[\d\d/\w{3}/\d{4}:\d\d:\d\d:\d\d [+-]\d{4}\] "GET /items\?id=42 HTTP/1\.1" 200 4\n$`},
+		{xlog.CombinedLog, `^192\.0\.2\.1 - frank \[.+\] "GET /items\?id=42 HTTP/1\.1" 200 4 "https://example\.com/" "curl \\"8\.0\\""\n$`},
+	}
+	for _, tt := range tests {
+		var access bytes.Buffer
+		records.Reset()
+		h := xlog.HTTPMiddleware(items, &xlog.HTTPMiddlewareOptions{
+			AccessLog:       &access,
+			AccessLogFormat: tt.format,
+			AccessLogOnly:   true,
+		})
+		h.ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		if !regexp.MustCompile(tt.want).MatchString(access.String()) {
+			t.Errorf("expected %s, got: %s", tt.want, access.String())
+		}
+		if records.Len() != 0 {
+			t.Errorf("expected no structured record, got: %s", records.String())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package xlog
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler returns an http.Handler for inspecting and changing logger
+// levels at runtime. GET lists the loggers as JSON, as ListLoggers does.
+// PUT or POST sets a level, taking the logger name and level from the
+// "logger" and "level" query parameters:
+//
+//	curl -X PUT 'localhost:6060/debug/xlog?logger=db&level=debug'
+//
+// The empty logger name sets the default level.
+//
+// With a "target" parameter, the handler manages debug targets instead:
+// PUT or POST adds one, DELETE removes one, and the response lists the
+// targets, as DebugTargets does. GET with "targets" lists them too:
+//
+//	curl -X PUT 'localhost:6060/debug/xlog?target=user_id&value=alice'
+//
+// Mount the handler only on an internal or authenticated endpoint.
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("target") != "" || r.URL.Query().Has("targets") {
+			adminTargets(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level, err := ParseLevel(r.FormValue("level"))
+			if err != nil {
+				http.Error(w, "xlog: invalid level", http.StatusBadRequest)
+				return
+			}
+			SetLoggerLevel(r.FormValue("logger"), level)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ListLoggers())
+	})
+}
+
+func adminTargets(w http.ResponseWriter, r *http.Request) {
+	key, value := ContextKey(r.FormValue("target")), r.FormValue("value")
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		AddDebugTarget(key, value)
+	case http.MethodDelete:
+		RemoveDebugTarget(key, value)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	targets := DebugTargets()
+	if targets == nil {
+		targets = []DebugTarget{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(targets)
+}
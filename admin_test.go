@@ -0,0 +1,108 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestAdminHandler(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+	db := xlog.Named("db").Named("pool")
+
+	ctx := context.Background()
+	db.Debug(ctx, "before")
+
+	srv := httptest.NewServer(xlog.AdminHandler())
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"?logger=db&level=debug", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+
+	// The existing child logger picks up the new level
+	db.Debug(ctx, "after")
+	if strings.Contains(buf.String(), "before") || !strings.Contains(buf.String(), "after") {
+		t.Errorf("expected the level change to apply immediately, got: %s", buf.String())
+	}
+
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var loggers []xlog.LoggerInfo
+	if err := json.NewDecoder(resp.Body).Decode(&loggers); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]xlog.LoggerInfo{
+		"":        {Name: "", Level: slog.LevelInfo},
+		"db":      {Name: "db", Level: slog.LevelDebug},
+		"db.pool": {Name: "db.pool", Level: slog.LevelDebug, Inherited: true},
+	}
+	for _, l := range loggers {
+		if w, ok := want[l.Name]; ok && l != w {
+			t.Errorf("got %+v, want %+v", l, w)
+		}
+		delete(want, l.Name)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing loggers: %v", want)
+	}
+
+	req, _ = http.NewRequest(http.MethodPut, srv.URL+"?logger=db&level=loud", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid level, got %d", resp.StatusCode)
+	}
+}
+
+func TestAdminHandlerTargets(t *testing.T) {
+	srv := httptest.NewServer(xlog.AdminHandler())
+	defer srv.Close()
+
+	do := func(method, query string) []xlog.DebugTarget {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+query, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var targets []xlog.DebugTarget
+		if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+			t.Fatal(err)
+		}
+		return targets
+	}
+
+	targets := do(http.MethodPut, "?target=user_id&value=alice")
+	if len(targets) != 1 || targets[0] != (xlog.DebugTarget{Key: xlog.UserIDKey, Value: "alice"}) {
+		t.Errorf("unexpected targets after PUT: %v", targets)
+	}
+	if targets := do(http.MethodGet, "?targets"); len(targets) != 1 {
+		t.Errorf("unexpected targets from GET: %v", targets)
+	}
+	if targets := do(http.MethodDelete, "?target=user_id&value=alice"); len(targets) != 0 {
+		t.Errorf("expected no targets after DELETE, got %v", targets)
+	}
+}
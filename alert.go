@@ -0,0 +1,255 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AlertPayload builds the JSON body posted for a record. fields holds the
+// record's attributes, as nested maps for groups.
+type AlertPayload func(r slog.Record, fields map[string]any) any
+
+// AlertHandlerOptions configures an AlertHandler.
+type AlertHandlerOptions struct {
+	// Level is the minimum level to post. Defaults to slog.LevelError.
+	Level slog.Leveler
+
+	// Cooldown is the minimum time between two alerts with the same
+	// message; records in between are counted and reported as
+	// "suppressed" with the next alert. Defaults to 5m.
+	Cooldown time.Duration
+
+	// Payload builds the request body. Defaults to the record as a
+	// JSON object; use SlackPayload for Slack incoming webhooks.
+	Payload AlertPayload
+
+	// QueueSize is the number of alerts waiting to be posted before new
+	// ones are dropped. Defaults to 100.
+	QueueSize int
+
+	// Client sends the requests. Defaults to a client with a 10s timeout.
+	Client *http.Client
+
+	// OnDeliveryError is called when an alert cannot be posted.
+	OnDeliveryError func(err error)
+}
+
+// AlertHandler posts records at or above a level to a webhook, such as a
+// Slack channel, in the background. A per-message cooldown keeps a crash
+// loop from flooding the channel. Like SentryHandler, it is meant to run
+// beside the main handler via Tee; call Close to post pending alerts.
+type AlertHandler struct {
+	opts  AlertHandlerOptions
+	state sinkState
+	alert *alertState
+	queue *postQueue
+}
+
+// alertState is shared between an AlertHandler and its derived handlers.
+type alertState struct {
+	mu    sync.Mutex
+	last  map[string]time.Time
+	count map[string]int
+}
+
+// NewAlertHandler creates an AlertHandler posting to url.
+func NewAlertHandler(url string, opts *AlertHandlerOptions) *AlertHandler {
+	var o AlertHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelError
+	}
+	if o.Cooldown <= 0 {
+		o.Cooldown = 5 * time.Minute
+	}
+	if o.Payload == nil {
+		o.Payload = jsonPayload
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 100
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &AlertHandler{
+		opts: o,
+		alert: &alertState{
+			last:  make(map[string]time.Time),
+			count: make(map[string]int),
+		},
+		queue: newPostQueue("alert", url, o.Client, o.QueueSize, o.OnDeliveryError),
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *AlertHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// Handle queues an alert for the record unless one with the same message
+// was posted within the cooldown.
+func (h *AlertHandler) Handle(_ context.Context, r slog.Record) error {
+	a := h.alert
+	a.mu.Lock()
+	if last, ok := a.last[r.Message]; ok && r.Time.Sub(last) < h.opts.Cooldown {
+		a.count[r.Message]++
+		a.mu.Unlock()
+		return nil
+	}
+	a.last[r.Message] = r.Time
+	suppressed := a.count[r.Message]
+	delete(a.count, r.Message)
+	a.mu.Unlock()
+
+	fields := h.state.fields(r)
+	if suppressed > 0 {
+		fields["suppressed"] = suppressed
+	}
+	body, err := json.Marshal(h.opts.Payload(r, fields))
+	if err != nil {
+		return fmt.Errorf("xlog: alert encode: %w", err)
+	}
+
+	h.queue.enqueue(body)
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *AlertHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *AlertHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Close posts the queued alerts and stops the handler. Records handled
+// after Close are dropped.
+func (h *AlertHandler) Close() error {
+	h.queue.close()
+	return nil
+}
+
+// postQueue posts JSON bodies to a URL from a background goroutine, so
+// handlers for slow HTTP services don't block logging calls.
+type postQueue struct {
+	name    string
+	url     string
+	client  *http.Client
+	onError func(err error)
+
+	mu     sync.Mutex
+	queue  chan []byte
+	closed bool
+	wg     sync.WaitGroup
+}
+
+func newPostQueue(name, url string, client *http.Client, size int, onError func(err error)) *postQueue {
+	q := &postQueue{
+		name:    name,
+		url:     url,
+		client:  client,
+		onError: onError,
+		queue:   make(chan []byte, size),
+	}
+	q.wg.Add(1)
+	go q.run()
+	return q
+}
+
+// enqueue queues body, dropping it if the queue is full or closed.
+func (q *postQueue) enqueue(body []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	select {
+	case q.queue <- body:
+	default:
+		recordDropped()
+	}
+}
+
+// close posts the queued bodies and stops the goroutine.
+func (q *postQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+func (q *postQueue) run() {
+	defer q.wg.Done()
+
+	for body := range q.queue {
+		if err := q.post(body); err != nil && q.onError != nil {
+			q.onError(err)
+		}
+	}
+}
+
+func (q *postQueue) post(body []byte) error {
+	resp, err := q.client.Post(q.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("xlog: %s: %w", q.name, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("xlog: %s: unexpected status %s", q.name, resp.Status)
+	}
+	return nil
+}
+
+// jsonPayload is the default AlertPayload: the record as a JSON object.
+func jsonPayload(r slog.Record, fields map[string]any) any {
+	fields[slog.TimeKey] = r.Time.Format(time.RFC3339Nano)
+	fields[slog.LevelKey] = r.Level.String()
+	fields[slog.MessageKey] = r.Message
+	return fields
+}
+
+// SlackPayload is an AlertPayload for Slack incoming webhooks and
+// compatible services, such as Mattermost. It posts the level and message
+// followed by one line per attribute.
+func SlackPayload(r slog.Record, fields map[string]any) any {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s* %s", r.Level, r.Message)
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := fields[k]
+		if m, ok := v.(map[string]any); ok {
+			if j, err := json.Marshal(m); err == nil {
+				v = string(j)
+			}
+		}
+		fmt.Fprintf(&b, "\n• `%s`: %v", k, v)
+	}
+	return map[string]string{"text": b.String()}
+}
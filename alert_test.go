@@ -0,0 +1,70 @@
+package xlog_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestAlertHandler(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	h := xlog.NewAlertHandler(srv.URL, nil)
+	logger := slog.New(h).With("service", "shop")
+	logger.Warn("below threshold")
+	for range 3 {
+		logger.Error("db down", "attempt", 1)
+	}
+	logger.Error("disk full")
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("expected one alert per message within the cooldown, got %d: %v", len(bodies), bodies)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(bodies[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["msg"] != "db down" || rec["level"] != "ERROR" || rec["service"] != "shop" {
+		t.Errorf("unexpected alert: %v", rec)
+	}
+}
+
+func TestSlackPayload(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	h := xlog.NewAlertHandler(srv.URL, &xlog.AlertHandlerOptions{Payload: xlog.SlackPayload})
+	slog.New(h).Error("payment failed", "order_id", "o-1")
+	_ = h.Close()
+
+	var msg struct{ Text string }
+	if err := json.Unmarshal(body, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg.Text, "*ERROR* payment failed") || !strings.Contains(msg.Text, "`order_id`: o-1") {
+		t.Errorf("unexpected Slack text: %q", msg.Text)
+	}
+}
@@ -0,0 +1,170 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ErrorKey is the attribute key used by Err.
+const ErrorKey = "error"
+
+// String returns an attribute for a string value.
+func String(key, value string) slog.Attr { return slog.String(key, value) }
+
+// Int returns an attribute for an int value.
+func Int(key string, value int) slog.Attr { return slog.Int(key, value) }
+
+// Int64 returns an attribute for an int64 value.
+func Int64(key string, value int64) slog.Attr { return slog.Int64(key, value) }
+
+// Uint64 returns an attribute for a uint64 value.
+func Uint64(key string, value uint64) slog.Attr { return slog.Uint64(key, value) }
+
+// Float64 returns an attribute for a float64 value.
+func Float64(key string, value float64) slog.Attr { return slog.Float64(key, value) }
+
+// Bool returns an attribute for a bool value.
+func Bool(key string, value bool) slog.Attr { return slog.Bool(key, value) }
+
+// Time returns an attribute for a time.Time value.
+func Time(key string, value time.Time) slog.Attr { return slog.Time(key, value) }
+
+// Dur returns an attribute for a time.Duration value.
+func Dur(key string, value time.Duration) slog.Attr { return slog.Duration(key, value) }
+
+// Any returns an attribute for any value, as slog.Any does.
+func Any(key string, value any) slog.Attr { return slog.Any(key, value) }
+
+// Group returns an attribute grouping args, as slog.Group does.
+func Group(key string, args ...any) slog.Attr { return slog.Group(key, args...) }
+
+// Err returns an "error" attribute for err. A nil error gives an empty
+// attribute, which handlers drop.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	return slog.Any(ErrorKey, err)
+}
+
+// expandJoinedErrors writes an error joining others, as errors.Join does,
+// as a group holding its message and each error under "causes", so they
+// stay searchable one by one: "error.causes.0", "error.causes.1" and so
+// on. Causes joining others are expanded in turn.
+func expandJoinedErrors(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	joined, ok := a.Value.Any().(interface {
+		error
+		Unwrap() []error
+	})
+	if !ok {
+		return a
+	}
+	var causes []slog.Attr
+	for _, err := range joined.Unwrap() {
+		if err != nil {
+			causes = append(causes, slog.Any(strconv.Itoa(len(causes)), err))
+		}
+	}
+	return slog.Group(a.Key,
+		slog.String("message", joined.Error()),
+		slog.Attr{Key: "causes", Value: slog.GroupValue(causes...)},
+	)
+}
+
+// Stringer returns an attribute whose value is s.String(), called only
+// when the record is written.
+func Stringer(key string, s fmt.Stringer) slog.Attr {
+	return slog.Any(key, stringerValue{s})
+}
+
+// JSON returns an attribute embedding raw, already marshaled JSON. JSON
+// handlers write it as a nested value instead of a quoted string; invalid
+// JSON is written as a string. ColorHandler writes it compacted onto one
+// line, or indented below the record with ColorOptions.Multiline.
+func JSON(key string, raw []byte) slog.Attr {
+	return slog.Any(key, jsonValue(raw))
+}
+
+// RawJSON is JSON, named after json.RawMessage, for payloads already
+// serialized by other systems.
+func RawJSON(key string, raw []byte) slog.Attr {
+	return JSON(key, raw)
+}
+
+// BinaryLimit is the number of bytes Hex and Base64 render. Longer data
+// is cut, and the rendering ends with its full length, such as
+// "…(4096 bytes)".
+const BinaryLimit = 256
+
+// Hex returns an attribute rendering b as a hexadecimal string, cut at
+// BinaryLimit bytes.
+func Hex(key string, b []byte) slog.Attr {
+	return HexN(key, b, BinaryLimit)
+}
+
+// HexN is like Hex but cuts b at limit bytes; zero or less means no limit.
+func HexN(key string, b []byte, limit int) slog.Attr {
+	return slog.Any(key, binaryValue{b: b, limit: limit, encode: hex.EncodeToString})
+}
+
+// Base64 returns an attribute rendering b as standard base64, cut at
+// BinaryLimit bytes.
+func Base64(key string, b []byte) slog.Attr {
+	return Base64N(key, b, BinaryLimit)
+}
+
+// Base64N is like Base64 but cuts b at limit bytes; zero or less means no
+// limit.
+func Base64N(key string, b []byte, limit int) slog.Attr {
+	return slog.Any(key, binaryValue{b: b, limit: limit, encode: base64.StdEncoding.EncodeToString})
+}
+
+type stringerValue struct{ s fmt.Stringer }
+
+func (v stringerValue) LogValue() slog.Value {
+	return slog.StringValue(v.s.String())
+}
+
+type jsonValue []byte
+
+// MarshalJSON returns the raw JSON compacted onto one line, so it cannot
+// break line-delimited output.
+func (v jsonValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return json.Marshal(string(v))
+	}
+	return buf.Bytes(), nil
+}
+
+// String returns the JSON compacted onto one line, or as written if it is
+// invalid.
+func (v jsonValue) String() string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return string(v)
+	}
+	return buf.String()
+}
+
+type binaryValue struct {
+	b      []byte
+	limit  int
+	encode func([]byte) string
+}
+
+func (v binaryValue) LogValue() slog.Value {
+	if v.limit <= 0 || len(v.b) <= v.limit {
+		return slog.StringValue(v.encode(v.b))
+	}
+	return slog.StringValue(v.encode(v.b[:v.limit]) + "…(" + strconv.Itoa(len(v.b)) + " bytes)")
+}
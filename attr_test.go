@@ -0,0 +1,104 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestAttrConstructors(t *testing.T) {
+	for _, format := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			_ = xlog.Init(
+				xlog.WithFormat(format),
+				xlog.WithOutput(&buf),
+				xlog.WithSource(false),
+			)
+
+			xlog.Info(context.Background(), "attrs",
+				xlog.String("s", "v"),
+				xlog.Int("i", 1),
+				xlog.Bool("b", true),
+				xlog.Dur("d", time.Second),
+				xlog.Err(errors.New("boom")),
+				xlog.Err(nil),
+				xlog.Stringer("ip", net.IPv4(10, 0, 0, 1)),
+				xlog.JSON("payload", []byte("{\n  \"id\": 1\n}")),
+				xlog.JSON("bad", []byte("{oops")),
+				xlog.Hex("raw", []byte{0xde, 0xad}),
+			)
+
+			output := buf.String()
+			want := `"s":"v","i":1,"b":true,"d":1000000000,"error":"boom","ip":"10.0.0.1","payload":{"id":1},"bad":"{oops","raw":"dead"}`
+			if !strings.HasSuffix(strings.TrimSpace(output), want) {
+				t.Errorf("expected suffix %s, got: %s", want, output)
+			}
+		})
+	}
+}
+
+func TestBinaryAttrs(t *testing.T) {
+	data := []byte("hello, world")
+	tests := []struct {
+		attr slog.Attr
+		want string
+	}{
+		{xlog.Hex("h", data[:2]), "6865"},
+		{xlog.HexN("h", data, 4), "68656c6c…(12 bytes)"},
+		{xlog.Base64("b", data), "aGVsbG8sIHdvcmxk"},
+		{xlog.Base64N("b", data, 3), "aGVs…(12 bytes)"},
+		{xlog.HexN("h", data, 0), "68656c6c6f2c20776f726c64"},
+		{xlog.Hex("h", make([]byte, xlog.BinaryLimit+1)), strings.Repeat("00", xlog.BinaryLimit) + "…(257 bytes)"},
+	}
+	for _, tt := range tests {
+		if got := tt.attr.Value.Resolve().String(); got != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, got)
+		}
+	}
+}
+
+func TestRawJSONColor(t *testing.T) {
+	payload := []byte("{\"id\": 1,\n \"tags\": [\"a\"]}")
+
+	var buf bytes.Buffer
+	slog.New(xlog.NewColorHandler(&buf, nil)).Info("raw", xlog.RawJSON("payload", payload))
+	if got := ansi.ReplaceAllString(buf.String(), ""); !strings.HasSuffix(got, `payload={"id":1,"tags":["a"]}`+"\n") {
+		t.Errorf("expected compact JSON, got: %s", got)
+	}
+
+	buf.Reset()
+	slog.New(xlog.NewColorHandler(&buf, nil).WithColorOptions(&xlog.ColorOptions{Multiline: true})).Info("raw", xlog.RawJSON("payload", payload))
+	want := "    payload:\n        {\n          \"id\": 1,\n          \"tags\": [\n            \"a\"\n          ]\n        }\n"
+	if got := ansi.ReplaceAllString(buf.String(), ""); !strings.HasSuffix(got, want) {
+		t.Errorf("expected indented JSON, got: %s", got)
+	}
+}
+
+func TestJoinedErrors(t *testing.T) {
+	for _, format := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			_ = xlog.Init(
+				xlog.WithFormat(format),
+				xlog.WithOutput(&buf),
+				xlog.WithSource(false),
+			)
+
+			a, b, c := errors.New("a"), errors.New("b"), errors.New("c")
+			xlog.Error(context.Background(), "workers failed", xlog.Err(errors.Join(a, nil, errors.Join(b, c))))
+
+			want := `"error":{"message":"a\nb\nc","causes":{"0":"a","1":{"message":"b\nc","causes":{"0":"b","1":"c"}}}}}`
+			if !strings.HasSuffix(strings.TrimSpace(buf.String()), want) {
+				t.Errorf("expected suffix %s, got: %s", want, buf.String())
+			}
+		})
+	}
+}
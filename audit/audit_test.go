@@ -0,0 +1,77 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/audit"
+)
+
+func writeTrail(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	l := audit.New(&buf)
+	ctx := xlog.WithUserID(context.Background(), "alice")
+	for _, action := range []string{"login", "user.update", "logout"} {
+		if err := l.Log(ctx, audit.Event{Action: action, Details: map[string]any{"n": 1}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	trail := writeTrail(t)
+	if err := audit.Verify(bytes.NewReader(trail)); err != nil {
+		t.Fatalf("expected a valid trail, got: %v", err)
+	}
+	if !bytes.Contains(trail, []byte(`"actor":"alice"`)) {
+		t.Errorf("expected actor from context, got: %s", trail)
+	}
+
+	lines := strings.SplitAfter(string(trail), "\n")
+	tampered := map[string]string{
+		"edited":    strings.Replace(string(trail), "user.update", "user.delete", 1),
+		"removed":   lines[0] + lines[2],
+		"reordered": lines[1] + lines[0] + lines[2],
+	}
+	for name, s := range tampered {
+		err := audit.Verify(strings.NewReader(s))
+		if !errors.Is(err, audit.ErrTampered) {
+			t.Errorf("%s: expected ErrTampered, got: %v", name, err)
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		l, err := audit.OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Log(ctx, audit.Event{Actor: "bob", Action: "deploy"}); err != nil {
+			t.Fatal(err)
+		}
+		_ = l.Close()
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := audit.Verify(bytes.NewReader(b)); err != nil {
+		t.Errorf("expected reopened trail to continue the chain, got: %v", err)
+	}
+	if !bytes.Contains(b, []byte(`"seq":2`)) {
+		t.Errorf("expected sequence to continue, got: %s", b)
+	}
+}
@@ -0,0 +1,321 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BridgeWriterOptions configures a BridgeWriter.
+type BridgeWriterOptions struct {
+	// Level is the level of lines without a recognized level. Defaults to
+	// slog.LevelInfo.
+	Level slog.Leveler
+}
+
+// BridgeWriter is an io.Writer that turns JSON lines written by another
+// logging library into xlog records, so its output goes through xlog's
+// handlers, context enrichment and outputs. The level, message and time
+// are read from the line; the remaining fields become attributes. Lines
+// that are not JSON objects are logged as messages.
+//
+// For zap, write JSON to a BridgeWriter from a zapcore.Core:
+//
+//	core := zapcore.NewCore(
+//		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+//		zapcore.AddSync(xlog.NewBridgeWriter(nil, nil)),
+//		zap.DebugLevel,
+//	)
+//	logger := zap.New(core)
+//
+// For logrus, use its JSON formatter:
+//
+//	logrus.SetFormatter(&logrus.JSONFormatter{})
+//	logrus.SetOutput(xlog.NewBridgeWriter(nil, nil))
+type BridgeWriter struct {
+	logger *Logger
+	level  slog.Leveler
+	lines  lineBuffer
+}
+
+// NewBridgeWriter creates a BridgeWriter logging to l, or to the default
+// logger at the time of each write if l is nil.
+func NewBridgeWriter(l *Logger, opts *BridgeWriterOptions) *BridgeWriter {
+	var o BridgeWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	return &BridgeWriter{logger: l, level: o.Level}
+}
+
+// Write logs each complete line in p, keeping a trailing partial line
+// until the rest of it is written.
+func (w *BridgeWriter) Write(p []byte) (int, error) {
+	w.lines.write(p, w.logLine)
+	return len(p), nil
+}
+
+func (w *BridgeWriter) logLine(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+	l := orDefault(w.logger)
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if line[0] != '{' || dec.Decode(&fields) != nil {
+		bridgeLog(l, time.Now(), w.level.Level(), string(line), nil)
+		return
+	}
+
+	level := w.level.Level()
+	if s, ok := fields["level"].(string); ok {
+		if lv, ok := bridgeLevel(s); ok {
+			level = lv
+			delete(fields, "level")
+		}
+	}
+	var msg string
+	for _, key := range []string{"msg", "message"} {
+		if s, ok := fields[key].(string); ok {
+			msg = s
+			delete(fields, key)
+			break
+		}
+	}
+	t := time.Now()
+	for _, key := range []string{"ts", "time"} {
+		if ft, ok := bridgeTime(fields[key]); ok {
+			t = ft
+			delete(fields, key)
+			break
+		}
+	}
+
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		attrs = append(attrs, slog.Any(k, bridgeValue(fields[k])))
+	}
+	bridgeLog(l, t, level, msg, attrs)
+}
+
+func bridgeLog(l *Logger, t time.Time, level slog.Level, msg string, attrs []slog.Attr) {
+	ctx := context.Background()
+	h := l.Handler()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = h.Handle(ctx, r)
+}
+
+// LevelWriter is an io.Writer that logs each line written to it, at the
+// level named by a prefix of the line if it has one, such as "ERROR:",
+// "[warn]" or "level=debug". It suits the output of subprocesses and
+// embedded servers:
+//
+//	cmd.Stdout = xlog.NewLevelWriter(xlog.Named("worker"), slog.LevelInfo)
+//	cmd.Stderr = xlog.NewLevelWriter(xlog.Named("worker"), slog.LevelWarn)
+type LevelWriter struct {
+	logger *Logger
+	level  slog.Level
+	lines  lineBuffer
+}
+
+// NewLevelWriter creates a LevelWriter logging to l, or to the default
+// logger at the time of each write if l is nil. Lines without a level
+// prefix are logged at level.
+func NewLevelWriter(l *Logger, level slog.Level) *LevelWriter {
+	return &LevelWriter{logger: l, level: level}
+}
+
+// Write logs each complete line in p, keeping a trailing partial line
+// until the rest of it is written or Close is called.
+func (w *LevelWriter) Write(p []byte) (int, error) {
+	w.lines.write(p, w.logLine)
+	return len(p), nil
+}
+
+// Close logs the trailing partial line, if any.
+func (w *LevelWriter) Close() error {
+	w.lines.flush(w.logLine)
+	return nil
+}
+
+func (w *LevelWriter) logLine(line []byte) {
+	msg := strings.TrimRight(string(line), "\r")
+	if strings.TrimSpace(msg) == "" {
+		return
+	}
+	level, rest, ok := detectLevel(msg)
+	if !ok {
+		level = w.level
+	}
+	bridgeLog(orDefault(w.logger), time.Now(), level, rest, nil)
+}
+
+// detectLevel finds a level in the first words of line, written as
+// "[LEVEL]", "LEVEL:" or an uppercase "LEVEL", and returns the line
+// without it. It also finds "level=" and "lvl=" anywhere in the line,
+// as written by logfmt, leaving the line unchanged.
+func detectLevel(line string) (slog.Level, string, bool) {
+	rest := line
+	for range 4 {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		word, after, _ := strings.Cut(rest, " ")
+		var name string
+		switch {
+		case word[0] == '[':
+			if i := strings.IndexByte(rest, ']'); i > 0 {
+				name, after = rest[1:i], strings.TrimPrefix(rest[i+1:], ":")
+			}
+		case strings.Contains(word, ":"):
+			name, after, _ = strings.Cut(rest, ":")
+		case word == strings.ToUpper(word):
+			name = word
+		}
+		if level, ok := bridgeLevel(name); ok {
+			prefix := line[:len(line)-len(rest)]
+			return level, prefix + strings.TrimLeft(after, " \t"), true
+		}
+		rest = rest[len(word):]
+	}
+
+	for _, field := range strings.Fields(line) {
+		k, v, ok := strings.Cut(field, "=")
+		if ok && (k == "level" || k == "lvl") {
+			if level, ok := bridgeLevel(strings.Trim(v, `"`)); ok {
+				return level, line, true
+			}
+		}
+	}
+	return 0, line, false
+}
+
+// orDefault returns l, or the current default logger if l is nil.
+func orDefault(l *Logger) *Logger {
+	if l == nil {
+		return Default()
+	}
+	return l
+}
+
+// lineBuffer splits writes into lines.
+type lineBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// write calls fn with each complete line, without its newline, keeping a
+// trailing partial line for the next write.
+func (b *lineBuffer) write(p []byte, fn func(line []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	for {
+		i := bytes.IndexByte(b.buf, '\n')
+		if i < 0 {
+			break
+		}
+		fn(b.buf[:i])
+		b.buf = b.buf[i+1:]
+	}
+	if len(b.buf) == 0 {
+		b.buf = nil
+	}
+}
+
+// flush calls fn with the partial line, if any.
+func (b *lineBuffer) flush(fn func(line []byte)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.buf) > 0 {
+		fn(b.buf)
+		b.buf = nil
+	}
+}
+
+// bridgeLevels maps the level names of other logging libraries to levels.
+var bridgeLevels = map[string]slog.Level{
+	"trace":    LevelTrace,
+	"debug":    slog.LevelDebug,
+	"dbg":      slog.LevelDebug,
+	"info":     slog.LevelInfo,
+	"inf":      slog.LevelInfo,
+	"warn":     slog.LevelWarn,
+	"warning":  slog.LevelWarn,
+	"wrn":      slog.LevelWarn,
+	"error":    slog.LevelError,
+	"err":      slog.LevelError,
+	"dpanic":   LevelCritical,
+	"panic":    LevelCritical,
+	"fatal":    LevelCritical,
+	"crit":     LevelCritical,
+	"critical": LevelCritical,
+}
+
+func bridgeLevel(s string) (slog.Level, bool) {
+	level, ok := bridgeLevels[strings.ToLower(s)]
+	return level, ok
+}
+
+// bridgeTime parses a time written as seconds since the Unix epoch, as
+// zap's production encoder does, or as an RFC 3339 or ISO 8601 string.
+func bridgeTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.000Z0700"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// bridgeValue converts JSON numbers to int64 or float64, in nested values
+// too.
+func bridgeValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = bridgeValue(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = bridgeValue(e)
+		}
+	}
+	return v
+}
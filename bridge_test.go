@@ -0,0 +1,112 @@
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestBridgeWriter(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	w := xlog.NewBridgeWriter(nil, nil)
+	// A zap production line, written in two parts
+	line := `{"level":"warn","ts":1705314645.123,"logger":"db","msg":"slow query","rows":42,"meta":{"ms":1.5}}` + "\n"
+	_, _ = io.WriteString(w, line[:20])
+	_, _ = io.WriteString(w, line[20:]+"not json\n")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got: %s", buf.String())
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "slow query" || rec["logger"] != "db" || rec["rows"] != float64(42) {
+		t.Errorf("unexpected record: %s", lines[0])
+	}
+	if !strings.HasPrefix(rec["time"].(string), "2024-01-15T") || rec["ts"] != nil {
+		t.Errorf("expected the time from ts, got: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"level":"INFO","msg":"not json"`) {
+		t.Errorf("expected a plain line at INFO, got: %s", lines[1])
+	}
+}
+
+func TestBridgeWriterLogrus(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	w := xlog.NewBridgeWriter(nil, nil)
+	_, _ = io.WriteString(w, `{"level":"warning","msg":"disk low","time":"2024-01-15T10:30:45+09:00","free":"2GB"}`+"\n")
+	_, _ = io.WriteString(w, `{"level":"trace","msg":"hidden","time":"2024-01-15T10:30:45+09:00"}`+"\n")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected one record: %v: %s", err, buf.String())
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "disk low" || rec["free"] != "2GB" {
+		t.Errorf("unexpected record: %s", buf.String())
+	}
+	if !strings.HasPrefix(rec["time"].(string), "2024-01-15T10:30:45") {
+		t.Errorf("expected the time from the line, got: %s", buf.String())
+	}
+}
+
+func TestLevelWriter(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithLevel(slog.LevelDebug),
+	)
+
+	w := xlog.NewLevelWriter(nil, slog.LevelInfo)
+	_, _ = io.WriteString(w, "ERROR: disk full\n[warn] retrying\n")
+	_, _ = io.WriteString(w, "2024/01/15 10:30:45 [debug] dialing\n")
+	_, _ = io.WriteString(w, "time=now level=debug msg=hi\nserver started\npartial")
+	_ = w.Close()
+
+	tests := []struct{ level, msg string }{
+		{"ERROR", "disk full"},
+		{"WARN", "retrying"},
+		{"DEBUG", "2024/01/15 10:30:45 dialing"},
+		{"DEBUG", "time=now level=debug msg=hi"},
+		{"INFO", "server started"},
+		{"INFO", "partial"},
+	}
+	dec := json.NewDecoder(&buf)
+	for _, tt := range tests {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec["level"] != tt.level || rec["msg"] != tt.msg {
+			t.Errorf("expected %s %q, got: %v", tt.level, tt.msg, rec)
+		}
+	}
+}
+
+func TestBridgeWriterEcho(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	w := xlog.NewBridgeWriter(nil, nil)
+	_, _ = io.WriteString(w, `{"time":"2024-01-15T10:30:45.123456789Z","level":"ERROR","prefix":"echo","file":"echo.go","line":"42","message":"bind failed"}`+"\n")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["level"] != "ERROR" || rec["msg"] != "bind failed" || rec["prefix"] != "echo" || rec["message"] != nil {
+		t.Errorf("unexpected record: %s", buf.String())
+	}
+}
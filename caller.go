@@ -0,0 +1,138 @@
+package xlog
+
+import (
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// helpers holds the names of functions marked with Helper
+	helpers    sync.Map
+	hasHelpers atomic.Bool
+)
+
+// SourceFormat renders a source location as the value of the source
+// attribute.
+type SourceFormat func(src *slog.Source) string
+
+// Built-in source formats for WithSourceFormat.
+var (
+	// SourceShort renders the file name and line, e.g. "handler.go:42".
+	SourceShort SourceFormat = func(src *slog.Source) string {
+		return filepath.Base(src.File) + ":" + strconv.Itoa(src.Line)
+	}
+
+	// SourceFull renders the full file path and line.
+	SourceFull SourceFormat = func(src *slog.Source) string {
+		return src.File + ":" + strconv.Itoa(src.Line)
+	}
+
+	// SourceFunction renders the package-qualified function name and
+	// line, e.g. "orders.(*Service).Place:42".
+	SourceFunction SourceFormat = func(src *slog.Source) string {
+		return shortFunction(src.Function) + ":" + strconv.Itoa(src.Line)
+	}
+)
+
+// shortFunction trims the import path from a function name, leaving the
+// package name, e.g. "orders.(*Service).Place".
+func shortFunction(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// WithSourceFormat renders the source attribute as a string using f
+// instead of the handler's default, which is the short file name for
+// ColorText and a {function, file, line} object for the JSON formats.
+// Like WithAttrTransformers, it does not apply to a handler set with
+// WithHandler.
+func WithSourceFormat(f SourceFormat) Option {
+	return func(c *config) {
+		c.sourceFormat = f
+	}
+}
+
+// sourceTransformer applies f to the top-level source attribute.
+func sourceTransformer(f SourceFormat) AttrTransformer {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key != slog.SourceKey || len(groups) > 0 {
+			return a
+		}
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.String(slog.SourceKey, f(src))
+		}
+		return a
+	}
+}
+
+// WithCallerSkip skips n more stack frames when recording the source
+// location of the default logger's records, for packages that wrap xlog
+// in their own logging functions.
+func WithCallerSkip(n int) Option {
+	return func(c *config) {
+		c.callerSkip = n
+	}
+}
+
+// WithCallerSkip returns a logger that skips n more stack frames when
+// recording the source location, for use by a wrapper n calls deep.
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	l2 := l.derive(l.Logger)
+	l2.callerSkip += n
+	return l2
+}
+
+// Helper marks the calling function as a logging helper, like
+// testing.T.Helper: records logged through it report the location of
+// the helper's caller instead.
+func Helper() {
+	markHelper()
+}
+
+// Helper marks the calling function as a logging helper. It is the same
+// as the package-level Helper; helpers apply to every logger.
+func (l *Logger) Helper() {
+	markHelper()
+}
+
+func markHelper() {
+	// Skip runtime.Callers, markHelper, and Helper
+	var pcs [1]uintptr
+	if runtime.Callers(3, pcs[:]) == 0 {
+		return
+	}
+	f, _ := runtime.CallersFrames(pcs[:]).Next()
+	if _, loaded := helpers.LoadOrStore(f.Function, struct{}{}); !loaded {
+		hasHelpers.Store(true)
+	}
+}
+
+// callerPC returns the program counter of the first caller, skip frames
+// up, that is not a helper.
+func callerPC(skip int) uintptr {
+	if !hasHelpers.Load() {
+		var pcs [1]uintptr
+		runtime.Callers(skip+1, pcs[:])
+		return pcs[0]
+	}
+
+	var pcs [16]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	for _, pc := range pcs[:n] {
+		f, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if _, ok := helpers.Load(f.Function); !ok {
+			return pc
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return pcs[n-1]
+}
@@ -0,0 +1,85 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func logViaSkip(ctx context.Context, l *xlog.Logger, msg string) {
+	l.WithCallerSkip(1).Info(ctx, msg)
+}
+
+func logViaHelper(ctx context.Context, msg string) {
+	xlog.Helper()
+	xlog.Info(ctx, msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+	)
+
+	ctx := context.Background()
+	_, _, line, _ := runtime.Caller(0)
+	logViaSkip(ctx, xlog.Default(), "skip")
+	logViaHelper(ctx, "helper")
+
+	dec := json.NewDecoder(&buf)
+	for i, msg := range []string{"skip", "helper"} {
+		var rec struct {
+			Msg    string
+			Source struct{ Line int }
+		}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Msg != msg || rec.Source.Line != line+1+i {
+			t.Errorf("%s: expected source line %d, got %d", msg, line+1+i, rec.Source.Line)
+		}
+	}
+}
+
+func TestWithSourceFormat(t *testing.T) {
+	_, file, line, _ := runtime.Caller(0)
+	tests := []struct {
+		name   string
+		format xlog.SourceFormat
+		want   string
+	}{
+		{"short", xlog.SourceShort, "caller_test.go"},
+		{"full", xlog.SourceFull, file},
+		{"function", xlog.SourceFunction, "xlog_test.TestWithSourceFormat.func"},
+		{"custom", func(src *slog.Source) string { return "at line:" + strconv.Itoa(src.Line) }, "at line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_ = xlog.Init(
+				xlog.WithFormat(xlog.StdJSON),
+				xlog.WithOutput(&buf),
+				xlog.WithSourceFormat(tt.format),
+			)
+			_, _, line, _ = runtime.Caller(0)
+			xlog.Info(context.Background(), "hello")
+
+			var rec struct{ Source string }
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			suffix := ":" + strconv.Itoa(line+1)
+			if !strings.HasPrefix(rec.Source, tt.want) || !strings.HasSuffix(rec.Source, suffix) {
+				t.Errorf("expected source %q...%q, got %q", tt.want, suffix, rec.Source)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package xlog
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CloudTraceHeader is the trace header set by Google Cloud load balancers,
+// Cloud Run and App Engine: "TRACE_ID/SPAN_ID;o=OPTIONS".
+const CloudTraceHeader = "X-Cloud-Trace-Context"
+
+// CloudTraceMiddleware reads the trace of the request from the
+// X-Cloud-Trace-Context header, or from a W3C traceparent header, and adds
+// it to the context, both as the trace ID and in the fields Cloud Logging
+// uses to group records under their trace. projectID defaults to the
+// GOOGLE_CLOUD_PROJECT environment variable:
+//
+//	http.ListenAndServe(":8080", xlog.CloudTraceMiddleware(mux, ""))
+func CloudTraceMiddleware(next http.Handler, projectID string) http.Handler {
+	if projectID == "" {
+		projectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, spanID := parseCloudTrace(r.Header.Get(CloudTraceHeader))
+		if traceID == "" {
+			traceID, spanID = parseTraceparent(r.Header.Get("traceparent"))
+		}
+		if traceID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := WithTraceID(r.Context(), traceID)
+		if projectID != "" {
+			ctx = WithContext(ctx, CloudTraceKey, "projects/"+projectID+"/traces/"+traceID)
+		}
+		if spanID != "" {
+			ctx = WithContext(ctx, CloudSpanIDKey, spanID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// parseCloudTrace parses an X-Cloud-Trace-Context header, returning the
+// span ID in hex as Cloud Logging expects instead of decimal.
+func parseCloudTrace(h string) (traceID, spanID string) {
+	h, _, _ = strings.Cut(h, ";")
+	traceID, span, _ := strings.Cut(h, "/")
+	if !isHex(traceID, 32) {
+		return "", ""
+	}
+	if n, err := strconv.ParseUint(span, 10, 64); err == nil && n != 0 {
+		spanID = fmt.Sprintf("%016x", n)
+	}
+	return strings.ToLower(traceID), spanID
+}
+
+// parseTraceparent parses a W3C traceparent header,
+// "00-TRACE_ID-SPAN_ID-FLAGS".
+func parseTraceparent(h string) (traceID, spanID string) {
+	parts := strings.Split(h, "-")
+	if len(parts) < 4 || !isHex(parts[1], 32) || !isHex(parts[2], 16) {
+		return "", ""
+	}
+	return strings.ToLower(parts[1]), strings.ToLower(parts[2])
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package xlog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestCloudTraceMiddleware(t *testing.T) {
+	tests := []struct {
+		name, header, value     string
+		trace, cloudTrace, span string
+	}{
+		{"cloud", xlog.CloudTraceHeader, "105445AA7843BC8BF206B12000100000/1;o=1",
+			"105445aa7843bc8bf206b12000100000", "projects/demo/traces/105445aa7843bc8bf206b12000100000", "0000000000000001"},
+		{"traceparent", "traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"4bf92f3577b34da6a3ce929d0e0e4736", "projects/demo/traces/4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"},
+		{"malformed", xlog.CloudTraceHeader, "not-a-trace", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctx context.Context
+			h := xlog.CloudTraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctx = r.Context()
+			}), "demo")
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			got := func(key xlog.ContextKey) string {
+				s, _ := ctx.Value(key).(string)
+				return s
+			}
+			if got(xlog.TraceIDKey) != tt.trace || got(xlog.CloudTraceKey) != tt.cloudTrace || got(xlog.CloudSpanIDKey) != tt.span {
+				t.Errorf("unexpected context: trace=%q cloud=%q span=%q",
+					got(xlog.TraceIDKey), got(xlog.CloudTraceKey), got(xlog.CloudSpanIDKey))
+			}
+		})
+	}
+}
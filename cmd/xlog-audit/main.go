@@ -0,0 +1,42 @@
+// Command xlog-audit verifies audit trails written by the audit package.
+//
+// Usage:
+//
+//	xlog-audit verify FILE...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/taro33333/xlog/audit"
+)
+
+func main() {
+	if len(os.Args) < 3 || os.Args[1] != "verify" {
+		fmt.Fprintln(os.Stderr, "usage: xlog-audit verify FILE...")
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, path := range os.Args[2:] {
+		if err := verifyFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: OK\n", path)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func verifyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return audit.Verify(f)
+}
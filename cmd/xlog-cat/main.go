@@ -0,0 +1,266 @@
+// Command xlog-cat pretty-prints JSON logs with xlog's development format.
+//
+// It reads production JSON logs from files or standard input and renders
+// each record the way ColorHandler would. Lines that aren't JSON objects
+// are printed unchanged.
+//
+// Usage:
+//
+//	xlog-cat [-level warn] [-filter EXPR] [-attrs trace_id,user_id] [FILE...]
+//
+// The -filter expression uses the syntax of xlog.ParseFilter, e.g.
+//
+//	xlog-cat -filter 'level>=warn && msg contains "timeout"' app.log
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func main() {
+	level := flag.String("level", "debug", "minimum level to show (debug, info, warn, error)")
+	attrs := flag.String("attrs", "", "comma-separated top-level attributes to show (default all)")
+	filter := flag.String("filter", "", "show only records matching the filter expression")
+	flag.Parse()
+
+	minLevel, err := xlog.ParseLevel(*level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "xlog-cat: invalid level %q\n", *level)
+		os.Exit(2)
+	}
+
+	c := &cat{
+		handler: xlog.NewColorHandler(os.Stdout, &slog.HandlerOptions{Level: minLevel}),
+		out:     os.Stdout,
+	}
+	if *filter != "" {
+		f, err := xlog.ParseFilter(*filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "xlog-cat: %v\n", err)
+			os.Exit(2)
+		}
+		c.filter = f
+	}
+	if *attrs != "" {
+		c.keep = make(map[string]bool)
+		for _, k := range strings.Split(*attrs, ",") {
+			c.keep[strings.TrimSpace(k)] = true
+		}
+	}
+
+	if flag.NArg() == 0 {
+		if err := c.run(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "xlog-cat: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := c.runFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "xlog-cat: %v\n", err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// cat renders log lines through a handler.
+type cat struct {
+	handler slog.Handler
+	out     io.Writer
+	filter  *xlog.Filter
+	// keep is the set of attributes to show, or nil for all
+	keep map[string]bool
+}
+
+func (c *cat) runFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.run(f)
+}
+
+func (c *cat) run(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	ctx := context.Background()
+	for sc.Scan() {
+		line := sc.Bytes()
+		rec, ok := parseRecord(line)
+		if !ok {
+			if _, err := fmt.Fprintf(c.out, "%s\n", line); err != nil {
+				return err
+			}
+			continue
+		}
+		if !c.handler.Enabled(ctx, rec.Level) || c.filter != nil && !c.filter.Match(rec) {
+			continue
+		}
+		if c.keep != nil {
+			rec = c.selectAttrs(rec)
+		}
+		if err := c.handler.Handle(ctx, rec); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// selectAttrs returns a copy of r with only the attributes in c.keep.
+func (c *cat) selectAttrs(r slog.Record) slog.Record {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, 0)
+	r.Attrs(func(a slog.Attr) bool {
+		if c.keep[a.Key] {
+			r2.AddAttrs(a)
+		}
+		return true
+	})
+	return r2
+}
+
+// parseRecord converts a JSON log line into a record, keeping attributes
+// in their original order. It reports false for lines that aren't objects.
+func parseRecord(line []byte) (slog.Record, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return slog.Record{}, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return slog.Record{}, false
+	}
+	attrs, err := decodeObject(dec)
+	if err != nil {
+		return slog.Record{}, false
+	}
+
+	var (
+		t     time.Time
+		level = slog.LevelInfo
+		msg   string
+		rest  = make([]slog.Attr, 0, len(attrs))
+	)
+	for _, a := range attrs {
+		switch a.Key {
+		case slog.TimeKey:
+			if parsed, err := time.Parse(time.RFC3339Nano, a.Value.String()); err == nil {
+				t = parsed.Local()
+				continue
+			}
+		case slog.LevelKey:
+			if parsed, err := xlog.ParseLevel(a.Value.String()); err == nil {
+				level = parsed
+				continue
+			}
+		case slog.MessageKey:
+			msg = a.Value.String()
+			continue
+		case slog.SourceKey:
+			if a.Value.Kind() == slog.KindGroup {
+				a = slog.String(slog.SourceKey, formatSource(a.Value.Group()))
+			}
+		}
+		rest = append(rest, a)
+	}
+
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(rest...)
+	return r, true
+}
+
+// formatSource renders a source object as file:line.
+func formatSource(attrs []slog.Attr) string {
+	var file, line string
+	for _, a := range attrs {
+		switch a.Key {
+		case "file":
+			file = a.Value.String()
+		case "line":
+			line = a.Value.String()
+		}
+	}
+	return file + ":" + line
+}
+
+// decodeObject reads the members of an object whose opening brace has
+// already been consumed.
+func decodeObject(dec *json.Decoder) ([]slog.Attr, error) {
+	var attrs []slog.Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return nil, errors.New("object key is not a string")
+		}
+		v, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: v})
+	}
+	_, err := dec.Token() // closing brace
+	return attrs, err
+}
+
+func decodeValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+	switch x := tok.(type) {
+	case json.Delim:
+		if x == '{' {
+			attrs, err := decodeObject(dec)
+			return slog.GroupValue(attrs...), err
+		}
+		// Arrays are rendered as JSON
+		var items []any
+		for dec.More() {
+			var item any
+			if err := dec.Decode(&item); err != nil {
+				return slog.Value{}, err
+			}
+			items = append(items, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return slog.Value{}, err
+		}
+		b, _ := json.Marshal(items)
+		return slog.StringValue(string(b)), nil
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		f, _ := x.Float64()
+		return slog.Float64Value(f), nil
+	case string:
+		return slog.StringValue(x), nil
+	case bool:
+		return slog.BoolValue(x), nil
+	default:
+		return slog.AnyValue(nil), nil
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+var ansi = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestCat(t *testing.T) {
+	input := strings.Join([]string{
+		`{"time":"2024-01-15T10:30:45Z","level":"INFO","source":{"function":"main.run","file":"/app/main.go","line":42},"msg":"request handled","status":200,"http":{"method":"GET","path":"/users"},"trace_id":"abc"}`,
+		`{"time":"2024-01-15T10:30:46Z","level":"DEBUG","msg":"cache miss","key":"user:1"}`,
+		`{"time":"2024-01-15T10:30:47Z","level":"ERROR","msg":"query failed","error":"timeout","tags":["db","slow"]}`,
+		`panic: not json`,
+	}, "\n")
+
+	var out bytes.Buffer
+	c := &cat{
+		handler: xlog.NewColorHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		out:     &out,
+	}
+	if err := c.run(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(ansi.ReplaceAllString(out.String(), "")), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got:\n%s", out.String())
+	}
+	for i, want := range []string{
+		`INF request handled source=/app/main.go:42 status=200 http.method=GET http.path=/users trace_id=abc`,
+		`ERR query failed error=timeout tags="[\"db\",\"slow\"]"`,
+		`panic: not json`,
+	} {
+		if !strings.HasSuffix(lines[i], want) {
+			t.Errorf("line %d:\n got: %s\nwant suffix: %s", i, lines[i], want)
+		}
+	}
+
+	out.Reset()
+	c.filter, _ = xlog.ParseFilter(`http.method == "GET"`)
+	_ = c.run(strings.NewReader(input))
+	if got := ansi.ReplaceAllString(out.String(), ""); strings.Count(got, "\n") != 2 || !strings.Contains(got, "request handled") {
+		t.Errorf("expected only matching records and non-JSON lines, got: %s", got)
+	}
+
+	out.Reset()
+	c.filter = nil
+	c.keep = map[string]bool{"trace_id": true}
+	_ = c.run(strings.NewReader(input))
+	if got := ansi.ReplaceAllString(out.String(), ""); !strings.Contains(got, "request handled trace_id=abc\n") {
+		t.Errorf("expected only selected attrs, got: %s", got)
+	}
+}
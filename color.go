@@ -0,0 +1,149 @@
+package xlog
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// ColorOptions configures the layout of ColorHandler.
+type ColorOptions struct {
+	// Align pads the time, level, source and message into columns, so
+	// the attributes of consecutive records line up. Times are aligned
+	// to the right; columns widen to the widest value seen so far.
+	Align bool
+
+	// Icons prefixes each record with the icon of the highest level in
+	// the map at or below the record's level, such as DefaultIcons.
+	Icons map[slog.Level]string
+
+	// Time renders the time column instead of the wall clock, such as
+	// TimeSinceStart or TimeSincePrevious().
+	Time TimeFormat
+
+	// Multiline writes string values containing newlines, such as stack
+	// traces and SQL, as indented lines below the record instead of
+	// quoting them into it.
+	Multiline bool
+}
+
+// DefaultIcons returns icons for the four slog levels: ✖ for ERROR, ⚠ for
+// WARN, ℹ for INFO and 🐛 for DEBUG.
+func DefaultIcons() map[slog.Level]string {
+	return map[slog.Level]string{
+		slog.LevelError: "✖",
+		slog.LevelWarn:  "⚠",
+		slog.LevelInfo:  "ℹ",
+		slog.LevelDebug: "🐛",
+	}
+}
+
+// icon returns the icon for records at level, if any.
+func (o *ColorOptions) icon(level slog.Level) (string, bool) {
+	var (
+		icon  string
+		found bool
+		best  slog.Level
+	)
+	for l, s := range o.Icons {
+		if l <= level && (!found || l > best) {
+			icon, found, best = s, true, l
+		}
+	}
+	return icon, found
+}
+
+// WithColorOptions returns a copy of h laid out as opts describes.
+func (h *ColorHandler) WithColorOptions(opts *ColorOptions) *ColorHandler {
+	h2 := *h
+	h2.color = ColorOptions{}
+	if opts != nil {
+		h2.color = *opts
+	}
+	h2.columns = &colorColumns{}
+	return &h2
+}
+
+// WithColorOptions configures the layout of the ColorText format.
+func WithColorOptions(opts *ColorOptions) Option {
+	return func(c *config) {
+		c.colorOptions = opts
+	}
+}
+
+// maxAlignedMessage is the widest message that widens the message
+// column; longer messages push their attributes right instead.
+const maxAlignedMessage = 40
+
+// colorColumns tracks the widths of the aligned columns, shared by a
+// ColorHandler and the handlers derived from it.
+type colorColumns struct {
+	time, level, source, message atomic.Int64
+}
+
+// fit widens col to the width of text, up to limit if it is positive,
+// and returns the column width.
+func fit(col *atomic.Int64, text []byte, limit int) int {
+	n := int64(utf8.RuneCount(text))
+	if limit > 0 && n > int64(limit) {
+		return int(col.Load())
+	}
+	for {
+		cur := col.Load()
+		if n <= cur || col.CompareAndSwap(cur, n) {
+			return int(max(cur, n))
+		}
+	}
+}
+
+// padRight appends spaces after the text buf[start:end] to fill width.
+func padRight(buf []byte, start, end, width int) []byte {
+	for n := utf8.RuneCount(buf[start:end]); n < width; n++ {
+		buf = append(buf, ' ')
+	}
+	return buf
+}
+
+// padLeft inserts spaces before the text buf[start:end] to fill width.
+func padLeft(buf []byte, start, end, width int) []byte {
+	n := width - utf8.RuneCount(buf[start:end])
+	if n <= 0 {
+		return buf
+	}
+	return slices.Insert(buf, start, bytes.Repeat([]byte{' '}, n)...)
+}
+
+// alignSource pads the source written by appendSource from buf[start:],
+// or its absence, to the width of the source column.
+func (h *ColorHandler) alignSource(buf []byte, start int) []byte {
+	var text []byte
+	if len(buf) > start {
+		// Skip the colors and the separating space
+		text = buf[start+len(colorCyan) : len(buf)-len(colorReset)-1]
+	}
+	width := fit(&h.columns.source, text, 0)
+	if width > 0 && text == nil {
+		width++ // the separating space
+	}
+	for n := utf8.RuneCount(text); n < width; n++ {
+		buf = append(buf, ' ')
+	}
+	return buf
+}
+
+// appendBlock writes the multi-line value s under key, indented below
+// the record line.
+func appendBlock(buf []byte, key, s string) []byte {
+	buf = append(buf, "    "+colorPurple...)
+	buf = append(buf, key...)
+	buf = append(buf, colorReset+":\n"...)
+	for line := range strings.Lines(strings.TrimRight(s, "\n")) {
+		buf = append(buf, "        "...)
+		buf = append(buf, strings.TrimSuffix(line, "\n")...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
@@ -0,0 +1,81 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestColorOptionsAlign(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.NewColorHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == slog.LevelWarn {
+				return slog.String(a.Key, "WARNING")
+			}
+			return a
+		},
+	}).WithColorOptions(&xlog.ColorOptions{Align: true})
+	logger := slog.New(h)
+
+	logger.Warn("a much longer message", "k", 1)
+	logger.Info("short", "k", 2)
+	logger.With("svc", "api").Info("mid message", "k", 3)
+
+	lines := strings.Split(strings.TrimSpace(ansi.ReplaceAllString(buf.String(), "")), "\n")
+	col := strings.Index(lines[0], " k=")
+	for _, line := range lines[1:] {
+		if i := strings.Index(line, " k="); i != col && !strings.Contains(line, "svc=") {
+			t.Errorf("expected attributes at column %d, got %d: %q", col, i, line)
+		}
+	}
+	if i := strings.Index(lines[2], " svc="); i != col {
+		t.Errorf("expected attributes at column %d, got %d: %q", col, i, lines[2])
+	}
+}
+
+func TestColorOptionsIcons(t *testing.T) {
+	var buf bytes.Buffer
+	icons := xlog.DefaultIcons()
+	icons[slog.LevelError+4] = "!!"
+	logger := slog.New(xlog.NewColorHandler(&buf, &slog.HandlerOptions{Level: xlog.LevelTrace}).
+		WithColorOptions(&xlog.ColorOptions{Icons: icons}))
+
+	logger.Debug("d")
+	logger.Warn("w")
+	logger.Log(context.Background(), slog.LevelError+2, "e")
+	logger.Log(context.Background(), slog.LevelError+4, "c")
+	logger.Log(context.Background(), slog.LevelDebug-4, "t")
+
+	lines := strings.Split(strings.TrimSpace(ansi.ReplaceAllString(buf.String(), "")), "\n")
+	// Levels below every icon get none
+	want := []string{"🐛 ", "⚠ ", "✖ ", "!! ", "20"}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: expected prefix %q, got %q", i, prefix, lines[i])
+		}
+	}
+}
+
+func TestColorOptionsMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewColorHandler(&buf, nil).WithColorOptions(&xlog.ColorOptions{Multiline: true}))
+
+	logger.With("query", "SELECT *\nFROM users\n").Error("failed", "stack", "main.main()\n\tmain.go:10", "code", 500)
+
+	got := ansi.ReplaceAllString(buf.String(), "")
+	lines := strings.Split(got, "\n")
+	if len(lines) != 8 || !strings.HasSuffix(lines[0], "failed code=500") {
+		t.Fatalf("unexpected output:\n%s", got)
+	}
+	want := []string{"    query:", "        SELECT *", "        FROM users", "    stack:", "        main.main()", "        \tmain.go:10"}
+	for i, w := range want {
+		if lines[i+1] != w {
+			t.Errorf("line %d: expected %q, got %q", i+1, w, lines[i+1])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package xlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// ColorJSONHandler writes records as FastJSONHandler does, with ANSI
+// colors for terminals: keys in one color and the rest of each line in
+// the color of its level. Terminals drop the colors when text is copied,
+// so lines can still be pasted as JSON.
+type ColorJSONHandler struct {
+	json *FastJSONHandler
+	out  *colorJSONWriter
+}
+
+// NewColorJSONHandler creates a ColorJSONHandler that writes to output.
+func NewColorJSONHandler(output io.Writer, opts *slog.HandlerOptions) *ColorJSONHandler {
+	out := &colorJSONWriter{output: output}
+	return &ColorJSONHandler{json: NewFastJSONHandler(out, opts), out: out}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *ColorJSONHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.json.Enabled(ctx, level)
+}
+
+// Handle encodes the record as a single line of colored JSON.
+func (h *ColorJSONHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs encodes the record with attrs written before its own.
+func (h *ColorJSONHandler) handleWithAttrs(ctx context.Context, r slog.Record, attrs []slog.Attr) error {
+	h.out.mu.Lock()
+	defer h.out.mu.Unlock()
+	h.out.level = r.Level
+	return h.json.handleWithAttrs(ctx, r, attrs)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *ColorJSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ColorJSONHandler{json: h.json.WithAttrs(attrs).(*FastJSONHandler), out: h.out}
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *ColorJSONHandler) WithGroup(name string) slog.Handler {
+	return &ColorJSONHandler{json: h.json.WithGroup(name).(*FastJSONHandler), out: h.out}
+}
+
+// colorJSONWriter colors the JSON lines written by a FastJSONHandler. Its
+// handler holds mu while writing and sets level first.
+type colorJSONWriter struct {
+	mu     sync.Mutex
+	output io.Writer
+	level  slog.Level
+	buf    []byte
+}
+
+// concurrentWrites lets the FastJSONHandler skip its own lock, since the
+// ColorJSONHandler already holds mu.
+func (w *colorJSONWriter) concurrentWrites() {}
+
+func (w *colorJSONWriter) Write(p []byte) (int, error) {
+	w.buf = colorizeJSON(w.buf[:0], p, levelColor(w.level))
+	if _, err := w.output.Write(w.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// colorizeJSON appends the JSON in src to buf with object keys in
+// colorPurple and everything else in lineColor.
+func colorizeJSON(buf, src []byte, lineColor string) []byte {
+	var (
+		nesting   []byte // '{' or '[' for each open container
+		expectKey bool
+		inString  bool
+		isKey     bool
+		escaped   bool
+	)
+	buf = append(buf, lineColor...)
+	for _, c := range src {
+		if inString {
+			buf = append(buf, c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if isKey {
+					buf = append(buf, colorReset+lineColor...)
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			isKey = expectKey
+			if isKey {
+				buf = append(buf, colorReset+colorPurple...)
+			}
+		case '{':
+			nesting = append(nesting, c)
+			expectKey = true
+		case '[':
+			nesting = append(nesting, c)
+		case '}', ']':
+			if len(nesting) > 0 {
+				nesting = nesting[:len(nesting)-1]
+			}
+		case ',':
+			expectKey = len(nesting) > 0 && nesting[len(nesting)-1] == '{'
+		case ':':
+			expectKey = false
+		case '\n':
+			buf = append(buf, colorReset...)
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
@@ -0,0 +1,53 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+var ansi = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func TestColorJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewColorJSONHandler(&buf, nil)).With("svc", "api")
+	logger.Error("failed", "tags", []string{"a", "b"}, slog.Group("req", "path", `/a"b`))
+
+	line := buf.String()
+	if !strings.HasPrefix(line, "\033[31m") {
+		t.Errorf("expected the line in the error color, got %q", line)
+	}
+	if !strings.Contains(line, "\033[35m\"msg\"") {
+		t.Errorf("expected keys to be colored, got %q", line)
+	}
+	if strings.Contains(line, "\033[35m\"a\"") {
+		t.Errorf("expected array elements not to be colored as keys, got %q", line)
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(ansi.ReplaceAllString(line, "")), &rec); err != nil {
+		t.Fatalf("expected JSON without the colors: %v: %q", err, line)
+	}
+	if rec["msg"] != "failed" || rec["svc"] != "api" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestInitColorJSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(xlog.WithFormat(xlog.ColorJSON), xlog.WithOutput(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info(context.Background(), "started")
+
+	if !strings.HasPrefix(buf.String(), "\033[32m{") {
+		t.Errorf("expected a colored JSON line, got %q", buf.String())
+	}
+}
@@ -0,0 +1,137 @@
+package xlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// CompressingWriterOptions configures a CompressingWriter.
+type CompressingWriterOptions struct {
+	// Level is the gzip compression level, from gzip.BestSpeed to
+	// gzip.BestCompression. Defaults to gzip.DefaultCompression.
+	Level int
+
+	// FlushInterval is how often a flush point is written, bounding how
+	// much data a crash can lose and letting readers follow the file.
+	// Defaults to 1s.
+	FlushInterval time.Duration
+}
+
+// CompressingWriter streams its output as gzip, for verbose services that
+// would otherwise need a separate compression step. Flush points are
+// written on every FlushInterval so the file is readable up to the last
+// one even if the process dies; Close writes the gzip trailer.
+//
+// Only gzip is supported, since xlog has no external dependencies. For
+// zstd, wrap a zstd encoder's Flush and Close the same way.
+type CompressingWriter struct {
+	out  io.Writer
+	opts CompressingWriterOptions
+
+	mu     sync.Mutex
+	zw     *gzip.Writer
+	dirty  bool
+	closed bool
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewCompressingWriter creates a CompressingWriter over out and starts
+// its background flusher. Call Close to stop it and finish the stream.
+func NewCompressingWriter(out io.Writer, opts *CompressingWriterOptions) *CompressingWriter {
+	var o CompressingWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == 0 {
+		o.Level = gzip.DefaultCompression
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+
+	zw, err := gzip.NewWriterLevel(out, o.Level)
+	if err != nil {
+		o.Level = gzip.DefaultCompression
+		zw = gzip.NewWriter(out)
+	}
+
+	w := &CompressingWriter{
+		out:  out,
+		opts: o,
+		zw:   zw,
+		done: make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.flushLoop()
+
+	return w
+}
+
+// Write compresses p.
+func (w *CompressingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+	w.dirty = true
+	return w.zw.Write(p)
+}
+
+// Flush writes a flush point so all data written so far can be decompressed.
+func (w *CompressingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed || !w.dirty {
+		return nil
+	}
+	w.dirty = false
+	return w.zw.Flush()
+}
+
+// Close stops the background flusher, finishes the gzip stream, and
+// closes the underlying writer if it implements io.Closer.
+func (w *CompressingWriter) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	err := w.zw.Close()
+	if c, ok := w.out.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+func (w *CompressingWriter) flushLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
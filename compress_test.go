@@ -0,0 +1,60 @@
+package xlog_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestCompressingWriter(t *testing.T) {
+	var buf syncBuffer
+	w := xlog.NewCompressingWriter(&buf, nil)
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(w),
+		xlog.WithSource(false),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		xlog.Info(ctx, "compressed record", "i", i)
+	}
+
+	// After a flush point the data so far can be read without the trailer
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader([]byte(buf.String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, _ := io.ReadAll(zr)
+	if n := strings.Count(string(partial), "compressed record"); n != 100 {
+		t.Errorf("expected 100 records after Flush, got %d", n)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late")); !errors.Is(err, xlog.ErrWriterClosed) {
+		t.Errorf("expected ErrWriterClosed after Close, got: %v", err)
+	}
+
+	zr, err = gzip.NewReader(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("expected a complete gzip stream, got: %v", err)
+	}
+	if len(full) <= len(buf.String()) {
+		t.Errorf("expected compression, got %d bytes from %d", len(buf.String()), len(full))
+	}
+}
@@ -0,0 +1,58 @@
+package xlog
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// ConsumerOptions configures WrapConsumer.
+type ConsumerOptions[M any] struct {
+	// MessageID returns the ID of msg, added to the context under
+	// MessageIDKey.
+	MessageID func(msg M) string
+
+	// CorrelationID returns the ID linking msg to the request that
+	// produced it. It is added to the context as the request ID, so the
+	// records of both share a request_id.
+	CorrelationID func(msg M) string
+}
+
+// WrapConsumer wraps the message handler h so that it logs one record
+// per message, as Op does for an operation called name: with the
+// duration, at INFO if h succeeds and at ERROR with the error if it
+// fails. A panic in h is logged with its stack and returned as an error.
+// It does for queue consumers what HTTPMiddleware does for HTTP servers:
+//
+//	handle := xlog.WrapConsumer("orders", processOrder, &xlog.ConsumerOptions[*nats.Msg]{
+//		MessageID: func(m *nats.Msg) string { return m.Header.Get("Nats-Msg-Id") },
+//	})
+//	sub, err := nc.Subscribe("orders", func(m *nats.Msg) { _ = handle(ctx, m) })
+func WrapConsumer[M any](name string, h func(ctx context.Context, msg M) error, opts *ConsumerOptions[M]) func(ctx context.Context, msg M) error {
+	var o ConsumerOptions[M]
+	if opts != nil {
+		o = *opts
+	}
+	return func(ctx context.Context, msg M) (err error) {
+		if o.MessageID != nil {
+			if id := o.MessageID(msg); id != "" {
+				ctx = WithContext(ctx, MessageIDKey, id)
+			}
+		}
+		if o.CorrelationID != nil {
+			if id := o.CorrelationID(msg); id != "" {
+				ctx = WithRequestID(ctx, id)
+			}
+		}
+
+		op := FromContext(ctx).Start(ctx, name)
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("xlog: panic in %s: %v", name, p)
+				op.args = append(op.args, "panic", p, "stack", string(debug.Stack()))
+			}
+			op.End(err)
+		}()
+		return h(ctx, msg)
+	}
+}
@@ -0,0 +1,72 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+type message struct {
+	id, correlation string
+	fail, panic     bool
+}
+
+func TestWrapConsumer(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+
+	handle := xlog.WrapConsumer("orders", func(ctx context.Context, m message) error {
+		if m.panic {
+			panic("bad message")
+		}
+		if m.fail {
+			return errors.New("rejected")
+		}
+		xlog.Info(ctx, "processing")
+		return nil
+	}, &xlog.ConsumerOptions[message]{
+		MessageID:     func(m message) string { return m.id },
+		CorrelationID: func(m message) string { return m.correlation },
+	})
+
+	ctx := context.Background()
+	if err := handle(ctx, message{id: "m-1", correlation: "req-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := handle(ctx, message{id: "m-2", fail: true}); err == nil {
+		t.Error("expected the handler's error")
+	}
+	if err := handle(ctx, message{id: "m-3", panic: true}); err == nil || !strings.Contains(err.Error(), "bad message") {
+		t.Errorf("expected the panic as an error, got: %v", err)
+	}
+
+	var recs []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 4 {
+		t.Fatalf("expected 4 records, got: %s", buf.String())
+	}
+	if recs[0]["message_id"] != "m-1" || recs[0]["request_id"] != "req-1" {
+		t.Errorf("expected IDs in the handler's records, got: %v", recs[0])
+	}
+	if recs[1]["msg"] != "orders" || recs[1]["outcome"] != "ok" || recs[1]["message_id"] != "m-1" {
+		t.Errorf("unexpected record: %v", recs[1])
+	}
+	if recs[2]["level"] != "ERROR" || recs[2]["error"] != "rejected" {
+		t.Errorf("unexpected record: %v", recs[2])
+	}
+	if recs[3]["panic"] != "bad message" || recs[3]["stack"] == nil {
+		t.Errorf("expected the panic and stack, got: %v", recs[3])
+	}
+}
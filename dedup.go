@@ -0,0 +1,120 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// DedupPolicy decides which attribute is kept when a key repeats.
+type DedupPolicy int
+
+const (
+	// DedupLastWins keeps the last attribute with a key, so values passed
+	// to a log call override those from With and the context.
+	DedupLastWins DedupPolicy = iota
+	// DedupFirstWins keeps the first attribute with a key.
+	DedupFirstWins
+)
+
+// DedupHandler removes attributes with repeated keys before passing records
+// to the handler it wraps. Keys are compared within each group, and
+// attributes from WithAttrs take part, so it holds them back from the
+// wrapped handler until a record is handled.
+type DedupHandler struct {
+	handler slog.Handler
+	policy  DedupPolicy
+	state   sinkState
+}
+
+// NewDedupHandler creates a DedupHandler that writes to handler.
+func NewDedupHandler(handler slog.Handler, policy DedupPolicy) *DedupHandler {
+	return &DedupHandler{handler: handler, policy: policy}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *DedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle rebuilds the record without duplicate keys and passes it on.
+func (h *DedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs handles the record with extra ahead of its own
+// attributes, so they take part in deduplication.
+func (h *DedupHandler) handleWithAttrs(ctx context.Context, r slog.Record, extra []slog.Attr) error {
+	attrs := make([]slog.Attr, 0, len(extra)+r.NumAttrs())
+	attrs = append(attrs, extra...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// Nest the record attributes under the pre-set groups, innermost first
+	for i := len(h.state.goas) - 1; i >= 0; i-- {
+		goa := h.state.goas[i]
+		if goa.group != "" {
+			attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			continue
+		}
+		attrs = append(append(make([]slog.Attr, 0, len(goa.attrs)+len(attrs)), goa.attrs...), attrs...)
+	}
+
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(h.dedup(attrs)...)
+	return h.handler.Handle(ctx, r2)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// dedup returns attrs with one attribute per key, recursing into groups.
+// Groups with an empty key are inlined first so their members take part.
+func (h *DedupHandler) dedup(attrs []slog.Attr) []slog.Attr {
+	flat := make([]slog.Attr, 0, len(attrs))
+	var inline func([]slog.Attr)
+	inline = func(attrs []slog.Attr) {
+		for _, a := range attrs {
+			a.Value = a.Value.Resolve()
+			if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+				inline(a.Value.Group())
+				continue
+			}
+			flat = append(flat, a)
+		}
+	}
+	inline(attrs)
+
+	// winner maps each key to the index of the attribute that is kept
+	winner := make(map[string]int, len(flat))
+	for i, a := range flat {
+		if _, ok := winner[a.Key]; ok && h.policy == DedupFirstWins {
+			continue
+		}
+		winner[a.Key] = i
+	}
+
+	out := flat[:0]
+	for i, a := range flat {
+		if winner[a.Key] != i {
+			continue
+		}
+		if a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(h.dedup(a.Value.Group())...)
+		}
+		out = append(out, a)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestDedupHandler(t *testing.T) {
+	tests := []struct {
+		policy xlog.DedupPolicy
+		want   string
+	}{
+		{xlog.DedupLastWins, `{"level":"INFO","msg":"dup","a":2,"g":{"b":4},"trace_id":"explicit"}`},
+		{xlog.DedupFirstWins, `{"level":"INFO","msg":"dup","a":1,"g":{"b":3},"trace_id":"ctx"}`},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		json := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+		h := xlog.NewContextHandler(xlog.NewDedupHandler(json, tt.policy), xlog.TraceIDKey)
+		logger := slog.New(h).With("a", 1).With(slog.Group("g", "b", 3))
+
+		ctx := xlog.WithTraceID(context.Background(), "ctx")
+		logger.InfoContext(ctx, "dup", "a", 2, slog.Group("g", "b", 4), "trace_id", "explicit")
+
+		if got := buf.String(); got != tt.want+"\n" {
+			t.Errorf("policy %d:\n got: %s\nwant: %s", tt.policy, got, tt.want)
+		}
+	}
+}
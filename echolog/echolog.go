@@ -0,0 +1,82 @@
+// Package echolog provides Echo middleware logging requests with xlog, and
+// an echo.Logger writing Echo's own logs through xlog. It is a separate
+// module, so xlog itself does not depend on Echo.
+package echolog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/taro33333/xlog"
+)
+
+// Middleware returns an echo.MiddlewareFunc that, for each request:
+//
+//   - reads the request ID from the X-Request-ID header, or generates one
+//     if it is absent or malformed, and echoes it in the response;
+//   - adds the request ID to the request context, so handlers log with
+//     xlog.Info(c.Request().Context(), ...) or FromContext(c);
+//   - passes errors returned by the handler to c.Error, so the response
+//     is written before the request is logged;
+//   - logs one record with the request, including the route pattern such
+//     as "/items/:id", the response and the error, at ERROR for 5xx
+//     responses.
+//
+// Echo's Recover middleware, installed after this one, turns panics into
+// errors logged here.
+//
+//	e := echo.New()
+//	e.Use(echolog.Middleware(), middleware.Recover())
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			req := c.Request()
+			id := req.Header.Get(xlog.RequestIDHeader)
+			if !xlog.ValidRequestID(id) {
+				id = xlog.NewRequestID()
+			}
+			c.Response().Header().Set(xlog.RequestIDHeader, id)
+			ctx := xlog.WithRequestID(req.Context(), id)
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+			logRequest(ctx, c, time.Since(start), err)
+			return nil
+		}
+	}
+}
+
+// FromContext returns the logger installed by Middleware for the request
+// of c.
+func FromContext(c echo.Context) *xlog.Logger {
+	return xlog.FromContext(c.Request().Context())
+}
+
+// logRequest logs the request of c, served in dur with error err.
+func logRequest(ctx context.Context, c echo.Context, dur time.Duration, err error) {
+	resp := c.Response()
+	level := slog.LevelInfo
+	if resp.Status >= 500 {
+		level = slog.LevelError
+	}
+	l := xlog.FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	req := *c.Request()
+	req.Pattern = c.Path()
+	r := slog.NewRecord(time.Now(), level, "request", 0)
+	r.AddAttrs(xlog.HTTPRequest(&req), xlog.HTTPResponse(resp.Status, max(resp.Size, 0), dur))
+	if err != nil {
+		r.AddAttrs(xlog.Err(err))
+	}
+	_ = l.Logger.Handler().Handle(ctx, r)
+}
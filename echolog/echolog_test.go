@@ -0,0 +1,118 @@
+package echolog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/echolog"
+)
+
+type record struct {
+	Level   string `json:"level"`
+	Msg     string `json:"msg"`
+	Prefix  string `json:"prefix"`
+	Port    int    `json:"port"`
+	Error   string `json:"error"`
+	Request struct {
+		Route string `json:"route"`
+	} `json:"request"`
+	Response struct {
+		Status int `json:"status"`
+	} `json:"response"`
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	e := echo.New()
+	e.Use(echolog.Middleware())
+	e.GET("/items/:id", func(c echo.Context) error {
+		echolog.FromContext(c).Info(c.Request().Context(), "loading item")
+		return c.String(http.StatusOK, "item")
+	})
+	e.GET("/fail", func(c echo.Context) error { return errors.New("boom") })
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/items/42", nil))
+	if w.Header().Get(xlog.RequestIDHeader) == "" {
+		t.Error("expected a request ID in the response")
+	}
+
+	dec := json.NewDecoder(&buf)
+	var rec record
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "loading item" {
+		t.Errorf("expected the handler's record, got: %+v", rec)
+	}
+	rec = record{}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "request" || rec.Request.Route != "/items/:id" || rec.Response.Status != 200 {
+		t.Errorf("unexpected access record: %+v", rec)
+	}
+
+	req := httptest.NewRequest("GET", "/items/42", nil)
+	req.Header.Set(xlog.RequestIDHeader, `bad "id"`)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	if got := w.Header().Get(xlog.RequestIDHeader); got == "" || got == `bad "id"` {
+		t.Errorf("expected a malformed request ID to be replaced, got %q", got)
+	}
+
+	buf.Reset()
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/fail", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected a 500, got %d", w.Code)
+	}
+	rec = record{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level != "ERROR" || rec.Response.Status != 500 || rec.Error != "boom" {
+		t.Errorf("expected the error at ERROR with the status, got: %+v", rec)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false), xlog.WithLevel(slog.LevelDebug))
+
+	l := echolog.NewLogger(nil)
+	l.SetPrefix("echo")
+	l.SetLevel(log.WARN)
+	l.Info("hidden")
+	l.Warnf("retrying in %ds", 3)
+	l.Errorj(log.JSON{"port": 8080})
+	l.Print("always")
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []record{
+		{Level: "WARN", Msg: "retrying in 3s", Prefix: "echo"},
+		{Level: "ERROR", Prefix: "echo", Port: 8080},
+		{Level: "INFO", Msg: "always", Prefix: "echo"},
+	} {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec != want {
+			t.Errorf("got %+v, want %+v", rec, want)
+		}
+	}
+	if dec.More() {
+		t.Error("expected INFO to be filtered by SetLevel")
+	}
+}
@@ -0,0 +1,180 @@
+package echolog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/taro33333/xlog"
+)
+
+var _ echo.Logger = (*Logger)(nil)
+
+// Logger is an echo.Logger writing Echo's own logs, and those of handlers
+// using c.Logger(), through xlog:
+//
+//	e.Logger = echolog.NewLogger(xlog.Named("echo"))
+//
+// Levels map to their slog counterparts, Fatal and Panic to
+// xlog.LevelCritical, and Print to INFO. The prefix is logged as
+// xlog.PrefixKey, and the fields of the JSON variants as attributes.
+// Outputs, formats and headers belong to xlog, so SetOutput and SetHeader
+// do nothing.
+type Logger struct {
+	l *xlog.Logger
+
+	mu     sync.RWMutex
+	prefix string
+	level  log.Lvl
+}
+
+// NewLogger creates a Logger logging to l, or to the default logger at the
+// time of each write if l is nil. It logs every level until SetLevel is
+// called, leaving filtering to l.
+func NewLogger(l *xlog.Logger) *Logger {
+	return &Logger{l: l, level: log.DEBUG}
+}
+
+// Output returns a writer logging each line written to it at INFO.
+func (l *Logger) Output() io.Writer {
+	return xlog.NewLevelWriter(l.l, slog.LevelInfo)
+}
+
+// SetOutput does nothing; outputs are configured with xlog.
+func (l *Logger) SetOutput(io.Writer) {}
+
+// Prefix returns the prefix logged with each record.
+func (l *Logger) Prefix() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.prefix
+}
+
+// SetPrefix sets the prefix logged with each record.
+func (l *Logger) SetPrefix(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prefix = p
+}
+
+// Level returns the minimum level logged.
+func (l *Logger) Level() log.Lvl {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+// SetLevel sets the minimum level logged, on top of the level of the
+// xlog logger.
+func (l *Logger) SetLevel(v log.Lvl) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = v
+}
+
+// SetHeader does nothing; formats are configured with xlog.
+func (l *Logger) SetHeader(string) {}
+
+func (l *Logger) Print(i ...any)                 { l.log(0, fmt.Sprint(i...), nil) }
+func (l *Logger) Printf(format string, a ...any) { l.log(0, fmt.Sprintf(format, a...), nil) }
+func (l *Logger) Printj(j log.JSON)              { l.log(0, "", j) }
+func (l *Logger) Debug(i ...any)                 { l.log(log.DEBUG, fmt.Sprint(i...), nil) }
+func (l *Logger) Debugf(format string, a ...any) { l.log(log.DEBUG, fmt.Sprintf(format, a...), nil) }
+func (l *Logger) Debugj(j log.JSON)              { l.log(log.DEBUG, "", j) }
+func (l *Logger) Info(i ...any)                  { l.log(log.INFO, fmt.Sprint(i...), nil) }
+func (l *Logger) Infof(format string, a ...any)  { l.log(log.INFO, fmt.Sprintf(format, a...), nil) }
+func (l *Logger) Infoj(j log.JSON)               { l.log(log.INFO, "", j) }
+func (l *Logger) Warn(i ...any)                  { l.log(log.WARN, fmt.Sprint(i...), nil) }
+func (l *Logger) Warnf(format string, a ...any)  { l.log(log.WARN, fmt.Sprintf(format, a...), nil) }
+func (l *Logger) Warnj(j log.JSON)               { l.log(log.WARN, "", j) }
+func (l *Logger) Error(i ...any)                 { l.log(log.ERROR, fmt.Sprint(i...), nil) }
+func (l *Logger) Errorf(format string, a ...any) { l.log(log.ERROR, fmt.Sprintf(format, a...), nil) }
+func (l *Logger) Errorj(j log.JSON)              { l.log(log.ERROR, "", j) }
+
+// Fatal logs at xlog.LevelCritical, then exits with status 1.
+func (l *Logger) Fatal(i ...any) {
+	l.log(fatalLevel, fmt.Sprint(i...), nil)
+	os.Exit(1)
+}
+
+// Fatalf logs at xlog.LevelCritical, then exits with status 1.
+func (l *Logger) Fatalf(format string, a ...any) {
+	l.log(fatalLevel, fmt.Sprintf(format, a...), nil)
+	os.Exit(1)
+}
+
+// Fatalj logs at xlog.LevelCritical, then exits with status 1.
+func (l *Logger) Fatalj(j log.JSON) {
+	l.log(fatalLevel, "", j)
+	os.Exit(1)
+}
+
+// Panic logs at xlog.LevelCritical, then panics with the message.
+func (l *Logger) Panic(i ...any) {
+	msg := fmt.Sprint(i...)
+	l.log(fatalLevel, msg, nil)
+	panic(msg)
+}
+
+// Panicf logs at xlog.LevelCritical, then panics with the message.
+func (l *Logger) Panicf(format string, a ...any) {
+	msg := fmt.Sprintf(format, a...)
+	l.log(fatalLevel, msg, nil)
+	panic(msg)
+}
+
+// Panicj logs at xlog.LevelCritical, then panics with the fields.
+func (l *Logger) Panicj(j log.JSON) {
+	l.log(fatalLevel, "", j)
+	panic(j)
+}
+
+// fatalLevel stands for Fatal and Panic, above every level of Echo.
+const fatalLevel = log.OFF + 1
+
+// levels maps Echo levels to slog levels. Print, level 0, logs at INFO.
+var levels = map[log.Lvl]slog.Level{
+	0:          slog.LevelInfo,
+	log.DEBUG:  slog.LevelDebug,
+	log.INFO:   slog.LevelInfo,
+	log.WARN:   slog.LevelWarn,
+	log.ERROR:  slog.LevelError,
+	fatalLevel: xlog.LevelCritical,
+}
+
+// log logs msg and the fields of j at level, with the source of the caller
+// of the exported method. Print ignores the level set with SetLevel.
+func (l *Logger) log(level log.Lvl, msg string, j log.JSON) {
+	if level != 0 && level != fatalLevel && level < l.Level() {
+		return
+	}
+	logger := l.l
+	if logger == nil {
+		logger = xlog.Default()
+	}
+	ctx := context.Background()
+	sl := levels[level]
+	if !logger.Logger.Enabled(ctx, sl) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), sl, msg, pcs[0])
+	if p := l.Prefix(); p != "" {
+		r.AddAttrs(slog.String(xlog.PrefixKey, p))
+	}
+	for _, k := range slices.Sorted(maps.Keys(j)) {
+		r.AddAttrs(slog.Any(k, j[k]))
+	}
+	_ = logger.Logger.Handler().Handle(ctx, r)
+}
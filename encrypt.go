@@ -0,0 +1,211 @@
+package xlog
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrUnknownKey is returned by DecryptingReader for a frame encrypted with
+// a key ID it was not given.
+var ErrUnknownKey = errors.New("xlog: unknown encryption key")
+
+// encryptHeader is the frame length and key ID preceding each frame.
+const encryptHeader = 8
+
+// maxFramePlaintext is the most plaintext in one frame. EncryptingWriter
+// splits larger writes, and DecryptingReader rejects larger frames before
+// allocating for them.
+const maxFramePlaintext = 16 << 20
+
+// EncryptingWriter encrypts log data at rest with AES-GCM. Each Write
+// becomes one frame:
+//
+//	length (4 bytes) | key ID (4 bytes) | nonce | ciphertext and tag
+//
+// where length covers everything after itself. The key ID is
+// authenticated, so frames can't be moved between keys. Call Rotate to
+// switch keys without restarting; use DecryptingReader to read the output.
+type EncryptingWriter struct {
+	out io.Writer
+
+	mu    sync.Mutex
+	keyID uint32
+	aead  cipher.AEAD
+	buf   []byte
+}
+
+// NewEncryptingWriter creates an EncryptingWriter that encrypts with key,
+// which must be 16, 24, or 32 bytes for AES-128, AES-192, or AES-256.
+func NewEncryptingWriter(out io.Writer, keyID uint32, key []byte) (*EncryptingWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptingWriter{out: out, keyID: keyID, aead: aead}, nil
+}
+
+// Rotate encrypts subsequent writes with key, identified by keyID.
+func (w *EncryptingWriter) Rotate(keyID uint32, key []byte) error {
+	aead, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.keyID = keyID
+	w.aead = aead
+	return nil
+}
+
+// Write encrypts p as a single frame, or as several if it is over 16 MiB.
+func (w *EncryptingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := 0
+	for {
+		chunk := p[:min(len(p), maxFramePlaintext)]
+		if err := w.writeFrame(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+		if len(p) == 0 {
+			return n, nil
+		}
+	}
+}
+
+// writeFrame encrypts p as one frame. The caller holds w.mu.
+func (w *EncryptingWriter) writeFrame(p []byte) error {
+	ns := w.aead.NonceSize()
+	n := encryptHeader + ns + len(p) + w.aead.Overhead()
+	buf := w.buf[:0]
+	if cap(buf) < n {
+		buf = make([]byte, 0, n)
+	}
+	buf = binary.BigEndian.AppendUint32(buf, uint32(n-4))
+	buf = binary.BigEndian.AppendUint32(buf, w.keyID)
+	nonce := buf[encryptHeader : encryptHeader+ns]
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("xlog: generate nonce: %w", err)
+	}
+	buf = w.aead.Seal(buf[:encryptHeader+ns], nonce, p, buf[4:encryptHeader])
+
+	// Keep small buffers for reuse
+	if cap(buf) <= maxPooledBuffer {
+		w.buf = buf
+	}
+
+	_, err := w.out.Write(buf)
+	return err
+}
+
+// Close closes the underlying writer if it implements io.Closer.
+func (w *EncryptingWriter) Close() error {
+	if c, ok := w.out.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// DecryptingReader reads the plaintext of data written by an
+// EncryptingWriter.
+type DecryptingReader struct {
+	r     io.Reader
+	keys  map[uint32]cipher.AEAD
+	frame []byte
+	plain []byte
+}
+
+// NewDecryptingReader creates a DecryptingReader that reads frames from r,
+// decrypting each with the key for its key ID. Keep retired keys in keys
+// for as long as data encrypted with them needs to be read.
+func NewDecryptingReader(r io.Reader, keys map[uint32][]byte) (*DecryptingReader, error) {
+	d := &DecryptingReader{r: r, keys: make(map[uint32]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		d.keys[id] = aead
+	}
+	return d, nil
+}
+
+// Read reads decrypted log data. A truncated trailing frame is reported
+// as io.ErrUnexpectedEOF, and a frame that is too large or fails
+// authentication as an error.
+func (d *DecryptingReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if err := d.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+func (d *DecryptingReader) next() error {
+	var hdr [encryptHeader]byte
+	if _, err := io.ReadFull(d.r, hdr[:4]); err != nil {
+		return err // io.EOF at a frame boundary ends the stream cleanly
+	}
+	n := binary.BigEndian.Uint32(hdr[:4])
+	if n < 4 {
+		return errors.New("xlog: invalid encrypted frame")
+	}
+	if _, err := io.ReadFull(d.r, hdr[4:]); err != nil {
+		return noEOF(err)
+	}
+	keyID := binary.BigEndian.Uint32(hdr[4:])
+	aead, ok := d.keys[keyID]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownKey, keyID)
+	}
+
+	size := int(n) - 4
+	if size < aead.NonceSize()+aead.Overhead() {
+		return errors.New("xlog: invalid encrypted frame")
+	}
+	if size > aead.NonceSize()+maxFramePlaintext+aead.Overhead() {
+		return fmt.Errorf("xlog: encrypted frame of %d bytes exceeds the maximum", size)
+	}
+	if cap(d.frame) < size {
+		d.frame = make([]byte, size)
+	}
+	frame := d.frame[:size]
+	if _, err := io.ReadFull(d.r, frame); err != nil {
+		return noEOF(err)
+	}
+
+	ns := aead.NonceSize()
+	plain, err := aead.Open(frame[ns:ns], frame[:ns], frame[ns:], hdr[4:])
+	if err != nil {
+		return fmt.Errorf("xlog: decrypt frame: %w", err)
+	}
+	d.plain = plain
+	return nil
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("xlog: encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
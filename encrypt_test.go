@@ -0,0 +1,88 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestEncryptingWriter(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	var buf bytes.Buffer
+	w, err := xlog.NewEncryptingWriter(&buf, 1, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(w),
+		xlog.WithSource(false),
+	)
+
+	ctx := context.Background()
+	xlog.Info(ctx, "before rotation", "ssn", "123-45-6789")
+	if err := w.Rotate(2, newKey); err != nil {
+		t.Fatal(err)
+	}
+	xlog.Info(ctx, "after rotation")
+
+	if bytes.Contains(buf.Bytes(), []byte("123-45-6789")) {
+		t.Fatal("expected output to be encrypted")
+	}
+
+	r, err := xlog.NewDecryptingReader(bytes.NewReader(buf.Bytes()), map[uint32][]byte{1: oldKey, 2: newKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(plain)), "\n"); len(lines) != 2 ||
+		!strings.Contains(lines[0], "123-45-6789") || !strings.Contains(lines[1], "after rotation") {
+		t.Errorf("unexpected plaintext: %s", plain)
+	}
+
+	// Without the retired key, its frames can't be read
+	r, _ = xlog.NewDecryptingReader(bytes.NewReader(buf.Bytes()), map[uint32][]byte{2: newKey})
+	if _, err := io.ReadAll(r); !errors.Is(err, xlog.ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got: %v", err)
+	}
+
+	// Tampered frames fail authentication
+	tampered := bytes.Clone(buf.Bytes())
+	tampered[len(tampered)-1] ^= 1
+	r, _ = xlog.NewDecryptingReader(bytes.NewReader(tampered), map[uint32][]byte{1: oldKey, 2: newKey})
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("expected tampered data to fail decryption")
+	}
+
+	// A forged length is rejected before the frame is read
+	forged := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 2}
+	r, _ = xlog.NewDecryptingReader(bytes.NewReader(forged), map[uint32][]byte{2: newKey})
+	if _, err := io.ReadAll(r); err == nil || !strings.Contains(err.Error(), "exceeds the maximum") {
+		t.Errorf("expected an oversized frame to be rejected, got: %v", err)
+	}
+
+	// Large writes are split into frames the reader accepts
+	buf.Reset()
+	large := bytes.Repeat([]byte("x"), 16<<20+1)
+	if n, err := w.Write(large); n != len(large) || err != nil {
+		t.Fatalf("write: %d, %v", n, err)
+	}
+	r, _ = xlog.NewDecryptingReader(bytes.NewReader(buf.Bytes()), map[uint32][]byte{2: newKey})
+	if plain, err := io.ReadAll(r); err != nil || !bytes.Equal(plain, large) {
+		t.Errorf("expected the large write back, got %d bytes: %v", len(plain), err)
+	}
+
+	if _, err := xlog.NewEncryptingWriter(&buf, 1, []byte("short")); err == nil {
+		t.Error("expected an invalid key length to fail")
+	}
+}
@@ -0,0 +1,50 @@
+package xlog
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// DetectEnvironment infers the environment when WithEnvironment isn't
+// given. In order, it checks:
+//
+//   - APP_ENV, then GO_ENV: "production", "prod", "staging" mean
+//     Production; "development", "dev", "local", "test" mean Development
+//   - container markers (/.dockerenv, /run/.containerenv,
+//     KUBERNETES_SERVICE_HOST), which mean Production
+//   - whether standard output is a terminal, which means Development
+//
+// Anything else is Production, so unattended processes get JSON. Init
+// checks the writer given to WithOutput instead of standard output.
+func DetectEnvironment() Environment {
+	return detectEnvironment(os.Stdout)
+}
+
+// detectEnvironment is DetectEnvironment checking w for a terminal.
+func detectEnvironment(w io.Writer) Environment {
+	for _, key := range []string{"APP_ENV", "GO_ENV"} {
+		switch strings.ToLower(os.Getenv(key)) {
+		case "production", "prod", "staging":
+			return Production
+		case "development", "dev", "local", "test":
+			return Development
+		}
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" || fileExists("/.dockerenv") || fileExists("/run/.containerenv") {
+		return Production
+	}
+
+	if f, ok := w.(interface{ Stat() (os.FileInfo, error) }); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+			return Development
+		}
+	}
+	return Production
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
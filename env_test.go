@@ -0,0 +1,65 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestDetectEnvironment(t *testing.T) {
+	tests := []struct {
+		appEnv, goEnv string
+		want          xlog.Environment
+	}{
+		{"production", "", xlog.Production},
+		{"DEV", "", xlog.Development},
+		{"", "prod", xlog.Production},
+		{"", "local", xlog.Development},
+		{"staging", "development", xlog.Production},
+	}
+	for _, tt := range tests {
+		t.Setenv("APP_ENV", tt.appEnv)
+		t.Setenv("GO_ENV", tt.goEnv)
+		if got := xlog.DetectEnvironment(); got != tt.want {
+			t.Errorf("APP_ENV=%q GO_ENV=%q: got %s, want %s", tt.appEnv, tt.goEnv, got, tt.want)
+		}
+	}
+}
+
+// ttyBuffer is a buffer that reports being a character device, like a terminal.
+type ttyBuffer struct {
+	bytes.Buffer
+}
+
+func (b *ttyBuffer) Stat() (os.FileInfo, error) {
+	return os.Stat(os.DevNull)
+}
+
+func TestDetectEnvironmentOutput(t *testing.T) {
+	t.Setenv("APP_ENV", "")
+	t.Setenv("GO_ENV", "")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	for _, path := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(path); err == nil {
+			t.Skip("running in a container")
+		}
+	}
+
+	var tty ttyBuffer
+	_ = xlog.Init(xlog.WithOutput(&tty))
+	xlog.Info(context.Background(), "hello")
+	if strings.HasPrefix(tty.String(), "{") {
+		t.Errorf("expected development output for a terminal, got: %q", tty.String())
+	}
+
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithOutput(&buf))
+	xlog.Info(context.Background(), "hello")
+	if !strings.HasPrefix(buf.String(), "{") {
+		t.Errorf("expected production output for a buffer, got: %q", buf.String())
+	}
+}
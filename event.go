@@ -0,0 +1,47 @@
+package xlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// EventKey is the attribute key holding the name of a business event.
+const EventKey = "event"
+
+// WithEventOutput sends records from Event to w, keeping analytics-style
+// events apart from diagnostic logs. They are written regardless of
+// WithLevel and are not sampled.
+func WithEventOutput(w io.Writer) Option {
+	return func(c *config) {
+		c.events = w
+	}
+}
+
+// Event logs the business event name, such as "order_placed", with args
+// as its properties. The record carries event=name; with WithEventOutput
+// it goes to its own output, otherwise to the default logger at INFO.
+//
+//	xlog.Event(ctx, "order_placed", "order_id", id, "total", total)
+func Event(ctx context.Context, name string, args ...any) {
+	defaultMu.RLock()
+	l := eventLogger
+	defaultMu.RUnlock()
+	if l == nil {
+		l = Default()
+	}
+	logEvent(ctx, l, name, args)
+}
+
+// Event logs the business event name at INFO through l, with event=name.
+func (l *Logger) Event(ctx context.Context, name string, args ...any) {
+	logEvent(ctx, l, name, args)
+}
+
+func logEvent(ctx context.Context, l *Logger, name string, args []any) {
+	if !l.Logger.Enabled(ctx, slog.LevelInfo) {
+		return
+	}
+	args = append([]any{slog.String(EventKey, name)}, args...)
+	logPC(ctx, l, slog.LevelInfo, callerPC(callerSkip+l.callerSkip), name, args...)
+}
@@ -0,0 +1,74 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"runtime"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestEvent(t *testing.T) {
+	var app, events bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithLevel(slog.LevelError),
+		xlog.WithOutput(&app),
+		xlog.WithEventOutput(&events),
+	)
+
+	ctx := context.Background()
+	_, _, line, _ := runtime.Caller(0)
+	xlog.Event(ctx, "order_placed", "order_id", "o-1", "total", 42)
+
+	if app.Len() > 0 {
+		t.Errorf("expected events to bypass the application output, got: %s", app.String())
+	}
+	var rec struct {
+		Msg     string
+		Event   string
+		OrderID string `json:"order_id"`
+		Total   int
+		Source  struct{ Line int }
+	}
+	if err := json.Unmarshal(events.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "order_placed" || rec.Event != "order_placed" || rec.OrderID != "o-1" || rec.Total != 42 {
+		t.Errorf("unexpected event: %+v", rec)
+	}
+	if rec.Source.Line != line+1 {
+		t.Errorf("expected source line %d, got %d", line+1, rec.Source.Line)
+	}
+
+	// Without an event output, events go through the default logger
+	app.Reset()
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&app),
+	)
+	xlog.Event(ctx, "signup")
+	if !bytes.Contains(app.Bytes(), []byte(`"event":"signup"`)) {
+		t.Errorf("expected event in the default output, got: %s", app.String())
+	}
+}
+
+func TestEventWithHandler(t *testing.T) {
+	var app, events bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithHandler(slog.NewJSONHandler(&app, nil)),
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithEventOutput(&events),
+	)
+
+	xlog.Event(context.Background(), "order_placed", "order_id", "o-1")
+	if app.Len() > 0 {
+		t.Errorf("expected events to bypass the handler, got: %s", app.String())
+	}
+	if !bytes.Contains(events.Bytes(), []byte(`"event":"order_placed"`)) {
+		t.Errorf("expected the event in the event output, got: %s", events.String())
+	}
+}
@@ -0,0 +1,6 @@
+package xlog
+
+// ResetThrottles forgets the call-site state of Once, EveryN and Every.
+func ResetThrottles() {
+	throttles.Clear()
+}
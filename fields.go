@@ -0,0 +1,43 @@
+package xlog
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// Fields is a set of attributes keyed by name, for call sites built around
+// field maps, such as those migrated from logrus.
+type Fields map[string]any
+
+// Attrs returns the fields as attributes sorted by key, so output is
+// stable. Pass them to a logging call with Args.
+func (f Fields) Attrs() []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(f))
+	for _, k := range slices.Sorted(maps.Keys(f)) {
+		attrs = append(attrs, slog.Any(k, f[k]))
+	}
+	return attrs
+}
+
+// Args returns the fields as arguments for a logging call, sorted by key:
+//
+//	xlog.Info(ctx, "order placed", fields.Args()...)
+func (f Fields) Args() []any {
+	args := make([]any, 0, len(f))
+	for _, a := range f.Attrs() {
+		args = append(args, a)
+	}
+	return args
+}
+
+// WithFields returns a new Logger with the given fields.
+func WithFields(f Fields) *Logger {
+	return Default().WithFields(f)
+}
+
+// WithFields returns a new Logger with the given fields, added in key
+// order.
+func (l *Logger) WithFields(f Fields) *Logger {
+	return l.derive(l.Logger.With(f.Args()...))
+}
@@ -0,0 +1,28 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithFields(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	logger := xlog.WithFields(xlog.Fields{"zone": "us-east", "app": "shop", "build": 7})
+	logger.Info(context.Background(), "started", xlog.Fields{"b": 2, "a": 1}.Args()...)
+
+	output := buf.String()
+	want := `"app":"shop","build":7,"zone":"us-east","a":1,"b":2}`
+	if !strings.HasSuffix(strings.TrimSpace(output), want) {
+		t.Errorf("expected fields in key order, got: %s", output)
+	}
+}
@@ -0,0 +1,424 @@
+package xlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed filter expression. See ParseFilter for the syntax.
+type Filter struct {
+	root filterNode
+}
+
+// ParseFilter parses a filter expression such as
+//
+//	level>=warn && user_id=="42" && msg contains "timeout"
+//
+// A comparison has a field on the left and a literal on the right. The
+// field is level, msg, or an attribute key, with groups separated by dots
+// (http.status). The operators are ==, !=, <, <=, >, >= and contains.
+// Literals are quoted strings, numbers, true, false, or level names.
+// Comparisons combine with &&, ||, ! and parentheses.
+//
+// Levels compare by severity and numbers numerically; everything else
+// compares as text. A comparison on a missing attribute is false.
+func ParseFilter(expr string) (*Filter, error) {
+	p := &filterParser{tokens: lexFilter(expr)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match reports whether r satisfies the filter.
+func (f *Filter) Match(r slog.Record) bool {
+	return f.root.eval(&filterRecord{r: r})
+}
+
+// FilterHandler passes on only the records that match a Filter.
+type FilterHandler struct {
+	handler slog.Handler
+	filter  *Filter
+	state   sinkState
+}
+
+// NewFilterHandler creates a FilterHandler that writes matching records
+// to handler.
+func NewFilterHandler(handler slog.Handler, f *Filter) *FilterHandler {
+	return &FilterHandler{handler: handler, filter: f}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *FilterHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle passes r on if it matches the filter. Attributes added with
+// WithAttrs and WithGroup are taken into account.
+func (h *FilterHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.filter.root.eval(&filterRecord{r: r, goas: h.state.goas}) {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *FilterHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &FilterHandler{
+		handler: h.handler.WithAttrs(attrs),
+		filter:  h.filter,
+		state:   h.state.withAttrs(attrs),
+	}
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *FilterHandler) WithGroup(name string) slog.Handler {
+	return &FilterHandler{
+		handler: h.handler.WithGroup(name),
+		filter:  h.filter,
+		state:   h.state.withGroup(name),
+	}
+}
+
+// filterRecord looks up fields of a record, flattening its attributes
+// into dotted keys on first use.
+type filterRecord struct {
+	r      slog.Record
+	goas   []groupOrAttrs
+	fields map[string]slog.Value
+}
+
+func (fr *filterRecord) lookup(key string) (slog.Value, bool) {
+	if fr.fields == nil {
+		fr.fields = make(map[string]slog.Value)
+		prefix := ""
+		for _, goa := range fr.goas {
+			if goa.group != "" {
+				prefix += goa.group + "."
+				continue
+			}
+			for _, a := range goa.attrs {
+				fr.add(prefix, a)
+			}
+		}
+		fr.r.Attrs(func(a slog.Attr) bool {
+			fr.add(prefix, a)
+			return true
+		})
+	}
+	v, ok := fr.fields[key]
+	return v, ok
+}
+
+func (fr *filterRecord) add(prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fr.add(prefix, ga)
+		}
+		return
+	}
+	fr.fields[prefix+a.Key] = a.Value
+}
+
+// filterNode is a node of a parsed filter expression.
+type filterNode interface {
+	eval(fr *filterRecord) bool
+}
+
+type (
+	andNode struct{ left, right filterNode }
+	orNode  struct{ left, right filterNode }
+	notNode struct{ node filterNode }
+	cmpNode struct {
+		field string
+		op    string
+		value string
+		// num is set when value is a number
+		num   float64
+		isNum bool
+		// level is set when the field is level
+		level slog.Level
+	}
+)
+
+func (n *andNode) eval(fr *filterRecord) bool { return n.left.eval(fr) && n.right.eval(fr) }
+func (n *orNode) eval(fr *filterRecord) bool  { return n.left.eval(fr) || n.right.eval(fr) }
+func (n *notNode) eval(fr *filterRecord) bool { return !n.node.eval(fr) }
+
+func (n *cmpNode) eval(fr *filterRecord) bool {
+	switch n.field {
+	case slog.LevelKey:
+		return compare(n.op, float64(fr.r.Level), float64(n.level), fr.r.Level.String(), n.value)
+	case slog.MessageKey:
+		return compareText(n.op, fr.r.Message, n.value)
+	}
+
+	v, ok := fr.lookup(n.field)
+	if !ok {
+		return false
+	}
+	if n.isNum {
+		var f float64
+		switch v.Kind() {
+		case slog.KindInt64:
+			f, ok = float64(v.Int64()), true
+		case slog.KindUint64:
+			f, ok = float64(v.Uint64()), true
+		case slog.KindFloat64:
+			f, ok = v.Float64(), true
+		case slog.KindDuration:
+			f, ok = float64(v.Duration()), true
+		default:
+			var err error
+			f, err = strconv.ParseFloat(v.String(), 64)
+			ok = err == nil
+		}
+		if ok {
+			return compare(n.op, f, n.num, v.String(), n.value)
+		}
+	}
+	return compareText(n.op, v.String(), n.value)
+}
+
+func compare(op string, a, b float64, text, value string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	default:
+		return strings.Contains(text, value)
+	}
+}
+
+func compareText(op, a, b string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	default:
+		return strings.Contains(a, b)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokInvalid
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func lexFilter(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return append(tokens, token{tokInvalid, s[i:], i})
+			}
+			tokens = append(tokens, token{tokString, s[i : j+1], i})
+			i = j + 1
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokNumber, s[i:j], i})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i + 1
+			for j < len(s) && (s[j] == '_' || s[j] == '.' || s[j] == '-' ||
+				unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{tokIdent, s[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return append(tokens, token{tokInvalid, s[i : i+1], i})
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(s)})
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *filterParser) errorf(tok token, format string, args ...any) error {
+	return fmt.Errorf("xlog: filter: %s at offset %d", fmt.Sprintf(format, args...), tok.pos)
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" && p.peek().kind == tokOp {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" && p.peek().kind == tokOp {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && tok.text == "!" {
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node}, nil
+	}
+	if tok.kind == tokOp && tok.text == "(" {
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if end := p.next(); end.kind != tokOp || end.text != ")" {
+			return nil, p.errorf(end, "expected )")
+		}
+		return node, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	field := p.next()
+	if field.kind != tokIdent {
+		return nil, p.errorf(field, "expected field name, got %q", field.text)
+	}
+
+	op := p.next()
+	switch op.text {
+	case "==", "!=", "<", "<=", ">", ">=", "contains":
+	default:
+		return nil, p.errorf(op, "expected operator after %s, got %q", field.text, op.text)
+	}
+
+	lit := p.next()
+	n := &cmpNode{field: field.text, op: op.text}
+	switch lit.kind {
+	case tokString:
+		s, err := strconv.Unquote(lit.text)
+		if err != nil {
+			return nil, p.errorf(lit, "invalid string %s", lit.text)
+		}
+		n.value = s
+	case tokNumber:
+		f, err := strconv.ParseFloat(lit.text, 64)
+		if err != nil {
+			return nil, p.errorf(lit, "invalid number %s", lit.text)
+		}
+		n.value, n.num, n.isNum = lit.text, f, true
+	case tokIdent:
+		n.value = lit.text
+	default:
+		return nil, p.errorf(lit, "expected value after %s %s", field.text, op.text)
+	}
+
+	if n.field == slog.LevelKey && n.op != "contains" {
+		if n.isNum {
+			n.level = slog.Level(n.num)
+		} else if level, err := ParseLevel(n.value); err == nil {
+			n.level = level
+		} else {
+			return nil, p.errorf(lit, "invalid level %q", n.value)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,73 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestParseFilter(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "upstream timeout", 0)
+	r.AddAttrs(
+		slog.String("user_id", "42"),
+		slog.Int("attempt", 3),
+		slog.Group("http", slog.Int("status", 504), slog.String("path", "/users")),
+	)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`level>=warn && user_id=="42" && msg contains "timeout"`, true},
+		{`level>=error`, false},
+		{`level == WARN`, true},
+		{`http.status >= 500 && http.path == "/users"`, true},
+		{`attempt < 3`, false},
+		{`attempt > 2.5`, true},
+		{`missing == "x"`, false},
+		{`!(user_id == "7") && (attempt == 1 || http.status == 504)`, true},
+		{`user_id != "42" || msg contains "upstream"`, true},
+	}
+	for _, tt := range tests {
+		f, err := xlog.ParseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := f.Match(r); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{``, `level >=`, `level >= bogus`, `user_id "42"`, `(a == 1`, `a == "unterminated`, `a == 1 b`} {
+		if _, err := xlog.ParseFilter(expr); err == nil {
+			t.Errorf("%q: expected a parse error", expr)
+		}
+	}
+}
+
+func TestFilterHandler(t *testing.T) {
+	f, err := xlog.ParseFilter(`req.tenant == "acme" && level >= info`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	h := xlog.NewFilterHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), f)
+	logger := slog.New(h).WithGroup("req")
+
+	ctx := context.Background()
+	logger.With("tenant", "acme").InfoContext(ctx, "kept")
+	logger.With("tenant", "acme").DebugContext(ctx, "too verbose")
+	logger.InfoContext(ctx, "other tenant", "tenant", "globex")
+
+	output := buf.String()
+	if strings.Count(output, "\n") != 1 || !strings.Contains(output, "kept") {
+		t.Errorf("expected only the matching record, got: %s", output)
+	}
+}
@@ -0,0 +1,111 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+)
+
+// FlattenHandler replaces groups and map[string]any values with dotted
+// keys, such as "http.request.method", before passing records to the
+// handler it wraps, for backends that handle nested JSON poorly.
+type FlattenHandler struct {
+	handler slog.Handler
+	sep     string
+	prefix  string
+}
+
+// NewFlattenHandler creates a FlattenHandler that writes to handler,
+// joining keys with sep, or "." if sep is empty.
+func NewFlattenHandler(handler slog.Handler, sep string) *FlattenHandler {
+	if sep == "" {
+		sep = "."
+	}
+	return &FlattenHandler{handler: handler, sep: sep}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *FlattenHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle rebuilds the record with flat attributes and passes it on.
+func (h *FlattenHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs handles the record with extra flattened ahead of its own
+// attributes.
+func (h *FlattenHandler) handleWithAttrs(ctx context.Context, r slog.Record, extra []slog.Attr) error {
+	attrs := make([]slog.Attr, 0, len(extra)+r.NumAttrs())
+	for _, a := range extra {
+		attrs = h.flatten(attrs, h.prefix, a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = h.flatten(attrs, h.prefix, a)
+		return true
+	})
+
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(attrs...)
+	return h.handler.Handle(ctx, r2)
+}
+
+// WithAttrs returns a new handler with the given attributes, flattened.
+func (h *FlattenHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var flat []slog.Attr
+	for _, a := range attrs {
+		flat = h.flatten(flat, h.prefix, a)
+	}
+	h2 := *h
+	h2.handler = h.handler.WithAttrs(flat)
+	return &h2
+}
+
+// WithGroup returns a new handler that prefixes later keys with name.
+func (h *FlattenHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix = h.prefix + name + h.sep
+	return &h2
+}
+
+// flatten appends a to dst, expanding groups and maps into attributes
+// whose keys start with prefix.
+func (h *FlattenHandler) flatten(dst []slog.Attr, prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	switch {
+	case a.Equal(slog.Attr{}):
+		return dst
+	case a.Value.Kind() == slog.KindGroup:
+		if a.Key != "" {
+			prefix += a.Key + h.sep
+		}
+		for _, ga := range a.Value.Group() {
+			dst = h.flatten(dst, prefix, ga)
+		}
+		return dst
+	}
+	if m, ok := mapValue(a.Value.Any()); ok {
+		prefix += a.Key + h.sep
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			dst = h.flatten(dst, prefix, slog.Any(k, m[k]))
+		}
+		return dst
+	}
+	a.Key = prefix + a.Key
+	return append(dst, a)
+}
+
+func mapValue(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case Fields:
+		return m, true
+	}
+	return nil, false
+}
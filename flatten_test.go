@@ -0,0 +1,45 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestFlattenHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.NewFlattenHandler(slog.NewJSONHandler(&buf, nil), "")
+	logger := slog.New(h).With(slog.Group("svc", "name", "shop")).WithGroup("http")
+
+	logger.Info("request",
+		slog.Group("request", "method", "GET", slog.Group("", "path", "/")),
+		"headers", map[string]any{"b": 2, "a": map[string]any{"x": 1}},
+		slog.Group("empty"),
+	)
+
+	want := `"svc.name":"shop","http.request.method":"GET","http.request.path":"/","http.headers.a.x":1,"http.headers.b":2}`
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), want) {
+		t.Errorf("expected suffix %s, got: %s", want, buf.String())
+	}
+}
+
+func TestWithFlatten(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithFlatten(true),
+	)
+	xlog.Info(xlog.WithTraceID(context.Background(), "t-1"), "flat", xlog.HTTPResponse(200, 10, 0))
+
+	output := buf.String()
+	for _, want := range []string{`"response.status":200`, `"trace_id":"t-1"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s, got: %s", want, output)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// FlightRecorderOptions configures StartFlightRecorder.
+type FlightRecorderOptions struct {
+	// Level is the lowest level recorded, even if it is below the
+	// logger's level. Defaults to slog.LevelDebug.
+	Level slog.Leveler
+
+	// MaxRecords bounds the held records; the oldest are dropped beyond
+	// it. Defaults to 1000.
+	MaxRecords int
+}
+
+// flightRecorderKey is the context key for the recording of a request.
+type flightRecorderKey struct{}
+
+// flightRecording holds the records of one request until an error.
+type flightRecording struct {
+	level slog.Level
+	max   int
+
+	mu        sync.Mutex
+	entries   []flightEntry
+	triggered bool
+}
+
+type flightEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	r       slog.Record
+}
+
+// WithFlightRecorder lets StartFlightRecorder hold the records of the
+// logger. Without it, the logger writes records logged with a flight
+// recorder context as usual, at no cost to the others.
+func WithFlightRecorder(enabled bool) Option {
+	return func(c *config) {
+		c.flight = enabled
+	}
+}
+
+// StartFlightRecorder returns a context in which records of loggers built
+// with WithFlightRecorder below WARN are held instead of written. If a record at ERROR or
+// above is logged with the context, the held records are written before
+// it, and later records are written directly; otherwise they are
+// discarded along with the context. This gives full detail for failing
+// requests at almost no cost for successful ones:
+//
+//	func middleware(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			ctx := xlog.StartFlightRecorder(r.Context(), nil)
+//			next.ServeHTTP(w, r.WithContext(ctx))
+//		})
+//	}
+func StartFlightRecorder(ctx context.Context, opts *FlightRecorderOptions) context.Context {
+	rec := &flightRecording{level: slog.LevelDebug, max: 1000}
+	if opts != nil {
+		if opts.Level != nil {
+			rec.level = opts.Level.Level()
+		}
+		if opts.MaxRecords > 0 {
+			rec.max = opts.MaxRecords
+		}
+	}
+	return context.WithValue(ctx, flightRecorderKey{}, rec)
+}
+
+// FlushFlightRecorder writes the records held for ctx, as an error would,
+// for example when recovering from a panic. Later records with ctx are
+// written directly.
+func FlushFlightRecorder(ctx context.Context) error {
+	if rec := flightRecordingFrom(ctx); rec != nil {
+		return rec.flush()
+	}
+	return nil
+}
+
+func flightRecordingFrom(ctx context.Context) *flightRecording {
+	if ctx == nil {
+		return nil
+	}
+	rec, _ := ctx.Value(flightRecorderKey{}).(*flightRecording)
+	return rec
+}
+
+// flightRecords reports whether records at level are recorded with ctx, even
+// if they are below the logger's level.
+func flightRecords(ctx context.Context, level slog.Level) bool {
+	rec := flightRecordingFrom(ctx)
+	return rec != nil && level >= rec.level
+}
+
+func (rec *flightRecording) flush() error {
+	rec.mu.Lock()
+	entries := rec.entries
+	rec.entries = nil
+	rec.triggered = true
+	rec.mu.Unlock()
+
+	var errs []error
+	for _, e := range entries {
+		errs = append(errs, e.handler.Handle(e.ctx, e.r))
+	}
+	return errors.Join(errs...)
+}
+
+// flightHandler holds records logged with a flight recorder context.
+type flightHandler struct {
+	handler slog.Handler
+}
+
+func (h *flightHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < slog.LevelWarn {
+		if rec := flightRecordingFrom(ctx); rec != nil {
+			rec.mu.Lock()
+			triggered := rec.triggered
+			rec.mu.Unlock()
+			if !triggered {
+				return level >= rec.level
+			}
+		}
+	}
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *flightHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs holds or writes the record with attrs ahead of its own.
+func (h *flightHandler) handleWithAttrs(ctx context.Context, r slog.Record, attrs []slog.Attr) error {
+	rec := flightRecordingFrom(ctx)
+	if rec == nil {
+		return handleWithAttrs(ctx, h.handler, r, attrs)
+	}
+
+	if r.Level < slog.LevelWarn {
+		rec.mu.Lock()
+		if !rec.triggered {
+			if len(rec.entries) == rec.max {
+				rec.entries = append(rec.entries[:0], rec.entries[1:]...)
+			}
+			held := r.Clone()
+			if len(attrs) > 0 {
+				held = prependAttrs(r, attrs)
+			}
+			rec.entries = append(rec.entries, flightEntry{ctx: ctx, handler: h.handler, r: held})
+			rec.mu.Unlock()
+			return nil
+		}
+		rec.mu.Unlock()
+	}
+	if r.Level >= slog.LevelError {
+		if err := rec.flush(); err != nil {
+			return errors.Join(err, handleWithAttrs(ctx, h.handler, r, attrs))
+		}
+	}
+	return handleWithAttrs(ctx, h.handler, r, attrs)
+}
+
+func (h *flightHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &flightHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *flightHandler) WithGroup(name string) slog.Handler {
+	return &flightHandler{handler: h.handler.WithGroup(name)}
+}
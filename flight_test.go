@@ -0,0 +1,74 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithFlightRecorder(true),
+	)
+
+	// A successful request writes only what is at WARN or above
+	ctx := xlog.StartFlightRecorder(context.Background(), nil)
+	xlog.Debug(ctx, "cache miss")
+	xlog.Info(ctx, "loaded")
+	xlog.Warn(ctx, "slow query")
+	if got := buf.String(); strings.Contains(got, "cache miss") || strings.Contains(got, "loaded") || !strings.Contains(got, "slow query") {
+		t.Errorf("expected only the warning, got: %s", got)
+	}
+
+	// A failing request writes the held records before the error
+	buf.Reset()
+	ctx = xlog.StartFlightRecorder(xlog.WithTraceID(context.Background(), "t-1"), nil)
+	xlog.With("user", "alice").Debug(ctx, "cache miss")
+	xlog.Info(ctx, "loaded")
+	xlog.Error(ctx, "failed")
+	xlog.Debug(ctx, "after")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{"cache miss", "loaded", "failed", "after"}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d records, got: %s", len(want), buf.String())
+	}
+	for i, msg := range want {
+		if !strings.Contains(lines[i], `"msg":"`+msg+`"`) || !strings.Contains(lines[i], `"trace_id":"t-1"`) {
+			t.Errorf("record %d: expected %q with trace_id, got %s", i, msg, lines[i])
+		}
+	}
+	if !strings.Contains(lines[0], `"user":"alice"`) {
+		t.Errorf("expected With attrs on held records, got %s", lines[0])
+	}
+
+	// Without a recorder, DEBUG stays below the level
+	buf.Reset()
+	xlog.Debug(context.Background(), "hidden")
+	if buf.Len() > 0 {
+		t.Errorf("expected no output, got: %s", buf.String())
+	}
+}
+
+func TestFlightRecorderDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	// Without WithFlightRecorder, records are written as usual
+	ctx := xlog.StartFlightRecorder(context.Background(), nil)
+	xlog.Debug(ctx, "cache miss")
+	xlog.Info(ctx, "loaded")
+	if got := buf.String(); strings.Contains(got, "cache miss") || !strings.Contains(got, "loaded") {
+		t.Errorf("expected only the INFO record, got: %s", got)
+	}
+}
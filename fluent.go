@@ -0,0 +1,365 @@
+package xlog
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FluentHandlerOptions configures a FluentHandler.
+type FluentHandlerOptions struct {
+	// Tag is the Fluentd tag attached to every record. Defaults to "xlog".
+	Tag string
+
+	// Network is the dial network, "tcp" or "unix". Defaults to "tcp".
+	Network string
+
+	// Level is the minimum level to forward. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// RequireAck waits for the agent to acknowledge each record
+	// (forward protocol "chunk" option) before Handle returns.
+	RequireAck bool
+
+	// Timeout bounds dialing, writing, and waiting for acks. Defaults to 5s.
+	Timeout time.Duration
+}
+
+// FluentHandler ships records to a Fluentd or Fluent Bit agent using the
+// forward protocol (msgpack over TCP). The connection is dialed lazily and
+// re-established after any error.
+type FluentHandler struct {
+	addr  string
+	opts  FluentHandlerOptions
+	state sinkState
+	conn  *fluentConn
+}
+
+// fluentConn is shared between a FluentHandler and its derived handlers.
+type fluentConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewFluentHandler creates a FluentHandler that forwards records to addr.
+func NewFluentHandler(addr string, opts *FluentHandlerOptions) *FluentHandler {
+	var o FluentHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Tag == "" {
+		o.Tag = "xlog"
+	}
+	if o.Network == "" {
+		o.Network = "tcp"
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	return &FluentHandler{
+		addr: addr,
+		opts: o,
+		conn: &fluentConn{},
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *FluentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// Handle encodes the record as a forward protocol message and sends it.
+func (h *FluentHandler) Handle(_ context.Context, r slog.Record) error {
+	record := h.state.fields(r)
+	record[slog.LevelKey] = r.Level.String()
+	record[slog.MessageKey] = r.Message
+
+	var chunk string
+	if h.opts.RequireAck {
+		var err error
+		if chunk, err = newChunkID(); err != nil {
+			return err
+		}
+	}
+
+	// Message mode: [tag, time, record, option?]
+	n := 3
+	if chunk != "" {
+		n = 4
+	}
+	buf := make([]byte, 0, 256)
+	buf = appendMsgpackArrayHeader(buf, n)
+	buf = appendMsgpack(buf, h.opts.Tag)
+	buf = appendEventTime(buf, r.Time)
+	buf = appendMsgpack(buf, record)
+	if chunk != "" {
+		buf = appendMsgpack(buf, map[string]any{"chunk": chunk})
+	}
+
+	return h.conn.send(h, buf, chunk)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *FluentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *FluentHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Close closes the connection to the agent.
+func (h *FluentHandler) Close() error {
+	h.conn.mu.Lock()
+	defer h.conn.mu.Unlock()
+	return h.conn.closeLocked()
+}
+
+func (c *fluentConn) send(h *FluentHandler, msg []byte, chunk string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout(h.opts.Network, h.addr, h.opts.Timeout)
+		if err != nil {
+			return fmt.Errorf("xlog: fluent dial: %w", err)
+		}
+		c.conn = conn
+		c.r = bufio.NewReader(conn)
+	}
+
+	_ = c.conn.SetDeadline(time.Now().Add(h.opts.Timeout))
+	if _, err := c.conn.Write(msg); err != nil {
+		_ = c.closeLocked()
+		return fmt.Errorf("xlog: fluent write: %w", err)
+	}
+	if chunk == "" {
+		return nil
+	}
+
+	ack, err := readMsgpackStringMap(c.r)
+	if err != nil {
+		_ = c.closeLocked()
+		return fmt.Errorf("xlog: fluent ack: %w", err)
+	}
+	if ack["ack"] != chunk {
+		_ = c.closeLocked()
+		return errors.New("xlog: fluent ack mismatch")
+	}
+	return nil
+}
+
+func (c *fluentConn) closeLocked() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.r = nil
+	return err
+}
+
+func newChunkID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b[:]), nil
+}
+
+// appendEventTime encodes t as the forward protocol EventTime extension
+// (fixext8, type 0) to preserve nanosecond precision.
+func appendEventTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xd7, 0x00)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(t.Unix()))
+	return binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+}
+
+func appendMsgpackArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+func appendMsgpackString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendMsgpack encodes the plain Go values produced by valueToAny.
+// Unknown types are encoded as their fmt representation.
+func appendMsgpack(buf []byte, v any) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if x {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendMsgpackInt(buf, int64(x))
+	case int64:
+		return appendMsgpackInt(buf, x)
+	case uint64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), x)
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcb), math.Float64bits(x))
+	case string:
+		return appendMsgpackString(buf, x)
+	case []byte:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(len(x)))
+		return append(buf, x...)
+	case []any:
+		buf = appendMsgpackArrayHeader(buf, len(x))
+		for _, e := range x {
+			buf = appendMsgpack(buf, e)
+		}
+		return buf
+	case map[string]any:
+		// Sort keys so output is deterministic
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf = appendMsgpackMapHeader(buf, len(x))
+		for _, k := range keys {
+			buf = appendMsgpackString(buf, k)
+			buf = appendMsgpack(buf, x[k])
+		}
+		return buf
+	default:
+		return appendMsgpackString(buf, fmt.Sprint(x))
+	}
+}
+
+func appendMsgpackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i < 128:
+		return append(buf, byte(i))
+	case i < 0 && i >= -32:
+		return append(buf, byte(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(i))
+	}
+}
+
+// readMsgpackStringMap decodes a single msgpack map whose keys and values
+// are strings, which is the shape of a forward protocol ack response.
+func readMsgpackStringMap(r *bufio.Reader) (map[string]string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case b&0xf0 == 0x80:
+		n = int(b & 0x0f)
+	case b == 0xde:
+		var u uint16
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return nil, err
+		}
+		n = int(u)
+	default:
+		return nil, fmt.Errorf("unexpected msgpack type 0x%x", b)
+	}
+
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func readMsgpackString(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9:
+		l, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		n = int(l)
+	case b == 0xda:
+		var u uint16
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return "", err
+		}
+		n = int(u)
+	case b == 0xdb:
+		var u uint32
+		if err := binary.Read(r, binary.BigEndian, &u); err != nil {
+			return "", err
+		}
+		n = int(u)
+	default:
+		return "", fmt.Errorf("unexpected msgpack type 0x%x", b)
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(r, s); err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
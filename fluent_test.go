@@ -0,0 +1,79 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestFluentHandlerAck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		msg := buf[:n]
+		received <- msg
+
+		// The chunk option is the last element: {"chunk": <24-byte base64>}
+		i := bytes.LastIndex(msg, []byte("chunk"))
+		if i < 0 {
+			return
+		}
+		chunk := msg[i+6 : i+6+24]
+		ack := append([]byte{0x81, 0xa3}, "ack"...)
+		ack = append(ack, 0xb8)
+		ack = append(ack, chunk...)
+		_, _ = conn.Write(ack)
+	}()
+
+	h := xlog.NewFluentHandler(ln.Addr().String(), &xlog.FluentHandlerOptions{
+		Tag:        "app.test",
+		RequireAck: true,
+		Timeout:    2 * time.Second,
+	})
+	defer h.Close()
+
+	logger := slog.New(h).With("service", "api")
+	if err := logger.Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "fluent message", 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msg := <-received
+	for _, want := range []string{"app.test", "fluent message", "service", "api"} {
+		if !bytes.Contains(msg, []byte(want)) {
+			t.Errorf("expected message to contain %q, got: %q", want, msg)
+		}
+	}
+}
+
+func TestFluentHandlerDialError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	h := xlog.NewFluentHandler(addr, &xlog.FluentHandlerOptions{Timeout: time.Second})
+	err = h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "dropped", 0))
+	if err == nil {
+		t.Error("expected dial error")
+	}
+}
@@ -0,0 +1,107 @@
+// Package ginlog provides Gin middleware logging requests with xlog. It is
+// a separate module, so xlog itself does not depend on Gin.
+package ginlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/taro33333/xlog"
+)
+
+// Middleware returns a gin.HandlerFunc that, for each request:
+//
+//   - reads the request ID from the X-Request-ID header, or generates one
+//     if it is absent or malformed, and echoes it in the response;
+//   - adds the request ID to the request context, so handlers log with
+//     xlog.Info(c.Request.Context(), ...) or FromContext(c);
+//   - logs one record with the request, including the route pattern such
+//     as "/items/:id", and the response, at ERROR for 5xx responses;
+//   - recovers panics, logging them at ERROR with their stack and a new
+//     error ID, which a 500 response quotes.
+//
+// Install it first:
+//
+//	r := gin.New()
+//	r.Use(ginlog.Middleware())
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(xlog.RequestIDHeader)
+		if !xlog.ValidRequestID(id) {
+			id = xlog.NewRequestID()
+		}
+		c.Header(xlog.RequestIDHeader, id)
+		ctx := xlog.WithRequestID(c.Request.Context(), id)
+		c.Request = c.Request.WithContext(ctx)
+
+		var errorID string
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				errorID = recovered(ctx, c, p)
+			}
+			logRequest(ctx, c, time.Since(start), errorID)
+		}()
+		c.Next()
+	}
+}
+
+// FromContext returns the logger installed by Middleware for the request
+// of c.
+func FromContext(c *gin.Context) *xlog.Logger {
+	return xlog.FromContext(c.Request.Context())
+}
+
+// recovered logs the panic p and answers the request with a 500 quoting a
+// new error ID, which it returns.
+func recovered(ctx context.Context, c *gin.Context, p any) string {
+	id := xlog.NewRequestID()
+	l := xlog.FromContext(ctx)
+	if l.Logger.Enabled(ctx, slog.LevelError) {
+		r := slog.NewRecord(time.Now(), slog.LevelError, "panic recovered", 0)
+		r.Add("panic", p, "stack", string(debug.Stack()), xlog.ErrorIDKey, id, xlog.HTTPRequest(c.Request))
+		_ = l.Logger.Handler().Handle(ctx, r)
+	}
+	if c.Writer.Written() {
+		c.Abort()
+	} else {
+		c.Header(xlog.ErrorIDHeader, id)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(c.Writer, "Internal Server Error (error ID: %s)\n", id)
+	}
+	return id
+}
+
+// logRequest logs the request of c, served in dur.
+func logRequest(ctx context.Context, c *gin.Context, dur time.Duration, errorID string) {
+	status := c.Writer.Status()
+	level := slog.LevelInfo
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	l := xlog.FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	req := *c.Request
+	req.Pattern = c.FullPath()
+	r := slog.NewRecord(time.Now(), level, "request", 0)
+	r.AddAttrs(xlog.HTTPRequest(&req), xlog.HTTPResponse(status, int64(max(c.Writer.Size(), 0)), dur))
+	if len(c.Errors) > 0 {
+		r.AddAttrs(slog.String("errors", c.Errors.String()))
+	}
+	if errorID != "" {
+		r.AddAttrs(slog.String(xlog.ErrorIDKey, errorID))
+	}
+	_ = l.Logger.Handler().Handle(ctx, r)
+}
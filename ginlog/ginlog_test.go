@@ -0,0 +1,88 @@
+package ginlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/ginlog"
+)
+
+type record struct {
+	Level   string `json:"level"`
+	Msg     string `json:"msg"`
+	ErrorID string `json:"error_id"`
+	Request struct {
+		Route string `json:"route"`
+	} `json:"request"`
+	Response struct {
+		Status int `json:"status"`
+	} `json:"response"`
+}
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(ginlog.Middleware())
+	r.GET("/items/:id", func(c *gin.Context) {
+		ginlog.FromContext(c).Info(c.Request.Context(), "loading item")
+		c.String(http.StatusOK, "item")
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/items/42", nil))
+	if w.Header().Get(xlog.RequestIDHeader) == "" {
+		t.Error("expected a request ID in the response")
+	}
+
+	dec := json.NewDecoder(&buf)
+	var rec record
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "loading item" {
+		t.Errorf("expected the handler's record, got: %+v", rec)
+	}
+	rec = record{}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "request" || rec.Request.Route != "/items/:id" || rec.Response.Status != 200 {
+		t.Errorf("unexpected access record: %+v", rec)
+	}
+
+	req := httptest.NewRequest("GET", "/items/42", nil)
+	req.Header.Set(xlog.RequestIDHeader, `bad "id"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get(xlog.RequestIDHeader); got == "" || got == `bad "id"` {
+		t.Errorf("expected a malformed request ID to be replaced, got %q", got)
+	}
+
+	buf.Reset()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	id := w.Header().Get(xlog.ErrorIDHeader)
+	if w.Code != http.StatusInternalServerError || id == "" || !strings.Contains(w.Body.String(), id) {
+		t.Fatalf("expected a 500 quoting the error ID, got %d: %s", w.Code, w.Body.String())
+	}
+	dec = json.NewDecoder(&buf)
+	for _, want := range []string{"panic recovered", "request"} {
+		rec = record{}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Level != "ERROR" || rec.Msg != want || rec.ErrorID != id {
+			t.Errorf("expected %q at ERROR with the error ID, got: %+v", want, rec)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package xlog
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -19,19 +23,97 @@ const (
 	RequestIDKey ContextKey = "request_id"
 	SessionIDKey ContextKey = "session_id"
 	SpanIDKey    ContextKey = "span_id"
+	MessageIDKey ContextKey = "message_id"
+
+	// CloudTraceKey and CloudSpanIDKey hold the trace and span in the
+	// fields Google Cloud Logging groups records by; see
+	// CloudTraceMiddleware.
+	CloudTraceKey  ContextKey = "logging.googleapis.com/trace"
+	CloudSpanIDKey ContextKey = "logging.googleapis.com/spanId"
 )
 
+// maxPooledBuffer is the largest buffer returned to a pool; larger ones are
+// left for the garbage collector so one huge record doesn't pin memory.
+const maxPooledBuffer = 64 << 10
+
+var (
+	bufPool = sync.Pool{
+		New: func() any {
+			b := make([]byte, 0, 256)
+			return &b
+		},
+	}
+	attrPool = sync.Pool{
+		New: func() any {
+			a := make([]slog.Attr, 0, 8)
+			return &a
+		},
+	}
+)
+
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBuffer {
+		return
+	}
+	*b = (*b)[:0]
+	bufPool.Put(b)
+}
+
+func putAttrs(a *[]slog.Attr) {
+	clear(*a)
+	*a = (*a)[:0]
+	attrPool.Put(a)
+}
+
+// attrsHandler is implemented by handlers that can emit extra attributes
+// ahead of a record's own attributes without the record being cloned.
+type attrsHandler interface {
+	handleWithAttrs(ctx context.Context, r slog.Record, attrs []slog.Attr) error
+}
+
+// handleWithAttrs passes r to h with attrs ahead of the record's own
+// attributes, adding them to a copy of the record unless h accepts them
+// alongside it.
+func handleWithAttrs(ctx context.Context, h slog.Handler, r slog.Record, attrs []slog.Attr) error {
+	if len(attrs) == 0 {
+		return h.Handle(ctx, r)
+	}
+	if ah, ok := h.(attrsHandler); ok {
+		return ah.handleWithAttrs(ctx, r, attrs)
+	}
+	return h.Handle(ctx, prependAttrs(r, attrs))
+}
+
+// prependAttrs returns a copy of r with attrs ahead of its own attributes.
+func prependAttrs(r slog.Record, attrs []slog.Attr) slog.Record {
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		r2.AddAttrs(a)
+		return true
+	})
+	return r2
+}
+
 // ContextHandler wraps a slog.Handler and extracts values from context.
 type ContextHandler struct {
 	handler slog.Handler
 	keys    []ContextKey
+	// boxed holds keys converted to interfaces once, as each conversion
+	// for ctx.Value would allocate
+	boxed []any
 }
 
 // NewContextHandler creates a new ContextHandler that extracts the specified keys from context.
 func NewContextHandler(handler slog.Handler, keys ...ContextKey) *ContextHandler {
+	boxed := make([]any, len(keys))
+	for i, key := range keys {
+		boxed[i] = key
+	}
 	return &ContextHandler{
 		handler: handler,
 		keys:    keys,
+		boxed:   boxed,
 	}
 }
 
@@ -43,26 +125,38 @@ func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
 // Handle extracts context values and adds them to the record before delegating.
 func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
 	// Extract values from context and add as attributes
-	// Use a pre-allocated slice to minimize allocations
-	attrs := make([]slog.Attr, 0, len(h.keys))
+	// Use a pooled slice to minimize allocations
+	ap := attrPool.Get().(*[]slog.Attr)
+	defer putAttrs(ap)
+	attrs := (*ap)[:0]
 
-	for _, key := range h.keys {
-		if v := ctx.Value(key); v != nil {
+	for i, key := range h.keys {
+		if v := ctx.Value(h.boxed[i]); v != nil {
 			attrs = append(attrs, slog.Any(string(key), v))
 		}
 	}
 
 	if len(attrs) > 0 {
+		*ap = attrs
+
+		// xlog's own handlers accept the context attributes alongside the
+		// record, so the record doesn't need to be rebuilt
+		if ah, ok := h.handler.(attrsHandler); ok {
+			return ah.handleWithAttrs(ctx, r, attrs)
+		}
+
 		// Clone the record and add context attributes at the beginning
 		r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
-		r2.AddAttrs(attrs...)
 		r.Attrs(func(a slog.Attr) bool {
-			r2.AddAttrs(a)
+			attrs = append(attrs, a)
 			return true
 		})
+		*ap = attrs
+		r2.AddAttrs(attrs...)
 		return h.handler.Handle(ctx, r2)
 	}
 
+	*ap = attrs
 	return h.handler.Handle(ctx, r)
 }
 
@@ -71,6 +165,7 @@ func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &ContextHandler{
 		handler: h.handler.WithAttrs(attrs),
 		keys:    h.keys,
+		boxed:   h.boxed,
 	}
 }
 
@@ -79,6 +174,7 @@ func (h *ContextHandler) WithGroup(name string) slog.Handler {
 	return &ContextHandler{
 		handler: h.handler.WithGroup(name),
 		keys:    h.keys,
+		boxed:   h.boxed,
 	}
 }
 
@@ -95,14 +191,29 @@ const (
 	colorBold   = "\033[1m"
 )
 
+// outputMutex returns the lock a handler should hold while writing to w,
+// or nil if w already handles concurrent writes atomically.
+func outputMutex(w io.Writer) *sync.Mutex {
+	if _, ok := w.(concurrentWriter); ok {
+		return nil
+	}
+	return &sync.Mutex{}
+}
+
 // ColorHandler is a development-friendly handler with colored output.
+// Attributes inside groups are written with dot-separated keys, outermost
+// group first (e.g. "http.request.method=GET").
 type ColorHandler struct {
-	opts      *slog.HandlerOptions
-	output    io.Writer
-	mu        *sync.Mutex
-	attrs     []slog.Attr
-	groups    []string
-	preformat string
+	opts        *slog.HandlerOptions
+	output      io.Writer
+	mu          *sync.Mutex
+	attrs       []slog.Attr
+	groups      []string
+	groupPrefix string
+	preformat   string
+	preblock    string
+	color       ColorOptions
+	columns     *colorColumns
 }
 
 // NewColorHandler creates a new ColorHandler for development environments.
@@ -113,7 +224,7 @@ func NewColorHandler(output io.Writer, opts *slog.HandlerOptions) *ColorHandler
 	return &ColorHandler{
 		opts:   opts,
 		output: output,
-		mu:     &sync.Mutex{},
+		mu:     outputMutex(output),
 		attrs:  make([]slog.Attr, 0),
 		groups: make([]string, 0),
 	}
@@ -129,97 +240,155 @@ func (h *ColorHandler) Enabled(_ context.Context, level slog.Level) bool {
 }
 
 // Handle formats and writes the log record with colors.
-func (h *ColorHandler) Handle(_ context.Context, r slog.Record) error {
-	// Get level color
-	levelColor := h.levelColor(r.Level)
-	levelStr := h.levelString(r.Level)
+func (h *ColorHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
 
-	// Build the log line using a byte slice for efficiency
-	buf := make([]byte, 0, 256)
+// handleWithAttrs formats the record with attrs written before its own.
+func (h *ColorHandler) handleWithAttrs(_ context.Context, r slog.Record, attrs []slog.Attr) error {
+	// Build the log line using a pooled byte slice for efficiency
+	bp := bufPool.Get().(*[]byte)
+	defer putBuffer(bp)
+	buf := *bp
+
+	if icon, ok := h.color.icon(r.Level); ok {
+		buf = append(buf, levelColor(r.Level)...)
+		buf = append(buf, icon...)
+		buf = append(buf, colorReset...)
+		buf = append(buf, ' ')
+	}
 
 	// Timestamp
 	if !r.Time.IsZero() {
-		buf = append(buf, colorGray...)
-		if h.opts.ReplaceAttr != nil {
-			a := h.opts.ReplaceAttr(nil, slog.Time(slog.TimeKey, r.Time))
-			buf = append(buf, a.Value.String()...)
+		a := slog.Time(slog.TimeKey, r.Time)
+		if h.color.Time != nil {
+			a.Value = h.color.Time(r.Time)
+		}
+		if a, ok := h.builtin(a); ok {
+			buf = append(buf, colorGray...)
+			start := len(buf)
+			switch a.Value.Kind() {
+			case slog.KindTime:
+				buf = a.Value.Time().AppendFormat(buf, "2006-01-02 15:04:05")
+			case slog.KindString:
+				// Formatted times are written as they are, spaces included
+				buf = append(buf, a.Value.String()...)
+			default:
+				buf = appendValue(buf, a.Value)
+			}
+			if h.color.Align {
+				buf = padLeft(buf, start, len(buf), fit(&h.columns.time, buf[start:], 0))
+			}
+			buf = append(buf, colorReset...)
+			buf = append(buf, ' ')
+		}
+	}
+
+	// Level
+	if a, ok := h.builtin(slog.Any(slog.LevelKey, r.Level)); ok {
+		buf = append(buf, levelColor(r.Level)...)
+		start := len(buf)
+		if l, isLevel := a.Value.Any().(slog.Level); isLevel {
+			buf = append(buf, h.levelString(l)...)
 		} else {
-			buf = append(buf, r.Time.Format("2006-01-02 15:04:05")...)
+			buf = appendValue(buf, a.Value)
+		}
+		if h.color.Align {
+			buf = padRight(buf, start, len(buf), fit(&h.columns.level, buf[start:], 0))
 		}
 		buf = append(buf, colorReset...)
 		buf = append(buf, ' ')
 	}
 
-	// Level
-	buf = append(buf, levelColor...)
-	buf = append(buf, levelStr...)
-	buf = append(buf, colorReset...)
-	buf = append(buf, ' ')
-
 	// Source
-	if h.opts.AddSource && r.PC != 0 {
-		buf = append(buf, colorCyan...)
-		buf = append(buf, h.formatSource(r.PC)...)
-		buf = append(buf, colorReset...)
-		buf = append(buf, ' ')
+	if h.opts.AddSource {
+		start := len(buf)
+		if r.PC != 0 {
+			buf = h.appendSource(buf, r.PC)
+		}
+		if h.color.Align {
+			buf = h.alignSource(buf, start)
+		}
 	}
 
 	// Message
-	buf = append(buf, colorBold...)
-	buf = append(buf, r.Message...)
-	buf = append(buf, colorReset...)
+	if a, ok := h.builtin(slog.String(slog.MessageKey, r.Message)); ok {
+		buf = append(buf, colorBold...)
+		start := len(buf)
+		if a.Value.Kind() == slog.KindString {
+			buf = append(buf, a.Value.String()...)
+		} else {
+			buf = appendValue(buf, a.Value)
+		}
+		end := len(buf)
+		buf = append(buf, colorReset...)
+		if h.color.Align && (h.preformat != "" || len(attrs) > 0 || r.NumAttrs() > 0) {
+			buf = padRight(buf, start, end, fit(&h.columns.message, buf[start:end], maxAlignedMessage))
+		}
+	} else if len(buf) > 0 && buf[len(buf)-1] == ' ' {
+		buf = buf[:len(buf)-1]
+	}
 
 	// Pre-formatted attrs from WithAttrs
-	if h.preformat != "" {
-		buf = append(buf, ' ')
-		buf = append(buf, h.preformat...)
-	}
+	buf = append(buf, h.preformat...)
 
-	// Record attrs
+	// Record attrs, with multi-line values gathered into a block
+	var block []byte
+	block = append(block, h.preblock...)
+	for _, a := range attrs {
+		buf = h.appendAttr(buf, &block, a, h.groups, h.groupPrefix)
+	}
 	r.Attrs(func(a slog.Attr) bool {
-		buf = append(buf, ' ')
-		buf = h.appendAttr(buf, a, h.groups)
+		buf = h.appendAttr(buf, &block, a, h.groups, h.groupPrefix)
 		return true
 	})
 
 	buf = append(buf, '\n')
+	buf = append(buf, block...)
+	*bp = buf
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
+	if h.mu != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+	}
 	_, err := h.output.Write(buf)
 	return err
 }
 
+// builtin passes a built-in attribute through ReplaceAttr, reporting
+// false if it was removed.
+func (h *ColorHandler) builtin(a slog.Attr) (slog.Attr, bool) {
+	if h.opts.ReplaceAttr == nil {
+		return a, true
+	}
+	a = h.opts.ReplaceAttr(nil, a)
+	a.Value = a.Value.Resolve()
+	return a, a.Key != ""
+}
+
 // WithAttrs returns a new handler with the given attributes.
 func (h *ColorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	newAttrs := make([]slog.Attr, len(h.attrs), len(h.attrs)+len(attrs))
 	copy(newAttrs, h.attrs)
 	newAttrs = append(newAttrs, attrs...)
 
-	// Pre-format the attributes
-	var buf []byte
+	// Pre-format the attributes under the groups open right now
+	var buf, block []byte
 	for _, a := range attrs {
-		if len(buf) > 0 {
-			buf = append(buf, ' ')
-		}
-		buf = h.appendAttr(buf, a, h.groups)
-	}
-
-	preformat := h.preformat
-	if len(buf) > 0 {
-		if preformat != "" {
-			preformat += " "
-		}
-		preformat += string(buf)
+		buf = h.appendAttr(buf, &block, a, h.groups, h.groupPrefix)
 	}
 
 	return &ColorHandler{
-		opts:      h.opts,
-		output:    h.output,
-		mu:        h.mu,
-		attrs:     newAttrs,
-		groups:    h.groups,
-		preformat: preformat,
+		opts:        h.opts,
+		output:      h.output,
+		mu:          h.mu,
+		attrs:       newAttrs,
+		groups:      h.groups,
+		groupPrefix: h.groupPrefix,
+		preformat:   h.preformat + string(buf),
+		preblock:    h.preblock + string(block),
+		color:       h.color,
+		columns:     h.columns,
 	}
 }
 
@@ -233,16 +402,21 @@ func (h *ColorHandler) WithGroup(name string) slog.Handler {
 	newGroups = append(newGroups, name)
 
 	return &ColorHandler{
-		opts:      h.opts,
-		output:    h.output,
-		mu:        h.mu,
-		attrs:     h.attrs,
-		groups:    newGroups,
-		preformat: h.preformat,
+		opts:        h.opts,
+		output:      h.output,
+		mu:          h.mu,
+		attrs:       h.attrs,
+		groups:      newGroups,
+		groupPrefix: h.groupPrefix + name + ".",
+		preformat:   h.preformat,
+		preblock:    h.preblock,
+		color:       h.color,
+		columns:     h.columns,
 	}
 }
 
-func (h *ColorHandler) levelColor(level slog.Level) string {
+// levelColor returns the color of records at level.
+func levelColor(level slog.Level) string {
 	switch {
 	case level >= slog.LevelError:
 		return colorRed
@@ -268,10 +442,32 @@ func (h *ColorHandler) levelString(level slog.Level) string {
 	}
 }
 
-func (h *ColorHandler) formatSource(pc uintptr) string {
+func (h *ColorHandler) appendSource(buf []byte, pc uintptr) []byte {
 	frames := runtime.CallersFrames([]uintptr{pc})
 	frame, _ := frames.Next()
-	if frame.File != "" {
+	if frame.File == "" {
+		return buf
+	}
+
+	var custom slog.Value
+	replaced := false
+	if h.opts.ReplaceAttr != nil {
+		src := &slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}
+		a, ok := h.builtin(slog.Any(slog.SourceKey, src))
+		if !ok {
+			return buf
+		}
+		if s, isSource := a.Value.Any().(*slog.Source); isSource {
+			frame.File, frame.Line = s.File, s.Line
+		} else {
+			custom, replaced = a.Value, true
+		}
+	}
+
+	buf = append(buf, colorCyan...)
+	if replaced {
+		buf = appendValue(buf, custom)
+	} else {
 		// Extract just the filename, not the full path
 		short := frame.File
 		for i := len(frame.File) - 1; i > 0; i-- {
@@ -280,31 +476,29 @@ func (h *ColorHandler) formatSource(pc uintptr) string {
 				break
 			}
 		}
-		return fmt.Sprintf("%s:%d", short, frame.Line)
+		buf = append(buf, short...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
 	}
-	return ""
+	buf = append(buf, colorReset...)
+	return append(buf, ' ')
 }
 
-func (h *ColorHandler) appendAttr(buf []byte, a slog.Attr, groups []string) []byte {
-	// Skip empty attrs
-	if a.Equal(slog.Attr{}) {
-		return buf
-	}
+// appendAttr writes a as " key=value", or to block if it is a multi-line
+// string and ColorOptions.Multiline is set. groups are the open group
+// names passed to ReplaceAttr and prefix is the same path joined with dots.
+func (h *ColorHandler) appendAttr(buf []byte, block *[]byte, a slog.Attr, groups []string, prefix string) []byte {
+	a.Value = a.Value.Resolve()
 
-	// Handle ReplaceAttr if set
-	if h.opts.ReplaceAttr != nil {
+	// Handle ReplaceAttr if set; it is not called for groups
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
 		a = h.opts.ReplaceAttr(groups, a)
-		if a.Equal(slog.Attr{}) {
-			return buf
-		}
+		a.Value = a.Value.Resolve()
 	}
 
-	// Build key with groups
-	key := a.Key
-	if len(groups) > 0 {
-		for _, g := range groups {
-			key = g + "." + key
-		}
+	// Skip empty attrs
+	if a.Equal(slog.Attr{}) {
+		return buf
 	}
 
 	// Handle group values
@@ -313,41 +507,73 @@ func (h *ColorHandler) appendAttr(buf []byte, a slog.Attr, groups []string) []by
 		if len(groupAttrs) == 0 {
 			return buf
 		}
-		newGroups := append(groups, a.Key)
-		for i, ga := range groupAttrs {
-			if i > 0 || len(buf) > 0 {
-				buf = append(buf, ' ')
-			}
-			buf = h.appendAttr(buf, ga, newGroups)
+		// Inline groups with an empty key
+		if a.Key != "" {
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+			prefix += a.Key + "."
+		}
+		for _, ga := range groupAttrs {
+			buf = h.appendAttr(buf, block, ga, groups, prefix)
 		}
 		return buf
 	}
 
+	if h.color.Multiline {
+		if raw, ok := a.Value.Any().(jsonValue); ok {
+			var indented bytes.Buffer
+			if json.Indent(&indented, raw, "", "  ") == nil {
+				a.Value = slog.StringValue(indented.String())
+			}
+		}
+		if a.Value.Kind() == slog.KindString && strings.Contains(a.Value.String(), "\n") {
+			*block = appendBlock(*block, prefix+a.Key, a.Value.String())
+			return buf
+		}
+	}
+
 	// Format key=value
+	buf = append(buf, ' ')
 	buf = append(buf, colorPurple...)
-	buf = append(buf, key...)
+	buf = append(buf, prefix...)
+	buf = append(buf, a.Key...)
 	buf = append(buf, colorReset...)
 	buf = append(buf, '=')
-	buf = append(buf, formatValue(a.Value)...)
+	buf = appendValue(buf, a.Value)
 
 	return buf
 }
 
-func formatValue(v slog.Value) string {
+func appendValue(buf []byte, v slog.Value) []byte {
 	switch v.Kind() {
 	case slog.KindString:
 		s := v.String()
 		// Quote strings with spaces
 		if needsQuoting(s) {
-			return fmt.Sprintf("%q", s)
+			return strconv.AppendQuote(buf, s)
 		}
-		return s
+		return append(buf, s...)
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
 	case slog.KindTime:
-		return v.Time().Format("2006-01-02T15:04:05.000Z07:00")
+		return v.Time().AppendFormat(buf, "2006-01-02T15:04:05.000Z07:00")
 	case slog.KindDuration:
-		return v.Duration().String()
+		return append(buf, v.Duration().String()...)
+	case slog.KindLogValuer:
+		return appendValue(buf, v.Resolve())
 	default:
-		return fmt.Sprintf("%v", v.Any())
+		switch x := v.Any().(type) {
+		case error:
+			return append(buf, x.Error()...)
+		case fmt.Stringer:
+			return append(buf, x.String()...)
+		}
+		return fmt.Append(buf, v.Any())
 	}
 }
 
@@ -379,3 +605,10 @@ func WithUserID(ctx context.Context, userID string) context.Context {
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, RequestIDKey, requestID)
 }
+
+// RequestIDFromContext returns the request ID added with WithRequestID,
+// or "" if there is none, for passing it on to outgoing requests.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
@@ -0,0 +1,207 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+// stripColors removes ANSI escape sequences from ColorHandler output.
+func stripColors(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\033' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func TestColorHandlerValues(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewColorHandler(&buf, nil))
+
+	ts := time.Date(2024, 1, 15, 10, 30, 45, 123e6, time.UTC)
+	logger.Info("values",
+		"int", -42,
+		"uint", uint64(7),
+		"float", 1.5,
+		"bool", true,
+		"time", ts,
+		"dur", 1500*time.Millisecond,
+		"quoted", "a b",
+		"err", errors.New("boom"),
+		"slice", []int{1, 2},
+	)
+
+	output := stripColors(buf.String())
+	for _, want := range []string{
+		"int=-42",
+		"uint=7",
+		"float=1.5",
+		"bool=true",
+		"time=2024-01-15T10:30:45.123Z",
+		"dur=1.5s",
+		`quoted="a b"`,
+		"err=boom",
+		"slice=[1 2]",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got: %s", want, output)
+		}
+	}
+}
+
+func BenchmarkColorHandlerValues(b *testing.B) {
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewColorHandler(&buf, nil))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		logger.Info("values", "int", i, "float", 1.5, "bool", true)
+	}
+}
+
+var (
+	colorTimeRE  = regexp.MustCompile("^\033\\[37m(.*?)\033\\[0m ")
+	colorLevelRE = regexp.MustCompile("^\033\\[3[1-4]m(.*?)\033\\[0m ?")
+	colorMsgRE   = regexp.MustCompile("^\033\\[1m(.*?)\033\\[0m")
+	colorAttrRE  = regexp.MustCompile(" \033\\[35m([^\033]*)\033\\[0m=(\"(?:[^\"\\\\]|\\\\.)*\"|\\S*)")
+)
+
+// parseColorLine parses one line of ColorHandler output into a map,
+// nesting dotted keys into groups.
+func parseColorLine(t *testing.T, line string) map[string]any {
+	m := map[string]any{}
+	if sm := colorTimeRE.FindStringSubmatch(line); sm != nil {
+		m[slog.TimeKey] = sm[1]
+		line = line[len(sm[0]):]
+	}
+	if sm := colorLevelRE.FindStringSubmatch(line); sm != nil {
+		m[slog.LevelKey] = sm[1]
+		line = line[len(sm[0]):]
+	}
+	if sm := colorMsgRE.FindStringSubmatch(line); sm != nil {
+		m[slog.MessageKey] = sm[1]
+		line = line[len(sm[0]):]
+	}
+	for _, sm := range colorAttrRE.FindAllStringSubmatch(line, -1) {
+		value := sm[2]
+		if strings.HasPrefix(value, `"`) {
+			var err error
+			if value, err = strconv.Unquote(value); err != nil {
+				t.Fatalf("bad quoted value %s: %v", sm[2], err)
+			}
+		}
+		keys := strings.Split(sm[1], ".")
+		cur := m
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := cur[k].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				cur[k] = sub
+			}
+			cur = sub
+		}
+		cur[keys[len(keys)-1]] = value
+	}
+	return m
+}
+
+func TestColorHandlerSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.NewColorHandler(&buf, nil)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			ms = append(ms, parseColorLine(t, line))
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestColorHandlerGroupOrder(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewColorHandler(&buf, nil))
+
+	logger.With("a", 1).WithGroup("http").With("b", 2).WithGroup("request").Info("msg",
+		"method", "GET",
+		slog.Group("headers", "accept", "json"),
+	)
+
+	output := stripColors(buf.String())
+	for _, want := range []string{
+		" a=1 ",
+		" http.b=2 ",
+		" http.request.method=GET ",
+		" http.request.headers.accept=json",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected output to contain %q, got: %s", want, output)
+		}
+	}
+}
+
+func TestColorHandlerReplaceAttrBuiltins(t *testing.T) {
+	var buf bytes.Buffer
+	var seen []string
+	logger := slog.New(xlog.NewColorHandler(&buf, &slog.HandlerOptions{
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 {
+				seen = append(seen, a.Key)
+			}
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			if a.Key == slog.LevelKey {
+				return slog.String(slog.LevelKey, "NOTICE")
+			}
+			return a
+		},
+	}))
+
+	logger.Info("replaced", "k", "v")
+
+	output := stripColors(buf.String())
+	if !strings.HasPrefix(output, "NOTICE handler_test.go:") {
+		t.Errorf("expected replaced level and no time, got: %s", output)
+	}
+	want := []string{slog.TimeKey, slog.LevelKey, slog.SourceKey, slog.MessageKey, "k"}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Errorf("expected ReplaceAttr calls for %v, got %v", want, seen)
+	}
+}
+
+func TestColorHandlerStringTime(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Development),
+		xlog.WithOutput(&buf),
+		xlog.WithTimeFormat("2006-01-02 15:04:05"),
+	)
+
+	xlog.Info(context.Background(), "formatted")
+	if !regexp.MustCompile(`^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d INF`).MatchString(stripColors(buf.String())) {
+		t.Errorf("expected the formatted time unquoted, got: %s", stripColors(buf.String()))
+	}
+}
@@ -0,0 +1,97 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Hook observes records handled by loggers built with Init. Either field
+// may be nil.
+type Hook struct {
+	// Before is called before the record is handled and may modify it,
+	// for example to add attributes.
+	Before func(ctx context.Context, r *slog.Record)
+
+	// After is called once the record has been handled, with the error
+	// returned by the handler.
+	After func(ctx context.Context, r slog.Record, err error)
+}
+
+var (
+	// hooks is replaced, never modified, so Handle can read it without locking
+	hooks   atomic.Pointer[[]*Hook]
+	hooksMu sync.Mutex
+)
+
+// RegisterHook adds h to the hooks run for every record, in registration
+// order. It returns a function that removes the hook again.
+func RegisterHook(h Hook) (unregister func()) {
+	p := &h
+
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	var list []*Hook
+	if cur := hooks.Load(); cur != nil {
+		list = slices.Clone(*cur)
+	}
+	list = append(list, p)
+	hooks.Store(&list)
+
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+		cur := hooks.Load()
+		if cur == nil {
+			return
+		}
+		list := slices.DeleteFunc(slices.Clone(*cur), func(h *Hook) bool { return h == p })
+		hooks.Store(&list)
+	}
+}
+
+// hookHandler runs the registered hooks around the handler it wraps.
+type hookHandler struct {
+	handler slog.Handler
+}
+
+func (h *hookHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *hookHandler) Handle(ctx context.Context, r slog.Record) error {
+	p := hooks.Load()
+	if p == nil || len(*p) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+	// Hooks may add attributes, which must not leak into the caller's record
+	return h.handleHooks(ctx, r.Clone(), *p)
+}
+
+// handleHooks runs hooks around handling r. It is separate from Handle so
+// that r, whose address is passed to the hooks, escapes only when there
+// are hooks.
+func (h *hookHandler) handleHooks(ctx context.Context, r slog.Record, hooks []*Hook) error {
+	for _, hook := range hooks {
+		if hook.Before != nil {
+			hook.Before(ctx, &r)
+		}
+	}
+	err := h.handler.Handle(ctx, r)
+	for _, hook := range hooks {
+		if hook.After != nil {
+			hook.After(ctx, r, err)
+		}
+	}
+	return err
+}
+
+func (h *hookHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &hookHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *hookHandler) WithGroup(name string) slog.Handler {
+	return &hookHandler{handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,48 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestRegisterHook(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	var after []string
+	unregister := xlog.RegisterHook(xlog.Hook{
+		Before: func(ctx context.Context, r *slog.Record) {
+			r.AddAttrs(slog.String("hooked", "yes"))
+		},
+		After: func(ctx context.Context, r slog.Record, err error) {
+			if err == nil {
+				after = append(after, r.Message)
+			}
+		},
+	})
+
+	ctx := context.Background()
+	xlog.Info(ctx, "first")
+	if !strings.Contains(buf.String(), `"hooked":"yes"`) {
+		t.Errorf("expected Before to add an attribute, got: %s", buf.String())
+	}
+	if len(after) != 1 || after[0] != "first" {
+		t.Errorf("expected After to see the record, got: %v", after)
+	}
+
+	unregister()
+	buf.Reset()
+	xlog.Info(ctx, "second")
+	if strings.Contains(buf.String(), "hooked") || len(after) != 1 {
+		t.Errorf("expected hook to be removed, got: %s %v", buf.String(), after)
+	}
+}
@@ -0,0 +1,203 @@
+package xlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrorIDKey is the attribute key of the error ID of a recovered panic.
+const ErrorIDKey = "error_id"
+
+// ErrorIDHeader is the response header carrying the error ID of a
+// recovered panic.
+const ErrorIDHeader = "X-Error-ID"
+
+// HTTPMiddlewareOptions configures HTTPMiddleware.
+type HTTPMiddlewareOptions struct {
+	// RoutePattern returns the route pattern matched for r, such as
+	// "/items/{id}", so records aggregate by endpoint rather than by
+	// path. It is called after the request is served. By default the
+	// pattern matched by http.ServeMux is used.
+	RoutePattern func(r *http.Request) string
+
+	// Recover recovers panics in the handler. The panic is logged at
+	// ERROR with its stack, the request and a new error ID, and unless
+	// the response has started, a 500 response quoting the ID is written
+	// so users can report it. The ID is also added to the request's
+	// record. http.ErrAbortHandler is not recovered.
+	Recover bool
+
+	// AccessLog receives a line per request in AccessLogFormat, for
+	// analyzers that need Apache's formats. Lines are written whole, one
+	// at a time.
+	AccessLog io.Writer
+
+	// AccessLogFormat is the format of AccessLog lines, CommonLog by
+	// default.
+	AccessLogFormat AccessLogFormat
+
+	// AccessLogOnly skips the structured record of each request when
+	// AccessLog is set. Recovered panics are still logged.
+	AccessLogOnly bool
+
+	// Bodies captures the first bytes of request and response bodies of
+	// the configured content types and routes into the request's record,
+	// as request.body and response.body. JSON and form bodies are groups
+	// of their fields, which pass through the transformers and middleware
+	// set with Init like any attribute, so redact sensitive fields there
+	// by key. Nil captures none.
+	Bodies *BodyCaptureOptions
+}
+
+// HTTPMiddleware logs one record per request, with the request and
+// response described by HTTPRequest and HTTPResponse, using the logger
+// from the request context. Responses with a 5xx status are logged at
+// ERROR, others at INFO. With a router other than http.ServeMux, set
+// RoutePattern and install the middleware in the router, for chi:
+//
+//	r.Use(func(next http.Handler) http.Handler {
+//		return xlog.HTTPMiddleware(next, &xlog.HTTPMiddlewareOptions{
+//			RoutePattern: func(r *http.Request) string {
+//				return chi.RouteContext(r.Context()).RoutePattern()
+//			},
+//		})
+//	})
+func HTTPMiddleware(next http.Handler, opts *HTTPMiddlewareOptions) http.Handler {
+	var o HTTPMiddlewareOptions
+	if opts != nil {
+		o = *opts
+	}
+	var accessMu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		var bodies *bodyCapture
+		if o.Bodies != nil {
+			bodies = newBodyCapture(o.Bodies, r, sw)
+		}
+		var args []any
+		if o.Recover {
+			if id := serveRecovering(next, sw, r); id != "" {
+				args = append(args, ErrorIDKey, id)
+			}
+		} else {
+			next.ServeHTTP(sw, r)
+		}
+
+		if o.RoutePattern != nil {
+			if pattern := o.RoutePattern(r); pattern != "" {
+				r2 := *r
+				r2.Pattern = pattern
+				r = &r2
+			}
+		}
+		if o.AccessLog != nil {
+			accessMu.Lock()
+			_, _ = o.AccessLog.Write(appendAccessLog(nil, o.AccessLogFormat, r, sw, start))
+			accessMu.Unlock()
+			if o.AccessLogOnly {
+				return
+			}
+		}
+		logRequest(r.Context(), r, sw, time.Since(start), bodies, args...)
+	})
+}
+
+// serveRecovering serves r with next, recovering a panic as described by
+// HTTPMiddlewareOptions.Recover. It returns the error ID of the panic, if
+// any.
+func serveRecovering(next http.Handler, sw *statusWriter, r *http.Request) (errorID string) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+		errorID = NewRequestID()
+		ctx := r.Context()
+		if l := FromContext(ctx); l.Logger.Enabled(ctx, slog.LevelError) {
+			logPC(ctx, l, slog.LevelError, 0, "panic recovered",
+				"panic", p, "stack", string(debug.Stack()), ErrorIDKey, errorID, HTTPRequest(r))
+		}
+		if sw.code == 0 {
+			sw.Header().Set(ErrorIDHeader, errorID)
+			http.Error(sw, "Internal Server Error (error ID: "+errorID+")", http.StatusInternalServerError)
+		}
+	}()
+	next.ServeHTTP(sw, r)
+	return ""
+}
+
+func logRequest(ctx context.Context, r *http.Request, sw *statusWriter, dur time.Duration, bodies *bodyCapture, args ...any) {
+	level := slog.LevelInfo
+	if sw.status() >= 500 {
+		level = slog.LevelError
+	}
+	l := FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+	req, resp := HTTPRequest(r), HTTPResponse(sw.status(), sw.size, dur)
+	if bodies != nil {
+		reqBody, respBody := bodies.attrs(r, sw)
+		req, resp = withAttrs(req, reqBody), withAttrs(resp, respBody)
+	}
+	args = append([]any{req, resp}, args...)
+	logPC(ctx, l, level, 0, "request", args...)
+}
+
+// statusWriter records the status code and body size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+	size int64
+	// body captures the response body, if set
+	body *bodyBuffer
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	// Informational responses precede the final one
+	if w.code == 0 && code >= 200 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	if w.body != nil {
+		w.body.write(p[:n])
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher for handlers that stream.
+func (w *statusWriter) Flush() {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
@@ -0,0 +1,139 @@
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+type accessRecord struct {
+	Level   string `json:"level"`
+	Msg     string `json:"msg"`
+	Request struct {
+		Path  string `json:"path"`
+		Route string `json:"route"`
+	} `json:"request"`
+	Response struct {
+		Status int `json:"status"`
+		Size   int `json:"size"`
+	} `json:"response"`
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("item"))
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	h := xlog.HTTPMiddleware(mux, nil)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/items/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+
+	dec := json.NewDecoder(&buf)
+	var rec accessRecord
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level != "INFO" || rec.Request.Path != "/items/42" || rec.Request.Route != "GET /items/{id}" ||
+		rec.Response.Status != 200 || rec.Response.Size != 4 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	rec = accessRecord{}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Level != "ERROR" || rec.Response.Status != 500 {
+		t.Errorf("expected a 500 at ERROR, got: %+v", rec)
+	}
+}
+
+func TestHTTPMiddlewareRoutePattern(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+
+	h := xlog.HTTPMiddleware(http.NotFoundHandler(), &xlog.HTTPMiddlewareOptions{
+		RoutePattern: func(r *http.Request) string { return "/users/:id" },
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+
+	var rec accessRecord
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Request.Route != "/users/:id" || rec.Response.Status != 404 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestHTTPMiddlewareRecover(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+
+	h := xlog.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}), &xlog.HTTPMiddlewareOptions{Recover: true})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/orders", nil))
+
+	id := w.Header().Get(xlog.ErrorIDHeader)
+	if w.Code != 500 || id == "" || !strings.Contains(w.Body.String(), id) {
+		t.Fatalf("expected a 500 quoting the error ID, got %d %q: %s", w.Code, id, w.Body)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var panicked struct {
+		Level   string `json:"level"`
+		Panic   string `json:"panic"`
+		Stack   string `json:"stack"`
+		ErrorID string `json:"error_id"`
+		Request struct {
+			Path string `json:"path"`
+		} `json:"request"`
+	}
+	if err := dec.Decode(&panicked); err != nil {
+		t.Fatal(err)
+	}
+	if panicked.Level != "ERROR" || panicked.Panic != "nil map" || panicked.Stack == "" ||
+		panicked.ErrorID != id || panicked.Request.Path != "/orders" {
+		t.Errorf("unexpected panic record: %+v", panicked)
+	}
+	var rec struct {
+		accessRecord
+		ErrorID string `json:"error_id"`
+	}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Response.Status != 500 || rec.ErrorID != id {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestHTTPMiddlewareRecoverDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(xlog.LevelCritical))
+
+	h := xlog.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}), &xlog.HTTPMiddlewareOptions{Recover: true})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/orders", nil))
+
+	if w.Code != 500 || w.Header().Get(xlog.ErrorIDHeader) == "" {
+		t.Fatalf("expected a 500 with an error ID, got %d: %s", w.Code, w.Body)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no records below the level, got: %s", buf.String())
+	}
+}
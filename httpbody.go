@@ -0,0 +1,254 @@
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// BodyCaptureOptions configures the capture of request and response
+// bodies by HTTPMiddleware.
+type BodyCaptureOptions struct {
+	// MaxBytes is the number of bytes logged of each body; longer bodies
+	// are cut and end with TruncatedMarker. Zero means 4096.
+	MaxBytes int
+
+	// ContentTypes lists the media types captured, such as
+	// "application/json" or "text/*". By default JSON, form and plain
+	// text bodies are captured.
+	ContentTypes []string
+
+	// Routes lists the route patterns captured, as logged in
+	// request.route, such as "POST /orders". Empty means every route.
+	Routes []string
+}
+
+var defaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"text/plain",
+}
+
+// bodyCapture captures the bodies of one request.
+type bodyCapture struct {
+	opts *BodyCaptureOptions
+	req  *bodyBuffer
+	resp *bodyBuffer
+}
+
+// newBodyCapture starts capturing the bodies of r, replacing its body with
+// one that records what the handler reads.
+func newBodyCapture(opts *BodyCaptureOptions, r *http.Request, sw *statusWriter) *bodyCapture {
+	limit := opts.MaxBytes
+	if limit <= 0 {
+		limit = 4096
+	}
+	c := &bodyCapture{opts: opts, resp: &bodyBuffer{limit: limit}}
+	if r.Body != nil && r.Body != http.NoBody && c.captures(r.Header) {
+		c.req = &bodyBuffer{limit: limit}
+		r.Body = &captureReader{ReadCloser: r.Body, buf: c.req}
+	}
+	sw.body = c.resp
+	return c
+}
+
+// captures reports whether the content type in h is one to capture.
+func (c *bodyCapture) captures(h http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	types := c.opts.ContentTypes
+	if len(types) == 0 {
+		types = defaultBodyContentTypes
+	}
+	for _, t := range types {
+		if prefix, ok := strings.CutSuffix(t, "*"); ok && strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+		if strings.EqualFold(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// attrs returns the captured bodies of the request r, served with sw, as
+// "body" attributes for the request and response groups. JSON and form
+// bodies are groups of their fields, so transformers redact them by key.
+func (c *bodyCapture) attrs(r *http.Request, sw *statusWriter) (req, resp []slog.Attr) {
+	if len(c.opts.Routes) > 0 && !slices.Contains(c.opts.Routes, r.Pattern) {
+		return nil, nil
+	}
+	if c.req != nil && len(c.req.buf) > 0 {
+		req = c.req.attrs(r.Header.Get("Content-Type"))
+	}
+	if len(c.resp.buf) > 0 && c.captures(sw.Header()) {
+		resp = c.resp.attrs(sw.Header().Get("Content-Type"))
+	}
+	return req, resp
+}
+
+// bodyBuffer keeps the first bytes of a body.
+type bodyBuffer struct {
+	limit     int
+	buf       []byte
+	truncated bool
+}
+
+func (b *bodyBuffer) write(p []byte) {
+	n := min(len(p), b.limit-len(b.buf))
+	b.buf = append(b.buf, p[:n]...)
+	if n < len(p) {
+		b.truncated = true
+	}
+}
+
+// attrs returns the body, of the given content type, as a "body"
+// attribute. JSON and form bodies are decoded into groups; those cut by
+// the limit keep their complete fields and add "body_truncated".
+func (b *bodyBuffer) attrs(contentType string) []slog.Attr {
+	var (
+		v  slog.Value
+		ok bool
+	)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		v, ok = jsonBody(b.buf)
+	case mediaType == "application/x-www-form-urlencoded":
+		v, ok = formBody(b.buf, b.truncated)
+	}
+	if !ok {
+		return []slog.Attr{slog.String("body", b.String())}
+	}
+	attrs := []slog.Attr{{Key: "body", Value: v}}
+	if b.truncated {
+		attrs = append(attrs, slog.Bool("body_truncated", true))
+	}
+	return attrs
+}
+
+// jsonBody decodes data into a value, with objects and arrays as groups,
+// arrays keyed by index, so transformers see every field. It returns the
+// fields decoded before an error, such as the end of a truncated body,
+// and false if data does not start with a JSON value.
+func jsonBody(data []byte) (slog.Value, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, false
+	}
+	v, _ := decodeJSON(dec, tok)
+	return v, true
+}
+
+// decodeJSON decodes the value starting with tok.
+func decodeJSON(dec *json.Decoder, tok json.Token) (slog.Value, error) {
+	switch t := tok.(type) {
+	case json.Delim:
+		var attrs []slog.Attr
+		for i := 0; dec.More(); i++ {
+			key := strconv.Itoa(i)
+			if t == '{' {
+				k, err := dec.Token()
+				if err != nil {
+					return slog.GroupValue(attrs...), err
+				}
+				key, _ = k.(string)
+			}
+			tok, err := dec.Token()
+			if err != nil {
+				return slog.GroupValue(attrs...), err
+			}
+			v, err := decodeJSON(dec, tok)
+			if err != nil {
+				return slog.GroupValue(attrs...), err
+			}
+			attrs = append(attrs, slog.Attr{Key: key, Value: v})
+		}
+		_, err := dec.Token()
+		return slog.GroupValue(attrs...), err
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return slog.Int64Value(n), nil
+		}
+		f, _ := t.Float64()
+		return slog.Float64Value(f), nil
+	case string:
+		return slog.StringValue(t), nil
+	case bool:
+		return slog.BoolValue(t), nil
+	default:
+		return slog.AnyValue(nil), nil
+	}
+}
+
+// formBody decodes a form into a group of its fields, dropping the last
+// one if the body is truncated, as it may be cut.
+func formBody(data []byte, truncated bool) (slog.Value, bool) {
+	s := string(data)
+	if truncated {
+		s = s[:max(strings.LastIndexByte(s, '&'), 0)]
+	}
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return slog.Value{}, false
+	}
+	attrs := make([]slog.Attr, 0, len(values))
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		if vs := values[k]; len(vs) == 1 {
+			attrs = append(attrs, slog.String(k, vs[0]))
+		} else {
+			attrs = append(attrs, slog.Any(k, vs))
+		}
+	}
+	return slog.GroupValue(attrs...), true
+}
+
+func (b *bodyBuffer) String() string {
+	if b.truncated {
+		// Drop a rune cut by the limit
+		buf := b.buf
+		start := len(buf) - 1
+		for start > 0 && start > len(buf)-utf8.UTFMax && !utf8.RuneStart(buf[start]) {
+			start--
+		}
+		if start >= 0 && !utf8.FullRune(buf[start:]) {
+			buf = buf[:start]
+		}
+		return string(buf) + TruncatedMarker
+	}
+	return string(b.buf)
+}
+
+// captureReader records the bytes read from a request body.
+type captureReader struct {
+	io.ReadCloser
+	buf *bodyBuffer
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.write(p[:n])
+	return n, err
+}
+
+// withAttrs returns the group a with attrs added.
+func withAttrs(a slog.Attr, attrs []slog.Attr) slog.Attr {
+	if len(attrs) == 0 {
+		return a
+	}
+	a.Value = slog.GroupValue(append(a.Value.Group(), attrs...)...)
+	return a
+}
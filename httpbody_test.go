@@ -0,0 +1,104 @@
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestHTTPMiddlewareBodies(t *testing.T) {
+	var buf bytes.Buffer
+	redact := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == "password" {
+			return slog.String(a.Key, "***")
+		}
+		return a
+	}
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithAttrTransformers(redact))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"token":"abc","expires":3600,"scope":"read write"}`))
+	})
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("ok"))
+	})
+	h := xlog.HTTPMiddleware(mux, &xlog.HTTPMiddlewareOptions{
+		Bodies: &xlog.BodyCaptureOptions{MaxBytes: 40, Routes: []string{"POST /login"}},
+	})
+
+	post := func(path, contentType, body string) {
+		r := httptest.NewRequest("POST", path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	post("/login", "application/json; charset=utf-8", `{"user":"alice","password":"hunter2"}`)
+	post("/login", "application/x-www-form-urlencoded", "user=alice&password=hunter2")
+	post("/login", "text/plain", "password hunter2 and more than forty bytes")
+	post("/upload", "application/json", `{"file":"data"}`)
+
+	output := buf.String()
+	type body struct {
+		User     string `json:"user"`
+		Password string `json:"password"`
+		Token    string `json:"token"`
+		Expires  int    `json:"expires"`
+		Scope    string `json:"scope"`
+	}
+	type bodies struct {
+		Request struct {
+			Body json.RawMessage `json:"body"`
+		} `json:"request"`
+		Response struct {
+			Body      *body `json:"body"`
+			Truncated bool  `json:"body_truncated"`
+		} `json:"response"`
+	}
+	dec := json.NewDecoder(&buf)
+	for i, want := range []string{"json", "form"} {
+		var rec bodies
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		var req body
+		if err := json.Unmarshal(rec.Request.Body, &req); err != nil || req.User != "alice" || req.Password != "***" {
+			t.Errorf("expected the %s request body with the password masked, got: %s", want, rec.Request.Body)
+		}
+		if i > 0 {
+			continue
+		}
+		if resp := rec.Response.Body; resp == nil || resp.Token != "abc" || resp.Expires != 3600 || resp.Scope != "" || !rec.Response.Truncated {
+			t.Errorf("expected the complete fields of the truncated response body, got: %+v", rec.Response)
+		}
+	}
+
+	var rec bodies
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"password hunter2 and more than forty byt` + xlog.TruncatedMarker + `"`; string(rec.Request.Body) != want {
+		t.Errorf("expected the truncated text body, got: %s", rec.Request.Body)
+	}
+
+	rec = bodies{}
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Request.Body != nil || rec.Response.Body != nil {
+		t.Errorf("expected no bodies for an unlisted route, got: %+v", rec)
+	}
+	if strings.Count(output, "hunter2") != 1 {
+		t.Errorf("expected the password only in the text body:\n%s", output)
+	}
+}
@@ -0,0 +1,45 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Interface is the logging API of Logger, for packages that accept a
+// logger without depending on the concrete type. Pass Logger.Interface
+// in production and a Nop or xlogtest.Mock in tests.
+type Interface interface {
+	Debug(ctx context.Context, msg string, args ...any)
+	Info(ctx context.Context, msg string, args ...any)
+	Warn(ctx context.Context, msg string, args ...any)
+	Error(ctx context.Context, msg string, args ...any)
+	With(args ...any) Interface
+	WithGroup(name string) Interface
+}
+
+// Interface returns l as an Interface.
+func (l *Logger) Interface() Interface {
+	return loggerInterface{l}
+}
+
+// loggerInterface adapts Logger's With and WithGroup to Interface. The
+// logging methods are promoted, so the caller is reported unchanged.
+type loggerInterface struct {
+	*Logger
+}
+
+func (l loggerInterface) With(args ...any) Interface {
+	return loggerInterface{l.Logger.With(args...)}
+}
+
+func (l loggerInterface) WithGroup(name string) Interface {
+	return loggerInterface{l.Logger.WithGroup(name)}
+}
+
+// Nop returns a Logger that discards every record.
+func Nop() *Logger {
+	return &Logger{
+		Logger:  slog.New(slog.DiscardHandler),
+		handler: slog.DiscardHandler,
+	}
+}
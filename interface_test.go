@@ -0,0 +1,47 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestLoggerInterface(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l xlog.Interface = logger.Interface()
+
+	_, _, line, _ := runtime.Caller(0)
+	l.WithGroup("req").With("id", "r1").Info(context.Background(), "handled")
+
+	var rec struct {
+		Msg    string
+		Req    struct{ ID string }
+		Source struct{ Line int }
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "handled" || rec.Req.ID != "r1" {
+		t.Errorf("unexpected record: %s", buf.String())
+	}
+	if rec.Source.Line != line+1 {
+		t.Errorf("expected source line %d, got %d", line+1, rec.Source.Line)
+	}
+}
+
+func TestNop(t *testing.T) {
+	l := xlog.Nop()
+	l.Error(context.Background(), "discarded")
+	l.Interface().With("k", "v").Info(context.Background(), "discarded")
+	if l.Enabled(context.Background(), 100) {
+		t.Error("expected Nop to be disabled at every level")
+	}
+}
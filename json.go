@@ -0,0 +1,381 @@
+package xlog
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FastJSONHandler writes records as JSON using an append-based encoder.
+// Output is compatible with slog.JSONHandler but common value kinds are
+// encoded without fmt, reflection, or interface boxing, so a typical
+// record costs zero or one allocations.
+type FastJSONHandler struct {
+	opts   slog.HandlerOptions
+	output io.Writer
+	mu     *sync.Mutex
+
+	// prefix holds attributes pre-encoded by WithAttrs, including any
+	// groups opened along the way. It always starts with a comma.
+	prefix []byte
+	// groups are the names of all groups from WithGroup, in order.
+	groups []string
+	// nOpen is how many of groups have been opened in prefix; the rest
+	// are opened lazily so empty groups are omitted.
+	nOpen int
+}
+
+// NewFastJSONHandler creates a FastJSONHandler that writes to output.
+func NewFastJSONHandler(output io.Writer, opts *slog.HandlerOptions) *FastJSONHandler {
+	h := &FastJSONHandler{
+		output: output,
+		mu:     outputMutex(output),
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *FastJSONHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle encodes the record as a single line of JSON.
+func (h *FastJSONHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs encodes the record with attrs written before its own.
+func (h *FastJSONHandler) handleWithAttrs(_ context.Context, r slog.Record, attrs []slog.Attr) error {
+	bp := bufPool.Get().(*[]byte)
+	defer putBuffer(bp)
+	buf := append(*bp, '{')
+
+	rep := h.opts.ReplaceAttr
+
+	// Built-in attributes
+	if !r.Time.IsZero() {
+		if rep == nil {
+			buf = append(buf, `"time":"`...)
+			buf = r.Time.AppendFormat(buf, time.RFC3339Nano)
+			buf = append(buf, '"')
+		} else {
+			buf = h.appendBuiltin(buf, slog.Time(slog.TimeKey, r.Time))
+		}
+	}
+	if rep == nil {
+		buf = appendJSONSep(buf)
+		buf = append(buf, `"level":"`...)
+		buf = append(buf, r.Level.String()...)
+		buf = append(buf, '"')
+	} else {
+		buf = h.appendBuiltin(buf, slog.Any(slog.LevelKey, r.Level))
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		f, _ := frames.Next()
+		src := &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+		buf = h.appendBuiltin(buf, slog.Any(slog.SourceKey, src))
+	}
+	if rep == nil {
+		buf = appendJSONSep(buf)
+		buf = append(buf, `"msg":`...)
+		buf = appendJSONString(buf, r.Message)
+	} else {
+		buf = h.appendBuiltin(buf, slog.String(slog.MessageKey, r.Message))
+	}
+
+	// Pre-encoded attributes from WithAttrs
+	buf = append(buf, h.prefix...)
+
+	// Record attributes, opening pending groups only if something is written
+	nOpen := h.nOpen
+	if len(attrs) > 0 || r.NumAttrs() > 0 {
+		mark := len(buf)
+		for _, g := range h.groups[h.nOpen:] {
+			buf = appendJSONSep(buf)
+			buf = appendJSONString(buf, g)
+			buf = append(buf, ':', '{')
+		}
+		body := len(buf)
+		for _, a := range attrs {
+			buf = h.appendAttr(buf, a, h.groups)
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			buf = h.appendAttr(buf, a, h.groups)
+			return true
+		})
+		if len(buf) == body {
+			buf = buf[:mark]
+		} else {
+			nOpen = len(h.groups)
+		}
+	}
+	for i := 0; i < nOpen; i++ {
+		buf = append(buf, '}')
+	}
+
+	buf = append(buf, '}', '\n')
+	*bp = buf
+
+	if h.mu != nil {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+	}
+	_, err := h.output.Write(buf)
+	return err
+}
+
+// WithAttrs returns a new handler with the given attributes pre-encoded.
+func (h *FastJSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	buf := make([]byte, len(h.prefix), len(h.prefix)+64)
+	copy(buf, h.prefix)
+	mark := len(buf)
+	for _, g := range h.groups[h.nOpen:] {
+		buf = appendJSONSep(buf)
+		buf = appendJSONString(buf, g)
+		buf = append(buf, ':', '{')
+	}
+	body := len(buf)
+	for _, a := range attrs {
+		buf = h.appendAttr(buf, a, h.groups)
+	}
+
+	h2 := *h
+	if len(buf) == body {
+		// Nothing was written; leave pending groups closed
+		h2.prefix = buf[:mark]
+	} else {
+		h2.prefix = buf
+		h2.nOpen = len(h.groups)
+	}
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *FastJSONHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = make([]string, len(h.groups), len(h.groups)+1)
+	copy(h2.groups, h.groups)
+	h2.groups = append(h2.groups, name)
+	return &h2
+}
+
+// appendBuiltin writes a built-in attribute after passing it through
+// ReplaceAttr with no groups, as slog.JSONHandler does.
+func (h *FastJSONHandler) appendBuiltin(buf []byte, a slog.Attr) []byte {
+	if h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(nil, a)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Key == "" {
+		return buf
+	}
+	buf = appendJSONSep(buf)
+	buf = appendJSONString(buf, a.Key)
+	buf = append(buf, ':')
+	return h.appendValue(buf, a.Value, nil)
+}
+
+func (h *FastJSONHandler) appendAttr(buf []byte, a slog.Attr, groups []string) []byte {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return buf
+		}
+		// Inline groups with an empty key
+		if a.Key == "" {
+			for _, ga := range attrs {
+				buf = h.appendAttr(buf, ga, groups)
+			}
+			return buf
+		}
+		mark := len(buf)
+		buf = appendJSONSep(buf)
+		buf = appendJSONString(buf, a.Key)
+		buf = append(buf, ':', '{')
+		body := len(buf)
+		var sub []string
+		if h.opts.ReplaceAttr != nil {
+			sub = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			buf = h.appendAttr(buf, ga, sub)
+		}
+		if len(buf) == body {
+			return buf[:mark]
+		}
+		return append(buf, '}')
+	}
+
+	buf = appendJSONSep(buf)
+	buf = appendJSONString(buf, a.Key)
+	buf = append(buf, ':')
+	return h.appendValue(buf, a.Value, groups)
+}
+
+func (h *FastJSONHandler) appendValue(buf []byte, v slog.Value, groups []string) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// JSON has no representation for these; quote them like strconv does
+			return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(buf, f, 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		buf = append(buf, '"')
+		buf = v.Time().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	case slog.KindGroup:
+		buf = append(buf, '{')
+		for _, ga := range v.Group() {
+			buf = h.appendAttr(buf, ga, groups)
+		}
+		return append(buf, '}')
+	default:
+		return appendJSONAny(buf, v.Any())
+	}
+}
+
+func appendJSONAny(buf []byte, v any) []byte {
+	switch x := v.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case *slog.Source:
+		buf = append(buf, `{"function":`...)
+		buf = appendJSONString(buf, x.Function)
+		buf = append(buf, `,"file":`...)
+		buf = appendJSONString(buf, x.File)
+		buf = append(buf, `,"line":`...)
+		buf = strconv.AppendInt(buf, int64(x.Line), 10)
+		return append(buf, '}')
+	case slog.Level:
+		return appendJSONString(buf, x.String())
+	case json.Marshaler:
+		// Checked before error so types controlling their JSON form win
+		b, err := x.MarshalJSON()
+		if err != nil {
+			return appendJSONString(buf, "!ERROR:"+err.Error())
+		}
+		return append(buf, b...)
+	case error:
+		return appendJSONString(buf, x.Error())
+	case encoding.TextMarshaler:
+		b, err := x.MarshalText()
+		if err != nil {
+			return appendJSONString(buf, "!ERROR:"+err.Error())
+		}
+		return appendJSONString(buf, string(b))
+	case []byte:
+		b, _ := json.Marshal(x)
+		return append(buf, b...)
+	default:
+		b, err := json.Marshal(x)
+		if err != nil {
+			return appendJSONString(buf, "!ERROR:"+err.Error())
+		}
+		return append(buf, b...)
+	}
+}
+
+// appendJSONSep adds a comma unless an object was just opened.
+func appendJSONSep(buf []byte) []byte {
+	if len(buf) > 0 && buf[len(buf)-1] == '{' {
+		return buf
+	}
+	return append(buf, ',')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a quoted JSON string. Like slog.JSONHandler
+// it does not escape HTML characters.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 break JavaScript parsers
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
@@ -0,0 +1,155 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestFastJSONHandlerSlogtest(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.NewFastJSONHandler(&buf, nil)
+
+	results := func() []map[string]any {
+		var ms []map[string]any
+		for _, line := range bytes.Split(buf.Bytes(), []byte{'\n'}) {
+			if len(line) == 0 {
+				continue
+			}
+			var m map[string]any
+			if err := json.Unmarshal(line, &m); err != nil {
+				t.Fatalf("invalid JSON %q: %v", line, err)
+			}
+			ms = append(ms, m)
+		}
+		return ms
+	}
+
+	if err := slogtest.TestHandler(h, results); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFastJSONHandlerAddSource(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(xlog.NewFastJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})).Info("with source")
+
+	var rec struct {
+		Source slog.Source `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(rec.Source.File, "json_test.go") || rec.Source.Line == 0 {
+		t.Errorf("expected the caller's source, got: %s", buf.String())
+	}
+}
+
+func TestFastJSONHandlerMatchesStdJSON(t *testing.T) {
+	var fast, std bytes.Buffer
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	loggers := []*slog.Logger{
+		slog.New(xlog.NewFastJSONHandler(&fast, opts)),
+		slog.New(slog.NewJSONHandler(&std, opts)),
+	}
+
+	for _, l := range loggers {
+		l.With("service", "api").WithGroup("req").Info("mixed \"values\"\n",
+			"str", "a\tb<c>",
+			"int", -42,
+			"uint", uint64(7),
+			"float", 1.5,
+			"bool", true,
+			"dur", 1500*time.Millisecond,
+			"err", errors.New("boom"),
+			"nil", nil,
+			"slice", []int{1, 2},
+			slog.Group("inner", "k", "v"),
+			slog.Group("empty"),
+		)
+	}
+
+	var got, want map[string]any
+	if err := json.Unmarshal(fast.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", fast.String(), err)
+	}
+	if err := json.Unmarshal(std.Bytes(), &want); err != nil {
+		t.Fatal(err)
+	}
+	delete(got, "time")
+	delete(want, "time")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("output mismatch\n got: %s\nwant: %s", fast.String(), std.String())
+	}
+}
+
+func TestWithFormatFastJSON(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Development),
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+	)
+
+	xlog.Info(context.Background(), "fast", "count", 42)
+
+	output := buf.String()
+	if !strings.HasPrefix(output, "{") || !strings.Contains(output, `"count":42`) {
+		t.Errorf("expected JSON output, got: %s", output)
+	}
+	if !strings.Contains(output, `"source":{`) {
+		t.Errorf("expected source location, got: %s", output)
+	}
+}
+
+func TestFastJSONAllocs(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+	ctx := xlog.WithTraceID(context.Background(), "trace-123")
+
+	// Log through slog, whose caller lookup doesn't depend on the helpers
+	// other tests register, to measure the handlers Init composes
+	logger := xlog.Default().Logger
+	allocs := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		logger.InfoContext(ctx, "benchmark message", "iteration", 1)
+	})
+	if allocs > 1 {
+		t.Errorf("expected at most 1 alloc per record, got %v", allocs)
+	}
+}
+
+func BenchmarkFastJSON(b *testing.B) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	ctx := context.Background()
+	ctx = xlog.WithTraceID(ctx, "trace-123")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		xlog.Info(ctx, "benchmark message", "iteration", i)
+	}
+}
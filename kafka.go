@@ -0,0 +1,255 @@
+package xlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrHandlerClosed is returned by KafkaHandler for records handled after
+// Close has been called.
+var ErrHandlerClosed = errors.New("xlog: handler closed")
+
+// KafkaMessage is a single encoded record destined for a Kafka topic.
+type KafkaMessage struct {
+	Topic string
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// KafkaWriter is the minimal producer interface used by KafkaHandler.
+// It is small enough to adapt any Kafka client (segmentio/kafka-go,
+// franz-go, sarama) without xlog depending on one.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...KafkaMessage) error
+}
+
+// KafkaHandlerOptions configures a KafkaHandler.
+type KafkaHandlerOptions struct {
+	// Topic is the destination topic for every record.
+	Topic string
+
+	// Level is the minimum level to publish. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+
+	// KeyAttr names the attribute whose value is used as the message key,
+	// e.g. "trace_id" to keep a trace on one partition. It matches the
+	// attribute at the top level first, then in groups, including those
+	// of WithAttrs and WithGroup; a dotted name such as "user.id" selects
+	// one in a given group. Records without the attribute are published
+	// with a nil key.
+	KeyAttr string
+
+	// BatchSize is the number of records buffered before a flush. Defaults to 100.
+	BatchSize int
+
+	// FlushInterval is the maximum time a record waits in the buffer. Defaults to 1s.
+	FlushInterval time.Duration
+
+	// OnDeliveryError is called with the failed batch when WriteMessages fails.
+	OnDeliveryError func(err error, msgs []KafkaMessage)
+}
+
+// KafkaHandler publishes JSON-encoded records to Kafka in batches.
+// Records are flushed when the batch is full, on every FlushInterval,
+// and on Close.
+type KafkaHandler struct {
+	w     KafkaWriter
+	opts  KafkaHandlerOptions
+	state sinkState
+	batch *kafkaBatch
+}
+
+// kafkaBatch is shared between a KafkaHandler and its derived handlers.
+type kafkaBatch struct {
+	mu      sync.Mutex
+	msgs    []KafkaMessage
+	closed  bool
+	flushMu sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// NewKafkaHandler creates a KafkaHandler that publishes through w.
+// Call Close to stop the background flusher and publish buffered records.
+func NewKafkaHandler(w KafkaWriter, opts *KafkaHandlerOptions) *KafkaHandler {
+	var o KafkaHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+
+	h := &KafkaHandler{
+		w:    w,
+		opts: o,
+		batch: &kafkaBatch{
+			msgs: make([]KafkaMessage, 0, o.BatchSize),
+			done: make(chan struct{}),
+		},
+	}
+
+	h.batch.wg.Add(1)
+	go h.flushLoop()
+
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *KafkaHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// Handle encodes the record and adds it to the current batch. A full
+// batch is published with a background context, so a canceled request
+// does not fail the records of others. After Close, it returns
+// ErrHandlerClosed.
+func (h *KafkaHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := h.state.fields(r)
+
+	var key []byte
+	if h.opts.KeyAttr != "" {
+		if v, ok := lookupField(fields, h.opts.KeyAttr); ok {
+			key = fmt.Append(nil, v)
+		}
+	}
+
+	fields[slog.TimeKey] = r.Time.Format(time.RFC3339Nano)
+	fields[slog.LevelKey] = r.Level.String()
+	fields[slog.MessageKey] = r.Message
+
+	value, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("xlog: kafka encode: %w", err)
+	}
+
+	b := h.batch
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrHandlerClosed
+	}
+	b.msgs = append(b.msgs, KafkaMessage{
+		Topic: h.opts.Topic,
+		Key:   key,
+		Value: value,
+		Time:  r.Time,
+	})
+	full := len(b.msgs) >= h.opts.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		_ = h.Flush(context.Background())
+	}
+	return nil
+}
+
+// lookupField returns the value of the field named key in fields: at the
+// top level, then in nested groups breadth first. A dotted key selects a
+// field in a given group.
+func lookupField(fields map[string]any, key string) (any, bool) {
+	if v, ok := fields[key]; ok {
+		return v, true
+	}
+	if group, rest, ok := strings.Cut(key, "."); ok {
+		if m, ok := fields[group].(map[string]any); ok {
+			if v, ok := lookupField(m, rest); ok {
+				return v, true
+			}
+		}
+	}
+	queue := []map[string]any{fields}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			if sub, ok := m[k].(map[string]any); ok {
+				if v, ok := sub[key]; ok {
+					return v, true
+				}
+				queue = append(queue, sub)
+			}
+		}
+	}
+	return nil, false
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *KafkaHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *KafkaHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Flush publishes all buffered records.
+func (h *KafkaHandler) Flush(ctx context.Context) error {
+	b := h.batch
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	msgs := b.msgs
+	b.msgs = make([]KafkaMessage, 0, h.opts.BatchSize)
+	b.mu.Unlock()
+
+	if len(msgs) == 0 {
+		return nil
+	}
+	err := h.w.WriteMessages(ctx, msgs...)
+	if err != nil && h.opts.OnDeliveryError != nil {
+		h.opts.OnDeliveryError(err, msgs)
+	}
+	return err
+}
+
+// Close stops the background flusher and publishes any buffered records.
+// Records handled afterwards are rejected with ErrHandlerClosed.
+func (h *KafkaHandler) Close() error {
+	h.batch.once.Do(func() {
+		h.batch.mu.Lock()
+		h.batch.closed = true
+		h.batch.mu.Unlock()
+		close(h.batch.done)
+	})
+	h.batch.wg.Wait()
+	return h.Flush(context.Background())
+}
+
+func (h *KafkaHandler) flushLoop() {
+	defer h.batch.wg.Done()
+
+	ticker := time.NewTicker(h.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = h.Flush(context.Background())
+		case <-h.batch.done:
+			return
+		}
+	}
+}
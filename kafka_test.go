@@ -0,0 +1,129 @@
+package xlog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+type fakeKafka struct {
+	mu   sync.Mutex
+	msgs []xlog.KafkaMessage
+	err  error
+}
+
+func (f *fakeKafka) WriteMessages(ctx context.Context, msgs ...xlog.KafkaMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.msgs = append(f.msgs, msgs...)
+	return nil
+}
+
+func TestKafkaHandlerBatching(t *testing.T) {
+	w := &fakeKafka{}
+	h := xlog.NewKafkaHandler(w, &xlog.KafkaHandlerOptions{
+		Topic:         "logs",
+		KeyAttr:       string(xlog.TraceIDKey),
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	defer h.Close()
+
+	logger := slog.New(xlog.NewContextHandler(h, xlog.TraceIDKey))
+	ctx := xlog.WithTraceID(context.Background(), "trace-123")
+
+	logger.InfoContext(ctx, "first")
+	if len(w.msgs) != 0 {
+		t.Fatalf("expected no messages before batch is full, got %d", len(w.msgs))
+	}
+	logger.InfoContext(ctx, "second")
+	if len(w.msgs) != 2 {
+		t.Fatalf("expected 2 messages after batch is full, got %d", len(w.msgs))
+	}
+
+	msg := w.msgs[0]
+	if msg.Topic != "logs" {
+		t.Errorf("expected topic logs, got %s", msg.Topic)
+	}
+	if string(msg.Key) != "trace-123" {
+		t.Errorf("expected key trace-123, got %s", msg.Key)
+	}
+	if !strings.Contains(string(msg.Value), `"msg":"first"`) {
+		t.Errorf("expected JSON value with msg, got: %s", msg.Value)
+	}
+}
+
+func TestKafkaHandlerDeliveryError(t *testing.T) {
+	w := &fakeKafka{err: errors.New("broker down")}
+	var failed int
+	h := xlog.NewKafkaHandler(w, &xlog.KafkaHandlerOptions{
+		Topic:         "logs",
+		FlushInterval: time.Hour,
+		OnDeliveryError: func(err error, msgs []xlog.KafkaMessage) {
+			failed += len(msgs)
+		},
+	})
+
+	slog.New(h).Info("lost")
+	if err := h.Close(); err == nil {
+		t.Error("expected delivery error from Close")
+	}
+	if failed != 1 {
+		t.Errorf("expected 1 failed message, got %d", failed)
+	}
+}
+
+func TestKafkaHandlerCanceledContext(t *testing.T) {
+	w := &fakeKafka{}
+	h := xlog.NewKafkaHandler(w, &xlog.KafkaHandlerOptions{BatchSize: 1, FlushInterval: time.Hour})
+	defer h.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slog.New(h).InfoContext(ctx, "request ended")
+	if len(w.msgs) != 1 {
+		t.Errorf("expected the batch to be published despite the canceled context, got %d messages", len(w.msgs))
+	}
+}
+
+func TestKafkaHandlerGroupedKey(t *testing.T) {
+	w := &fakeKafka{}
+	h := xlog.NewKafkaHandler(w, &xlog.KafkaHandlerOptions{KeyAttr: "tenant", BatchSize: 1, FlushInterval: time.Hour})
+	defer h.Close()
+
+	slog.New(h).WithGroup("req").With("tenant", "acme").Info("grouped")
+	slog.New(h).Info("nested", slog.Group("user", "id", 1, slog.Group("org", "tenant", "globex")))
+	dotted := xlog.NewKafkaHandler(w, &xlog.KafkaHandlerOptions{KeyAttr: "user.id", BatchSize: 1, FlushInterval: time.Hour})
+	defer dotted.Close()
+	slog.New(dotted).Info("dotted", "id", 7, slog.Group("user", "id", 42))
+
+	var keys []string
+	for _, m := range w.msgs {
+		keys = append(keys, string(m.Key))
+	}
+	if want := []string{"acme", "globex", "42"}; strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+}
+
+func TestKafkaHandlerClosed(t *testing.T) {
+	h := xlog.NewKafkaHandler(&fakeKafka{}, nil)
+	_ = h.Close()
+
+	var r slog.Record
+	if err := h.WithAttrs([]slog.Attr{slog.String("k", "v")}).Handle(context.Background(), r); !errors.Is(err, xlog.ErrHandlerClosed) {
+		t.Errorf("expected ErrHandlerClosed after Close, got %v", err)
+	}
+}
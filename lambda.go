@@ -0,0 +1,58 @@
+package xlog
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync/atomic"
+)
+
+// LambdaOptions configures WrapLambda.
+type LambdaOptions struct {
+	// RequestID returns the AWS request ID of the invocation, added to
+	// the context as the request ID. With aws-lambda-go:
+	//
+	//	func(ctx context.Context) string {
+	//		lc, _ := lambdacontext.FromContext(ctx)
+	//		return lc.AwsRequestID
+	//	}
+	RequestID func(ctx context.Context) string
+}
+
+// WrapLambda wraps an AWS Lambda handler so that it logs one record per
+// invocation, as Op does, with the function name and version and whether
+// the invocation was a cold start, the first of the wrapper; a Lambda
+// process runs a single handler. The record's source is the handler. The
+// outputs are flushed before the handler returns, since the environment
+// may be frozen right after:
+//
+//	lambda.Start(xlog.WrapLambda(handle, &xlog.LambdaOptions{RequestID: awsRequestID}))
+func WrapLambda[In, Out any](h func(ctx context.Context, in In) (Out, error), opts *LambdaOptions) func(ctx context.Context, in In) (Out, error) {
+	var o LambdaOptions
+	if opts != nil {
+		o = *opts
+	}
+	function := os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+	version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION")
+	// Records hold return addresses, so step past the handler's entry
+	pc := reflect.ValueOf(h).Pointer() + 1
+	var warm atomic.Bool
+	return func(ctx context.Context, in In) (out Out, err error) {
+		if o.RequestID != nil {
+			if id := o.RequestID(ctx); id != "" {
+				ctx = WithRequestID(ctx, id)
+			}
+		}
+		cold := !warm.Swap(true)
+
+		l := FromContext(ctx)
+		op := l.Start(ctx, "invocation", "function", function, "version", version, "cold_start", cold)
+		defer func() {
+			if level, args := op.result(err); l.Logger.Enabled(ctx, level) {
+				logPC(ctx, l, level, pc, op.name, args...)
+			}
+			_ = Flush(context.WithoutCancel(ctx))
+		}()
+		return h(ctx, in)
+	}
+}
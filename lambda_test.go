@@ -0,0 +1,45 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWrapLambda(t *testing.T) {
+	t.Setenv("AWS_LAMBDA_FUNCTION_NAME", "orders")
+	t.Setenv("AWS_LAMBDA_FUNCTION_VERSION", "7")
+
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+
+	handle := xlog.WrapLambda(func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	}, &xlog.LambdaOptions{
+		RequestID: func(context.Context) string { return "aws-req-1" },
+	})
+
+	for i, wantCold := range []bool{true, false} {
+		buf.Reset()
+		out, err := handle(context.Background(), 21)
+		if err != nil || out != 42 {
+			t.Fatalf("unexpected result %d, %v", out, err)
+		}
+		var rec map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec["msg"] != "invocation" || rec["function"] != "orders" || rec["version"] != "7" ||
+			rec["request_id"] != "aws-req-1" || rec["cold_start"] != wantCold {
+			t.Errorf("invocation %d: unexpected record: %s", i, buf.String())
+		}
+		if src, _ := rec["source"].(map[string]any); !strings.Contains(fmt.Sprint(src["function"]), "TestWrapLambda") {
+			t.Errorf("invocation %d: expected the handler as source, got %v", i, rec["source"])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package xlog
+
+import "log/slog"
+
+// LazyValue is a value computed only when a record is written. Records
+// dropped by the level check or sampling never call it.
+type LazyValue func() any
+
+// LogValue implements slog.LogValuer.
+func (f LazyValue) LogValue() slog.Value {
+	return slog.AnyValue(f())
+}
+
+// Lazy defers fn until the record it is attached to is written:
+//
+//	xlog.Debug(ctx, "cache state", "entries", xlog.Lazy(cache.Dump))
+func Lazy(fn func() any) LazyValue {
+	return LazyValue(fn)
+}
+
+// LazyAttr returns an attribute whose value is computed by fn only when
+// the record is written.
+func LazyAttr(key string, fn func() any) slog.Attr {
+	return slog.Any(key, LazyValue(fn))
+}
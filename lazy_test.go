@@ -0,0 +1,39 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestLazy(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	calls := 0
+	expensive := func() any {
+		calls++
+		return map[string]int{"entries": 3}
+	}
+
+	ctx := context.Background()
+	xlog.Debug(ctx, "suppressed", "state", xlog.Lazy(expensive))
+	if calls != 0 {
+		t.Fatalf("expected lazy value not to be computed for a suppressed record, got %d calls", calls)
+	}
+
+	xlog.Info(ctx, "written", "state", xlog.Lazy(expensive), xlog.LazyAttr("attr", expensive))
+	if calls != 2 {
+		t.Errorf("expected each lazy value to be computed once, got %d calls", calls)
+	}
+	if !strings.Contains(buf.String(), `"state":{"entries":3}`) || !strings.Contains(buf.String(), `"attr":{"entries":3}`) {
+		t.Errorf("expected lazy values in output, got: %s", buf.String())
+	}
+}
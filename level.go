@@ -0,0 +1,204 @@
+package xlog
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LevelFormat renders a level as the value of the level attribute.
+type LevelFormat func(level slog.Level) slog.Value
+
+// Built-in level formats for WithLevelFormat.
+var (
+	// LevelUpper renders levels with Level.String, e.g. "INFO", "WARN+2"
+	// or a name given to RegisterLevel.
+	LevelUpper LevelFormat = func(level slog.Level) slog.Value {
+		return slog.StringValue(Level(level).String())
+	}
+
+	// LevelLower renders levels as LevelUpper does in lowercase, e.g. "info"
+	// or "warn+2".
+	LevelLower LevelFormat = func(level slog.Level) slog.Value {
+		return slog.StringValue(strings.ToLower(Level(level).String()))
+	}
+
+	// LevelNumeric renders levels as their numeric value, e.g. 0 for INFO.
+	LevelNumeric LevelFormat = func(level slog.Level) slog.Value {
+		return slog.IntValue(int(level))
+	}
+)
+
+// LevelLabels returns a LevelFormat rendering the levels in labels with
+// their label, and other levels as LevelUpper does:
+//
+//	xlog.LevelLabels(map[slog.Level]string{
+//		slog.LevelWarn:      "WARNING",
+//		slog.LevelError + 4: "CRITICAL",
+//	})
+func LevelLabels(labels map[slog.Level]string) LevelFormat {
+	return func(level slog.Level) slog.Value {
+		if label, ok := labels[level]; ok {
+			return slog.StringValue(label)
+		}
+		return LevelUpper(level)
+	}
+}
+
+// WithLevelFormat sets how levels are rendered, separately for the
+// ColorText format and the JSON formats; nil keeps the format's default,
+// which is "INF" style for ColorText, or the name given to RegisterLevel,
+// and LevelUpper for JSON:
+//
+//	xlog.WithLevelFormat(nil, xlog.LevelLower)
+//
+// Like WithAttrTransformers, it does not apply to a handler set with
+// WithHandler.
+func WithLevelFormat(console, json LevelFormat) Option {
+	return func(c *config) {
+		c.consoleLevels = console
+		c.jsonLevels = json
+	}
+}
+
+// Levels beyond slog's four, with names understood by ParseLevel and
+// Level.String.
+const (
+	LevelTrace    = slog.LevelDebug - 4
+	LevelCritical = slog.LevelError + 4
+)
+
+// Level is a slog.Level whose text form uses xlog's level names, so it
+// can be set from flags and config files and written back:
+//
+//	level := xlog.Level(slog.LevelInfo)
+//	flag.TextVar(&level, "level", level, "minimum log level")
+//	flag.Parse()
+//	xlog.Init(xlog.WithLevel(level.Level()))
+type Level slog.Level
+
+// Level returns l as a slog.Level.
+func (l Level) Level() slog.Level {
+	return slog.Level(l)
+}
+
+// String returns the name of l: a name given to RegisterLevel, "TRACE",
+// "CRITICAL", or the name slog uses, such as "INFO" or "WARN+2".
+func (l Level) String() string {
+	customLevels.RLock()
+	name, ok := customLevels.names[slog.Level(l)]
+	customLevels.RUnlock()
+	switch {
+	case ok:
+		return name
+	case slog.Level(l) == LevelTrace:
+		return "TRACE"
+	case slog.Level(l) == LevelCritical:
+		return "CRITICAL"
+	}
+	return slog.Level(l).String()
+}
+
+// MarshalText implements encoding.TextMarshaler using String.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLevel.
+func (l *Level) UnmarshalText(data []byte) error {
+	level, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+	*l = Level(level)
+	return nil
+}
+
+// customLevels holds the names given to RegisterLevel.
+var customLevels struct {
+	sync.RWMutex
+	names  map[slog.Level]string
+	levels map[string]slog.Level
+}
+
+// RegisterLevel names a custom level for ParseLevel, Level.String and the
+// level attribute of the built-in formats.
+// Registering a level again replaces its name.
+func RegisterLevel(level slog.Level, name string) {
+	customLevels.Lock()
+	defer customLevels.Unlock()
+	if customLevels.names == nil {
+		customLevels.names = make(map[slog.Level]string)
+		customLevels.levels = make(map[string]slog.Level)
+	}
+	if old, ok := customLevels.names[level]; ok {
+		delete(customLevels.levels, strings.ToLower(old))
+	}
+	customLevels.names[level] = name
+	customLevels.levels[strings.ToLower(name)] = level
+}
+
+// ParseLevel parses a level name, ignoring case. It accepts the names of
+// slog and Level.String, the names of other logging libraries such as
+// "warning", "trace" and "fatal", names given to RegisterLevel, any of
+// those with an offset such as "warn+2", and numbers such as "-4".
+func ParseLevel(s string) (slog.Level, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return slog.Level(n), nil
+	}
+	if level, ok := namedLevelValue(s); ok {
+		return level, nil
+	}
+	if i := strings.LastIndexAny(s, "+-"); i > 0 {
+		level, ok := namedLevelValue(s[:i])
+		n, err := strconv.Atoi(s[i:])
+		if ok && err == nil {
+			return level + slog.Level(n), nil
+		}
+	}
+	return 0, fmt.Errorf("xlog: unknown level %q", s)
+}
+
+// namedLevelValue looks up a level name registered with RegisterLevel or
+// known from other logging libraries.
+func namedLevelValue(name string) (slog.Level, bool) {
+	name = strings.ToLower(name)
+	customLevels.RLock()
+	level, ok := customLevels.levels[name]
+	customLevels.RUnlock()
+	if !ok {
+		level, ok = bridgeLevels[name]
+	}
+	return level, ok
+}
+
+// levelTransformer renders the top-level level attribute with f.
+func levelTransformer(f LevelFormat) AttrTransformer {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.LevelKey && len(groups) == 0 {
+			if level, ok := a.Value.Any().(slog.Level); ok {
+				a.Value = f(level)
+			}
+		}
+		return a
+	}
+}
+
+// registeredLevelTransformer renders the top-level level attribute with the
+// name given to RegisterLevel, leaving other levels to the handler.
+func registeredLevelTransformer(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == slog.LevelKey && len(groups) == 0 {
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			customLevels.RLock()
+			name, ok := customLevels.names[level]
+			customLevels.RUnlock()
+			if ok {
+				a.Value = slog.StringValue(name)
+			}
+		}
+	}
+	return a
+}
@@ -0,0 +1,142 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithLevelFormat(t *testing.T) {
+	labels := xlog.LevelLabels(map[slog.Level]string{slog.LevelWarn: "WARNING"})
+	tests := []struct {
+		format xlog.LevelFormat
+		level  slog.Level
+		want   any
+	}{
+		{xlog.LevelUpper, slog.LevelInfo, "INFO"},
+		{xlog.LevelLower, slog.LevelWarn + 2, "warn+2"},
+		{xlog.LevelNumeric, slog.LevelError, float64(8)},
+		{labels, slog.LevelWarn, "WARNING"},
+		{labels, slog.LevelError, "ERROR"},
+	}
+	for _, jsonFormat := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		for _, tt := range tests {
+			var buf bytes.Buffer
+			_ = xlog.Init(
+				xlog.WithFormat(jsonFormat),
+				xlog.WithOutput(&buf),
+				xlog.WithLevelFormat(nil, tt.format),
+			)
+			xlog.Default().Log(context.Background(), tt.level, "hello")
+
+			var rec map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec["level"] != tt.want {
+				t.Errorf("%s: expected level %v, got: %s", jsonFormat, tt.want, buf.String())
+			}
+		}
+	}
+}
+
+func TestWithLevelFormatConsole(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.ColorText),
+		xlog.WithOutput(&buf),
+		xlog.WithLevelFormat(xlog.LevelLower, xlog.LevelNumeric),
+	)
+	xlog.Info(context.Background(), "hello")
+
+	if !strings.Contains(buf.String(), "info") || strings.Contains(buf.String(), "INF") {
+		t.Errorf("expected console level in lowercase, got: %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"WARNING", slog.LevelWarn},
+		{"trace", xlog.LevelTrace},
+		{"fatal", xlog.LevelCritical},
+		{"warn+2", slog.LevelWarn + 2},
+		{"DEBUG-4", xlog.LevelTrace},
+		{"-8", -8},
+	}
+	for _, tt := range tests {
+		got, err := xlog.ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "verbose", "warn+x"} {
+		if _, err := xlog.ParseLevel(in); err == nil {
+			t.Errorf("ParseLevel(%q): expected an error", in)
+		}
+	}
+}
+
+func TestLevelText(t *testing.T) {
+	xlog.RegisterLevel(slog.LevelInfo+2, "NOTICE")
+
+	for _, level := range []slog.Level{xlog.LevelTrace, slog.LevelInfo, slog.LevelInfo + 2, slog.LevelWarn + 1, xlog.LevelCritical} {
+		text, err := xlog.Level(level).MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got xlog.Level
+		if err := got.UnmarshalText(text); err != nil || got.Level() != level {
+			t.Errorf("%v: round trip through %q gave %v, %v", level, text, got.Level(), err)
+		}
+	}
+	if got := xlog.Level(slog.LevelInfo + 2).String(); got != "NOTICE" {
+		t.Errorf("expected NOTICE, got %q", got)
+	}
+	if got := xlog.Level(xlog.LevelTrace).String(); got != "TRACE" {
+		t.Errorf("expected TRACE, got %q", got)
+	}
+}
+
+func TestRegisterLevelOutput(t *testing.T) {
+	xlog.RegisterLevel(slog.LevelInfo+3, "AUDIT")
+
+	tests := []struct {
+		level slog.Level
+		want  string
+	}{
+		{slog.LevelInfo + 3, "AUDIT"},
+		{xlog.LevelCritical, "CRITICAL"},
+		{slog.LevelWarn, "WARN"},
+	}
+	for _, jsonFormat := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		for _, tt := range tests {
+			var buf bytes.Buffer
+			_ = xlog.Init(xlog.WithFormat(jsonFormat), xlog.WithOutput(&buf))
+			xlog.Default().Log(context.Background(), tt.level, "hello")
+
+			var rec map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec["level"] != tt.want {
+				t.Errorf("%s: expected level %s, got: %s", jsonFormat, tt.want, buf.String())
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.ColorText), xlog.WithOutput(&buf))
+	xlog.Default().Log(context.Background(), slog.LevelInfo+3, "hello")
+	if !strings.Contains(buf.String(), "AUDIT") {
+		t.Errorf("expected registered level name, got: %q", buf.String())
+	}
+}
@@ -0,0 +1,144 @@
+package xlog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// TruncatedMarker ends string values cut by WithMaxValueLength.
+const TruncatedMarker = "…truncated"
+
+// TruncatedAttrsKey is the attribute holding the number of attributes
+// dropped from a record by WithMaxAttrs.
+const TruncatedAttrsKey = "truncated_attrs"
+
+// WithMaxValueLength cuts the message and string values longer than n
+// bytes, including those in groups, and appends TruncatedMarker, so one
+// oversized value cannot produce a huge line. Other values whose text is
+// longer, such as errors, Stringers, byte slices and structs, are
+// replaced with their cut text. Zero means no limit.
+func WithMaxValueLength(n int) Option {
+	return func(c *config) {
+		c.maxValueLen = n
+	}
+}
+
+// WithMaxAttrs keeps at most n attributes per record, counting those
+// added with With and by options such as WithService, and records how
+// many were dropped in a "truncated_attrs" attribute. Attributes added
+// from the context and by PushScope are not counted. Zero means no limit.
+func WithMaxAttrs(n int) Option {
+	return func(c *config) {
+		c.maxAttrs = n
+	}
+}
+
+// limitHandler enforces WithMaxValueLength and WithMaxAttrs.
+type limitHandler struct {
+	handler  slog.Handler
+	maxLen   int
+	maxAttrs int
+	// preset is the number of attributes added with WithAttrs
+	preset int
+}
+
+func (h *limitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *limitHandler) Handle(ctx context.Context, r slog.Record) error {
+	budget := r.NumAttrs()
+	if h.maxAttrs > 0 {
+		budget = max(h.maxAttrs-h.preset, 0)
+	}
+	if h.maxLen <= 0 && budget >= r.NumAttrs() {
+		return h.handler.Handle(ctx, r)
+	}
+
+	r2 := slog.NewRecord(r.Time, r.Level, h.truncate(r.Message), r.PC)
+	dropped := 0
+	r.Attrs(func(a slog.Attr) bool {
+		if budget == 0 {
+			dropped++
+			return true
+		}
+		budget--
+		r2.AddAttrs(h.limitAttr(a))
+		return true
+	})
+	if dropped > 0 {
+		r2.AddAttrs(slog.Int(TruncatedAttrsKey, dropped))
+	}
+	return h.handler.Handle(ctx, r2)
+}
+
+func (h *limitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	limited := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		limited[i] = h.limitAttr(a)
+	}
+	h2 := *h
+	h2.handler = h.handler.WithAttrs(limited)
+	h2.preset += len(attrs)
+	return &h2
+}
+
+func (h *limitHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.handler = h.handler.WithGroup(name)
+	return &h2
+}
+
+// limitAttr truncates the string values of a, and the text of its
+// other values.
+func (h *limitHandler) limitAttr(a slog.Attr) slog.Attr {
+	if h.maxLen <= 0 {
+		return a
+	}
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(h.truncate(a.Value.String()))
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			attrs[i] = h.limitAttr(ga)
+		}
+		a.Value = slog.GroupValue(attrs...)
+	case slog.KindAny:
+		if s := anyText(a.Value.Any()); len(s) > h.maxLen {
+			a.Value = slog.StringValue(h.truncate(s))
+		}
+	}
+	return a
+}
+
+// anyText returns the text of v as handlers would write it: the message
+// of an error, the result of String, a byte slice as a string, or %+v.
+func anyText(v any) string {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprintf("%+v", v)
+	}
+}
+
+// truncate cuts s to at most maxLen bytes on a rune boundary.
+func (h *limitHandler) truncate(s string) string {
+	if h.maxLen <= 0 || len(s) <= h.maxLen {
+		return s
+	}
+	n := h.maxLen
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + TruncatedMarker
+}
@@ -0,0 +1,109 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithMaxValueLength(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithMaxValueLength(8),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.With("body", strings.Repeat("x", 100)).Info(context.Background(), "a long message",
+		"name", "日本語のテキスト", slog.Group("req", "query", "select * from users"), "n", 12345678910)
+
+	var rec struct {
+		Msg  string
+		Body string
+		Name string
+		Req  struct{ Query string }
+		N    int
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	m := xlog.TruncatedMarker
+	if rec.Msg != "a long m"+m || rec.Body != "xxxxxxxx"+m || rec.Req.Query != "select *"+m {
+		t.Errorf("unexpected truncation: %s", buf.String())
+	}
+	if rec.Name != "日本"+m {
+		t.Errorf("expected a cut on a rune boundary, got %q", rec.Name)
+	}
+	if rec.N != 12345678910 {
+		t.Errorf("expected numbers to be kept, got %d", rec.N)
+	}
+}
+
+type longStringer struct{}
+
+func (longStringer) String() string { return "a stringer with a long text" }
+
+func TestWithMaxValueLengthAny(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithMaxValueLength(8),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info(context.Background(), "msg",
+		"err", errors.New("connection refused by upstream"),
+		"stringer", longStringer{},
+		"bytes", []byte("a byte slice holding a payload"),
+		"struct", struct{ Name, City string }{"alice", "springfield"},
+		"short", errors.New("eof"))
+
+	var rec struct {
+		Err, Stringer, Bytes, Struct, Short string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	m := xlog.TruncatedMarker
+	want := [...]string{"connecti" + m, "a string" + m, "a byte s" + m, "{Name:al" + m, "eof"}
+	if got := [...]string{rec.Err, rec.Stringer, rec.Bytes, rec.Struct, rec.Short}; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWithMaxAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithMaxAttrs(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := xlog.WithRequestID(context.Background(), "r1")
+	logger.With("a", 1).Info(ctx, "many", "b", 2, "c", 3, "d", 4, "e", 5)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"a", "b", "c", "request_id"} {
+		if _, ok := rec[key]; !ok {
+			t.Errorf("expected %s to be kept, got: %s", key, buf.String())
+		}
+	}
+	if _, ok := rec["d"]; ok || rec[xlog.TruncatedAttrsKey] != float64(2) {
+		t.Errorf("expected two attributes to be dropped, got: %s", buf.String())
+	}
+}
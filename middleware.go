@@ -0,0 +1,86 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// HandlerMiddleware wraps a handler to add behavior such as redaction,
+// sampling, enrichment, or metrics.
+type HandlerMiddleware func(slog.Handler) slog.Handler
+
+// Chain combines middlewares into one. The first middleware is the
+// outermost, so it sees each record before the others.
+func Chain(mws ...HandlerMiddleware) HandlerMiddleware {
+	return func(h slog.Handler) slog.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			h = mws[i](h)
+		}
+		return h
+	}
+}
+
+// Tee returns a middleware that also sends each record to hs, such as an
+// error reporting handler running beside the main output. Errors from all
+// handlers are joined.
+func Tee(hs ...slog.Handler) HandlerMiddleware {
+	return func(next slog.Handler) slog.Handler {
+		return &teeHandler{next: next, hs: hs}
+	}
+}
+
+type teeHandler struct {
+	next slog.Handler
+	hs   []slog.Handler
+}
+
+// Enabled reports whether any handler accepts the level. The next
+// handler is asked only if none of hs accepts, and Handle asks it only
+// if one did, so each Enabled and Handle pair asks it once.
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, th := range h.hs {
+		if th.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handleWithAttrs(ctx, r, nil)
+}
+
+// handleWithAttrs passes the record with attrs ahead of its own to each
+// handler that accepts it.
+func (h *teeHandler) handleWithAttrs(ctx context.Context, r slog.Record, attrs []slog.Attr) error {
+	var errs []error
+	teed := false
+	for _, th := range h.hs {
+		if th.Enabled(ctx, r.Level) {
+			teed = true
+			errs = append(errs, handleWithAttrs(ctx, th, r.Clone(), attrs))
+		}
+	}
+	// Otherwise Enabled already asked the next handler
+	if !teed || h.next.Enabled(ctx, r.Level) {
+		errs = append(errs, handleWithAttrs(ctx, h.next, r, attrs))
+	}
+	return errors.Join(errs...)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make([]slog.Handler, len(h.hs))
+	for i, th := range h.hs {
+		hs[i] = th.WithAttrs(attrs)
+	}
+	return &teeHandler{next: h.next.WithAttrs(attrs), hs: hs}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	hs := make([]slog.Handler, len(h.hs))
+	for i, th := range h.hs {
+		hs[i] = th.WithGroup(name)
+	}
+	return &teeHandler{next: h.next.WithGroup(name), hs: hs}
+}
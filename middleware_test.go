@@ -0,0 +1,120 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+// tagHandler appends its tag to the "seen" attribute of each record.
+type tagHandler struct {
+	slog.Handler
+	tag string
+}
+
+func (h *tagHandler) Handle(ctx context.Context, r slog.Record) error {
+	var seen string
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "seen" {
+			seen = a.Value.String()
+		} else {
+			r2.AddAttrs(a)
+		}
+		return true
+	})
+	r2.AddAttrs(slog.String("seen", seen+h.tag))
+	return h.Handler.Handle(ctx, r2)
+}
+
+func tag(s string) xlog.HandlerMiddleware {
+	return func(h slog.Handler) slog.Handler {
+		return &tagHandler{Handler: h, tag: s}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.Chain(tag("a"), tag("b"), tag("c"))(slog.NewTextHandler(&buf, nil))
+
+	slog.New(h).Info("chained")
+
+	if !strings.Contains(buf.String(), "seen=abc") {
+		t.Errorf("expected middlewares to run first to last, got: %s", buf.String())
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithMiddleware(tag("a")),
+		xlog.WithMiddleware(tag("b")),
+	)
+
+	ctx := xlog.WithTraceID(context.Background(), "trace-123")
+	xlog.Info(ctx, "with middleware")
+
+	output := buf.String()
+	if !strings.Contains(output, `"seen":"ab"`) {
+		t.Errorf("expected middlewares in option order, got: %s", output)
+	}
+	if !strings.Contains(output, `"trace_id":"trace-123"`) {
+		t.Errorf("expected context attrs to reach middlewares, got: %s", output)
+	}
+}
+
+func TestTee(t *testing.T) {
+	var main, errs bytes.Buffer
+	errHandler := slog.NewTextHandler(&errs, &slog.HandlerOptions{Level: slog.LevelError})
+	logger := slog.New(xlog.Tee(errHandler)(slog.NewTextHandler(&main, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	logger = logger.With("app", "shop")
+	logger.Info("dropped by both")
+	logger.Warn("main only")
+	logger.Error("both")
+
+	if got := main.String(); strings.Contains(got, "dropped") || !strings.Contains(got, "main only") || !strings.Contains(got, "msg=both app=shop") {
+		t.Errorf("unexpected main output: %s", got)
+	}
+	if got := errs.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, "msg=both app=shop") {
+		t.Errorf("unexpected tee output: %s", got)
+	}
+}
+
+// countingHandler counts the calls to its Enabled method.
+type countingHandler struct {
+	slog.Handler
+	enabled int
+}
+
+func (h *countingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	h.enabled++
+	return h.Handler.Enabled(ctx, level)
+}
+
+func TestTeeAsksNextOnce(t *testing.T) {
+	var main, errs bytes.Buffer
+	errHandler := slog.NewTextHandler(&errs, &slog.HandlerOptions{Level: slog.LevelError})
+	next := &countingHandler{Handler: slog.NewTextHandler(&main, nil)}
+	logger := slog.New(xlog.Tee(errHandler)(next))
+
+	logger.Info("info")
+	if next.enabled != 1 {
+		t.Errorf("expected 1 Enabled call for an INFO record, got %d", next.enabled)
+	}
+	next.enabled = 0
+	logger.Error("error")
+	if next.enabled != 1 {
+		t.Errorf("expected 1 Enabled call for an ERROR record, got %d", next.enabled)
+	}
+	if strings.Count(main.String(), "\n") != 2 || strings.Count(errs.String(), "\n") != 1 {
+		t.Errorf("unexpected output: %s / %s", main.String(), errs.String())
+	}
+}
@@ -0,0 +1,197 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// LoggerKey is the attribute holding the name of a logger from Named.
+const LoggerKey = "logger"
+
+// lowestLevel lets every record through a handler so levels can be
+// decided per logger by levelHandler instead.
+const lowestLevel = slog.Level(math.MinInt)
+
+// loggerLevels maps logger names to their configured level. The empty
+// name holds the level of the default logger. The map is replaced, never
+// modified, so it can be read without locking.
+var loggerLevels atomic.Pointer[map[string]slog.Level]
+
+var (
+	// loggerLevelsMu serializes updates to loggerLevels
+	loggerLevelsMu sync.Mutex
+	// loggerNames records every name passed through Named
+	loggerNames sync.Map
+)
+
+// WithLoggerLevel sets the level of the logger called name and of its
+// descendants, overriding WithLevel for them:
+//
+//	xlog.Init(
+//		xlog.WithLevel(slog.LevelWarn),
+//		xlog.WithLoggerLevel("http", slog.LevelDebug), // http, http.server, ...
+//	)
+func WithLoggerLevel(name string, level slog.Level) Option {
+	return func(c *config) {
+		if c.loggerLevels == nil {
+			c.loggerLevels = make(map[string]slog.Level)
+		}
+		c.loggerLevels[name] = level
+	}
+}
+
+// Named returns a child of the default logger called name.
+func Named(name string) *Logger {
+	return Default().Named(name)
+}
+
+// Named returns a child logger whose records carry a "logger" attribute.
+// Names nest with dots: Named("http").Named("server") is "http.server".
+// The child's level is the one set for its name with WithLoggerLevel,
+// else that of its closest named ancestor, else the default level. The
+// children of a logger from New share its level instead.
+func (l *Logger) Named(name string) *Logger {
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+
+	// Replace the parent's level check with the child's
+	h := l.Logger.Handler()
+	var flight *atomic.Bool
+	if lh, ok := h.(*levelHandler); ok {
+		h, flight = lh.handler, lh.flight
+	}
+	// Loggers from New keep their own level
+	var level slog.Leveler = namedLevel(name)
+	if _, ok := l.level.(namedLevel); !ok && l.level != nil {
+		level = l.level
+	}
+	h = &levelHandler{
+		handler: h.WithAttrs([]slog.Attr{slog.String(LoggerKey, name)}),
+		level:   level,
+		flight:  flight,
+	}
+
+	loggerNames.Store(name, struct{}{})
+
+	l2 := l.derive(slog.New(h))
+	l2.name = name
+	l2.level = level
+	return l2
+}
+
+// SetLoggerLevel changes the level of the logger called name and of its
+// descendants without their own level. It applies immediately to loggers
+// that already exist. The empty name is the default logger.
+func SetLoggerLevel(name string, level slog.Level) {
+	loggerLevelsMu.Lock()
+	defer loggerLevelsMu.Unlock()
+
+	levels := make(map[string]slog.Level)
+	if p := loggerLevels.Load(); p != nil {
+		levels = maps.Clone(*p)
+	}
+	levels[name] = level
+	loggerLevels.Store(&levels)
+}
+
+// LoggerInfo describes a named logger.
+type LoggerInfo struct {
+	// Name is the logger's name; the default logger has an empty name.
+	Name string `json:"name"`
+	// Level is the logger's effective level.
+	Level slog.Level `json:"level"`
+	// Inherited reports whether the level comes from an ancestor rather
+	// than being set for this name.
+	Inherited bool `json:"inherited"`
+}
+
+// ListLoggers returns the default logger and every logger created with
+// Named or given a level, sorted by name.
+func ListLoggers() []LoggerInfo {
+	names := map[string]bool{"": true}
+	var levels map[string]slog.Level
+	if p := loggerLevels.Load(); p != nil {
+		levels = *p
+	}
+	for name := range levels {
+		names[name] = true
+	}
+	loggerNames.Range(func(k, _ any) bool {
+		names[k.(string)] = true
+		return true
+	})
+
+	infos := make([]LoggerInfo, 0, len(names))
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		_, set := levels[name]
+		infos = append(infos, LoggerInfo{
+			Name:      name,
+			Level:     namedLevel(name).Level(),
+			Inherited: !set,
+		})
+	}
+	return infos
+}
+
+// namedLevel is the level of the logger with its name, resolved on every
+// call so level changes apply to existing loggers.
+type namedLevel string
+
+// Level returns the level set for the name or its closest ancestor.
+func (n namedLevel) Level() slog.Level {
+	p := loggerLevels.Load()
+	if p == nil {
+		return slog.LevelInfo
+	}
+	levels := *p
+	name := string(n)
+	for name != "" {
+		if level, ok := levels[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	// The default logger is the ancestor of all names
+	return levels[""]
+}
+
+// levelHandler drops records below a level that can change at runtime.
+type levelHandler struct {
+	handler slog.Handler
+	level   slog.Leveler
+	// flight reports whether the handler holds records for
+	// StartFlightRecorder, which are enabled below the level
+	flight *atomic.Bool
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return (level >= h.level.Level() || contextEnabled(ctx, level) || h.flightRecords(ctx, level)) &&
+		h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) flightRecords(ctx context.Context, level slog.Level) bool {
+	return h.flight != nil && h.flight.Load() && flightRecords(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{handler: h.handler.WithAttrs(attrs), level: h.level, flight: h.flight}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{handler: h.handler.WithGroup(name), level: h.level, flight: h.flight}
+}
@@ -0,0 +1,50 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithLevel(slog.LevelWarn),
+		xlog.WithLoggerLevel("http", slog.LevelDebug),
+		xlog.WithLoggerLevel("http.client", slog.LevelError),
+	)
+
+	ctx := context.Background()
+	server := xlog.Named("http").With("port", 8080).Named("server")
+	client := xlog.Named("http").Named("client")
+	db := xlog.Named("db")
+
+	server.Debug(ctx, "server debug")
+	client.Warn(ctx, "client warn")
+	db.Info(ctx, "db info")
+	db.Warn(ctx, "db warn")
+
+	output := buf.String()
+	if !strings.Contains(output, `"logger":"http.server"`) || !strings.Contains(output, `"port":8080`) {
+		t.Errorf("expected http.server to inherit the http level and keep attrs, got: %s", output)
+	}
+	if strings.Contains(output, "client warn") {
+		t.Errorf("expected http.client to use its own level, got: %s", output)
+	}
+	if strings.Contains(output, "db info") || !strings.Contains(output, `"logger":"db"`) {
+		t.Errorf("expected db to use the default level, got: %s", output)
+	}
+	if strings.Count(output, `"logger":"http.server"`) != 1 {
+		t.Errorf("expected the logger attribute once, got: %s", output)
+	}
+	if got := server.Level(); got != slog.LevelDebug {
+		t.Errorf("expected server level DEBUG, got %v", got)
+	}
+}
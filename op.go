@@ -0,0 +1,94 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// timeItLevel is the level TimeIt logs at, set by WithTimeItLevel.
+var timeItLevel atomic.Int64
+
+// Op is an operation in progress, started by Start. It is a lightweight
+// alternative to a tracing span for code that is not traced:
+//
+//	op := xlog.Start(ctx, "load-user", "user_id", id)
+//	user, err := load(ctx, id)
+//	op.End(err)
+type Op struct {
+	ctx    context.Context
+	logger *Logger
+	name   string
+	args   []any
+	start  time.Time
+}
+
+// Start begins the operation name, with args logged when it ends.
+func Start(ctx context.Context, name string, args ...any) *Op {
+	return Default().Start(ctx, name, args...)
+}
+
+// Start begins the operation name, with args logged when it ends.
+func (l *Logger) Start(ctx context.Context, name string, args ...any) *Op {
+	return &Op{ctx: ctx, logger: l, name: name, args: args, start: time.Now()}
+}
+
+// End logs the operation with its duration and outcome: at INFO with
+// outcome=ok if err is nil, and at ERROR with outcome=error and the error
+// otherwise. The source location is that of the call to End.
+func (o *Op) End(err error) {
+	level, args := o.result(err)
+	logWithCaller(o.ctx, o.logger, level, o.name, args...)
+}
+
+// result returns the level and arguments of the record End logs.
+func (o *Op) result(err error) (slog.Level, []any) {
+	args := make([]any, 0, len(o.args)+6)
+	args = append(args, o.args...)
+	args = append(args, "duration", time.Since(o.start))
+	if err != nil {
+		return slog.LevelError, append(args, "outcome", "error", "error", err)
+	}
+	return slog.LevelInfo, append(args, "outcome", "ok")
+}
+
+// WithTimeItLevel sets the level TimeIt logs at. The default is DEBUG.
+func WithTimeItLevel(level slog.Level) Option {
+	return func(c *config) {
+		c.timeItLevel = level
+	}
+}
+
+// TimeIt logs the time until the returned function is called, along with
+// the name of the calling function. It is meant to be deferred:
+//
+//	defer xlog.TimeIt(ctx, "rebuild-index")()
+func TimeIt(ctx context.Context, name string, args ...any) func() {
+	return timeIt(ctx, Default(), name, args)
+}
+
+// TimeIt logs the time until the returned function is called, along with
+// the name of the calling function.
+func (l *Logger) TimeIt(ctx context.Context, name string, args ...any) func() {
+	return timeIt(ctx, l, name, args)
+}
+
+func timeIt(ctx context.Context, l *Logger, name string, args []any) func() {
+	level := slog.Level(timeItLevel.Load())
+	if !l.Logger.Enabled(ctx, level) {
+		return func() {}
+	}
+
+	// The deferred call runs at the caller's return, so record the
+	// location of the call to TimeIt instead
+	pc := callerPC(callerSkip + l.callerSkip)
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	function := shortFunction(frame.Function)
+	start := time.Now()
+	return func() {
+		args := append(args[:len(args):len(args)], "func", function, "duration", time.Since(start))
+		logPC(ctx, l, level, pc, name, args...)
+	}
+}
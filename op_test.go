@@ -0,0 +1,89 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestStartEnd(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+	)
+
+	ctx := context.Background()
+	xlog.Start(ctx, "load-user", "user_id", "u1").End(nil)
+	xlog.Start(ctx, "save-user").End(errors.New("disk full"))
+
+	dec := json.NewDecoder(&buf)
+	tests := []struct {
+		msg, level, outcome, err string
+	}{
+		{"load-user", "INFO", "ok", ""},
+		{"save-user", "ERROR", "error", "disk full"},
+	}
+	for _, tt := range tests {
+		var rec struct {
+			Msg      string
+			Level    string
+			Outcome  string
+			Error    string
+			Duration *int64
+		}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Msg != tt.msg || rec.Level != tt.level || rec.Outcome != tt.outcome || rec.Error != tt.err {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+		if rec.Duration == nil {
+			t.Errorf("%s: expected duration", tt.msg)
+		}
+	}
+}
+
+func rebuildIndex(ctx context.Context) {
+	defer xlog.TimeIt(ctx, "rebuild-index", "shards", 3)()
+}
+
+func TestTimeIt(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithLevel(slog.LevelDebug),
+	)
+	rebuildIndex(context.Background())
+
+	var rec struct {
+		Msg      string
+		Level    string
+		Func     string
+		Shards   int
+		Duration *int64
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Msg != "rebuild-index" || rec.Level != "DEBUG" || rec.Func != "xlog_test.rebuildIndex" || rec.Shards != 3 || rec.Duration == nil {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	buf.Reset()
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithTimeItLevel(slog.LevelInfo),
+	)
+	rebuildIndex(context.Background())
+	if !bytes.Contains(buf.Bytes(), []byte(`"level":"INFO"`)) {
+		t.Errorf("expected INFO record, got %s", buf.String())
+	}
+}
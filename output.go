@@ -0,0 +1,167 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// defaultOutput is the output of the default logger set by Init.
+var defaultOutput atomic.Pointer[switchWriter]
+
+// SetOutput redirects the default logger's output to w immediately, for
+// every logger derived from it and beneath any buffering or sharding set
+// with Init. Writes in progress finish on the previous output, which is
+// not closed. It has no effect before Init, on a handler set with
+// WithHandler, or on the security and event outputs.
+func SetOutput(w io.Writer) {
+	if out := defaultOutput.Load(); out != nil {
+		out.set(w)
+	}
+}
+
+// switchWriter is an io.Writer whose destination can be replaced while
+// in use.
+type switchWriter struct {
+	mu sync.RWMutex
+	w  io.Writer
+}
+
+func (s *switchWriter) set(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w = w
+}
+
+func (s *switchWriter) Write(p []byte) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.w.Write(p)
+}
+
+// Flush flushes the current output.
+func (s *switchWriter) Flush(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return flushSinks(ctx, []any{s.w})
+}
+
+// Close closes the current output, unless it is standard output or error.
+func (s *switchWriter) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.w.(io.Closer)
+	if !ok || s.w == os.Stdout || s.w == os.Stderr {
+		return nil
+	}
+	return c.Close()
+}
+
+// fileWriters holds the open FileWriters for ReopenFiles.
+var fileWriters sync.Map
+
+// FileWriter is an io.Writer appending to a file that can be reopened at
+// its path, after logrotate or a similar tool has moved it away:
+//
+//	f, err := xlog.NewFileWriter("/var/log/app.log")
+//	if err != nil {
+//		return err
+//	}
+//	xlog.Init(xlog.WithOutput(f))
+//
+//	hup := make(chan os.Signal, 1)
+//	signal.Notify(hup, syscall.SIGHUP)
+//	go func() {
+//		for range hup {
+//			_ = xlog.ReopenFiles()
+//		}
+//	}()
+//
+// The file is opened for appending, so logrotate's copytruncate works
+// without a reopen.
+type FileWriter struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileWriter opens the file at path for appending, creating it if
+// needed.
+func NewFileWriter(path string) (*FileWriter, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &FileWriter{path: path, f: f}
+	fileWriters.Store(w, struct{}{})
+	return w, nil
+}
+
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+}
+
+// Write appends p to the file.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	return w.f.Write(p)
+}
+
+// Reopen closes the file and opens the file now at its path. If the
+// path cannot be opened, the current file is kept.
+func (w *FileWriter) Reopen() error {
+	f, err := openLogFile(w.path)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return errors.Join(os.ErrClosed, f.Close())
+	}
+	old := w.f
+	w.f = f
+	return old.Close()
+}
+
+// Sync commits the file to stable storage.
+func (w *FileWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	return w.f.Sync()
+}
+
+// Close closes the file.
+func (w *FileWriter) Close() error {
+	fileWriters.Delete(w)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// ReopenFiles reopens every open FileWriter, as after log rotation. It
+// returns the errors of those that could not be reopened.
+func ReopenFiles() error {
+	var errs []error
+	fileWriters.Range(func(k, _ any) bool {
+		errs = append(errs, k.(*FileWriter).Reopen())
+		return true
+	})
+	return errors.Join(errs...)
+}
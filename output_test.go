@@ -0,0 +1,55 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestSetOutput(t *testing.T) {
+	var first, second bytes.Buffer
+	_ = xlog.Init(xlog.WithOutput(&first))
+	logger := xlog.Named("api")
+
+	xlog.SetOutput(&second)
+	logger.Info(context.Background(), "redirected")
+
+	if first.Len() != 0 || !strings.Contains(second.String(), "redirected") {
+		t.Errorf("expected the record in the new output, got %q and %q", first.String(), second.String())
+	}
+}
+
+func TestReopenFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := xlog.NewFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_ = xlog.Init(xlog.WithOutput(f))
+
+	ctx := context.Background()
+	xlog.Info(ctx, "before rotation")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := xlog.ReopenFiles(); err != nil {
+		t.Fatal(err)
+	}
+	xlog.Info(ctx, "after rotation")
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "before rotation") || strings.Contains(string(rotated), "after rotation") {
+		t.Errorf("unexpected rotated file: %s", rotated)
+	}
+	if !strings.Contains(string(current), "after rotation") {
+		t.Errorf("expected the new file to get later records, got: %s", current)
+	}
+}
@@ -0,0 +1,175 @@
+package xlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// PagerDutyEndpoint is the PagerDuty Events API v2 endpoint.
+const PagerDutyEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyHandlerOptions configures a PagerDutyHandler.
+type PagerDutyHandlerOptions struct {
+	// Level is the minimum level to page on. Defaults to slog.LevelError+4,
+	// so only records logged above ERROR trigger incidents.
+	Level slog.Leveler
+
+	// Source identifies the affected system. Defaults to the hostname.
+	Source string
+
+	// Component and Group are optional event fields used by PagerDuty
+	// for grouping and routing.
+	Component string
+	Group     string
+
+	// Endpoint is the Events API URL. Defaults to PagerDutyEndpoint.
+	Endpoint string
+
+	// QueueSize is the number of events waiting to be sent before new
+	// ones are dropped. Defaults to 100.
+	QueueSize int
+
+	// Client sends the requests. Defaults to a client with a 10s timeout.
+	Client *http.Client
+
+	// OnDeliveryError is called when an event cannot be sent.
+	OnDeliveryError func(err error)
+}
+
+// PagerDutyHandler triggers PagerDuty incidents from records through the
+// Events API v2. Records with the same message and error type share a
+// dedup key, so repeats update one incident instead of opening new ones.
+// Like SentryHandler, it is meant to run beside the main handler via Tee:
+//
+//	pd := xlog.NewPagerDutyHandler(routingKey, nil)
+//	defer pd.Close()
+//	xlog.Init(xlog.WithMiddleware(xlog.Tee(pd)))
+//	xlog.Default().Log(ctx, slog.LevelError+4, "primary database unreachable", "error", err)
+type PagerDutyHandler struct {
+	routingKey string
+	opts       PagerDutyHandlerOptions
+	state      sinkState
+	queue      *postQueue
+}
+
+// NewPagerDutyHandler creates a PagerDutyHandler sending events to the
+// service integration with routingKey.
+func NewPagerDutyHandler(routingKey string, opts *PagerDutyHandlerOptions) *PagerDutyHandler {
+	var o PagerDutyHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelError + 4
+	}
+	if o.Source == "" {
+		o.Source, _ = os.Hostname()
+	}
+	if o.Endpoint == "" {
+		o.Endpoint = PagerDutyEndpoint
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 100
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &PagerDutyHandler{
+		routingKey: routingKey,
+		opts:       o,
+		queue:      newPostQueue("pagerduty", o.Endpoint, o.Client, o.QueueSize, o.OnDeliveryError),
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *PagerDutyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// Handle queues a trigger event for the record.
+func (h *PagerDutyHandler) Handle(_ context.Context, r slog.Record) error {
+	var errType string
+	r.Attrs(func(a slog.Attr) bool {
+		if err, ok := a.Value.Resolve().Any().(error); ok {
+			errType = fmt.Sprintf("%T", err)
+			return false
+		}
+		return true
+	})
+	sum := sha256.Sum256([]byte(r.Message + "\x00" + errType))
+
+	summary := r.Message
+	if len(summary) > 1024 {
+		summary = summary[:1024]
+	}
+	payload := map[string]any{
+		"summary":        summary,
+		"source":         h.opts.Source,
+		"severity":       pagerDutySeverity(r.Level),
+		"timestamp":      r.Time.Format(time.RFC3339Nano),
+		"custom_details": h.state.fields(r),
+	}
+	if errType != "" {
+		payload["class"] = errType
+	}
+	if h.opts.Component != "" {
+		payload["component"] = h.opts.Component
+	}
+	if h.opts.Group != "" {
+		payload["group"] = h.opts.Group
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"routing_key":  h.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    hex.EncodeToString(sum[:16]),
+		"payload":      payload,
+	})
+	if err != nil {
+		return fmt.Errorf("xlog: pagerduty encode: %w", err)
+	}
+	h.queue.enqueue(body)
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *PagerDutyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *PagerDutyHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Close sends the queued events and stops the handler. Records handled
+// after Close are dropped.
+func (h *PagerDutyHandler) Close() error {
+	h.queue.close()
+	return nil
+}
+
+func pagerDutySeverity(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError+4:
+		return "critical"
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	default:
+		return "info"
+	}
+}
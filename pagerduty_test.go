@@ -0,0 +1,76 @@
+package xlog_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+type pdEvent struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	DedupKey    string `json:"dedup_key"`
+	Payload     struct {
+		Summary       string
+		Source        string
+		Severity      string
+		Class         string
+		CustomDetails map[string]any `json:"custom_details"`
+	}
+}
+
+func TestPagerDutyHandler(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []pdEvent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var e pdEvent
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("invalid event %q: %v", body, err)
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	h := xlog.NewPagerDutyHandler("rk", &xlog.PagerDutyHandlerOptions{
+		Endpoint: srv.URL,
+		Source:   "web-1",
+	})
+	logger := slog.New(h)
+	ctx := context.Background()
+	critical := slog.LevelError + 4
+	logger.Error("not paged")
+	logger.Log(ctx, critical, "db unreachable", "error", errors.New("timeout"), "db", "primary")
+	logger.Log(ctx, critical, "db unreachable", "error", errors.New("refused"))
+	logger.Log(ctx, critical, "db unreachable")
+	_ = h.Close()
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	e := events[0]
+	if e.RoutingKey != "rk" || e.EventAction != "trigger" || e.Payload.Summary != "db unreachable" ||
+		e.Payload.Severity != "critical" || e.Payload.Source != "web-1" || e.Payload.Class != "*errors.errorString" ||
+		e.Payload.CustomDetails["db"] != "primary" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if events[0].DedupKey != events[1].DedupKey {
+		t.Error("expected the same dedup key for the same message and error type")
+	}
+	if events[0].DedupKey == events[2].DedupKey {
+		t.Error("expected a different dedup key without an error")
+	}
+}
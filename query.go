@@ -0,0 +1,117 @@
+package xlog
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// QueryLoggerOptions configures a QueryLogger.
+type QueryLoggerOptions struct {
+	// SlowThreshold is the duration above which queries are logged at
+	// WARN instead of DEBUG. Zero means 200ms; negative never escalates.
+	SlowThreshold time.Duration
+
+	// Thresholds overrides SlowThreshold per statement, keyed by the
+	// statement text or by its first keyword, such as "SELECT" or
+	// "INSERT", compared case-insensitively. Exact statements win.
+	Thresholds map[string]time.Duration
+
+	// LogArgs adds the query arguments to slow and failed queries, so
+	// they can be rerun with EXPLAIN. Leave it off if arguments may hold
+	// secrets that no transformer redacts.
+	LogArgs bool
+}
+
+// QueryLogger logs database queries at DEBUG, escalating those slower
+// than their threshold to WARN and failed ones to ERROR, with the logger
+// from the context. It is the core of database integrations: call Log
+// from a driver wrapper or a library's query hook.
+type QueryLogger struct {
+	opts QueryLoggerOptions
+}
+
+// NewQueryLogger creates a QueryLogger.
+func NewQueryLogger(opts *QueryLoggerOptions) *QueryLogger {
+	q := &QueryLogger{}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.SlowThreshold == 0 {
+		q.opts.SlowThreshold = 200 * time.Millisecond
+	}
+	return q
+}
+
+// Threshold returns the duration above which query is slow, or a
+// negative duration if it never is.
+func (q *QueryLogger) Threshold(query string) time.Duration {
+	if len(q.opts.Thresholds) == 0 {
+		return q.opts.SlowThreshold
+	}
+	query = strings.TrimSpace(query)
+	if d, ok := q.opts.Thresholds[query]; ok {
+		return d
+	}
+	op := queryOperation(query)
+	for k, d := range q.opts.Thresholds {
+		if strings.EqualFold(k, op) {
+			return d
+		}
+	}
+	return q.opts.SlowThreshold
+}
+
+// Log logs query, run with args, which took dur and returned or affected
+// rows, or failed with err. sql.ErrNoRows is not a failure. Slow and
+// failed queries carry the operation, the threshold and, with LogArgs,
+// the arguments in the "db" group.
+func (q *QueryLogger) Log(ctx context.Context, query string, args []any, rows int64, dur time.Duration, err error) {
+	threshold := q.Threshold(query)
+	slow := threshold >= 0 && dur > threshold
+
+	level, msg := slog.LevelDebug, "query"
+	switch {
+	case err != nil && !errors.Is(err, sql.ErrNoRows):
+		level, msg = slog.LevelError, "query failed"
+	case slow:
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	l := FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("statement", query),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", dur),
+	}
+	if level > slog.LevelDebug {
+		attrs = append(attrs, slog.String("operation", queryOperation(query)))
+		if threshold >= 0 {
+			attrs = append(attrs, slog.Duration("threshold", threshold))
+		}
+		if q.opts.LogArgs && len(args) > 0 {
+			attrs = append(attrs, slog.Any("args", args))
+		}
+	}
+	logArgs := []any{slog.Attr{Key: DBKey, Value: slog.GroupValue(attrs...)}}
+	if err != nil {
+		logArgs = append(logArgs, Err(err))
+	}
+	logPC(ctx, l, level, 0, msg, logArgs...)
+}
+
+// queryOperation returns the first keyword of query in upper case, such
+// as "SELECT".
+func queryOperation(query string) string {
+	op, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	if i := strings.IndexAny(op, "\t\n\r("); i >= 0 {
+		op = op[:i]
+	}
+	return strings.ToUpper(op)
+}
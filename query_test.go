@@ -0,0 +1,63 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestQueryLogger(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(slog.LevelDebug))
+	ctx := context.Background()
+
+	q := xlog.NewQueryLogger(&xlog.QueryLoggerOptions{
+		SlowThreshold: 100 * time.Millisecond,
+		Thresholds: map[string]time.Duration{
+			"insert":                10 * time.Millisecond,
+			"SELECT * FROM reports": -1,
+		},
+		LogArgs: true,
+	})
+	q.Log(ctx, "SELECT * FROM users WHERE id = $1", []any{7}, 1, 50*time.Millisecond, nil)
+	q.Log(ctx, "INSERT INTO users VALUES ($1)", []any{"ann"}, 1, 50*time.Millisecond, nil)
+	q.Log(ctx, "SELECT * FROM reports", nil, 10, time.Minute, nil)
+	q.Log(ctx, "DELETE FROM users", nil, 0, time.Millisecond, errors.New("locked"))
+
+	type record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		Error string `json:"error"`
+		DB    struct {
+			Operation string `json:"operation"`
+			Threshold int64  `json:"threshold"`
+			Args      []any  `json:"args"`
+		} `json:"db"`
+	}
+	want := []record{
+		{Level: "DEBUG", Msg: "query"},
+		{Level: "WARN", Msg: "slow query"},
+		{Level: "DEBUG", Msg: "query"},
+		{Level: "ERROR", Msg: "query failed", Error: "locked"},
+	}
+	want[1].DB.Operation, want[1].DB.Threshold, want[1].DB.Args = "INSERT", int64(10*time.Millisecond), []any{"ann"}
+	want[3].DB.Operation, want[3].DB.Threshold = "DELETE", int64(100*time.Millisecond)
+
+	dec := json.NewDecoder(&buf)
+	for i, w := range want {
+		var got record
+		if err := dec.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Level != w.Level || got.Msg != w.Msg || got.Error != w.Error || got.DB.Operation != w.DB.Operation ||
+			got.DB.Threshold != w.DB.Threshold || len(got.DB.Args) != len(w.DB.Args) {
+			t.Errorf("record %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
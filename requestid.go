@@ -0,0 +1,90 @@
+package xlog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestIDHeader is the HTTP header read and written by RequestIDMiddleware.
+const RequestIDHeader = "X-Request-ID"
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	ulidMu   sync.Mutex
+	ulidMS   uint64
+	ulidRand [10]byte
+)
+
+// NewRequestID returns a new ULID: 26 characters that sort by creation
+// time, such as "01HMJ5X3Q8K4ZB8W4N6T2V9R7C". IDs created in the same
+// millisecond increase monotonically.
+func NewRequestID() string {
+	ms := uint64(time.Now().UnixMilli())
+
+	ulidMu.Lock()
+	if ms > ulidMS {
+		ulidMS = ms
+		_, _ = rand.Read(ulidRand[:])
+	} else {
+		// Same millisecond, or the clock went back: increment the
+		// random part so IDs stay ordered
+		for i := len(ulidRand) - 1; i >= 0; i-- {
+			ulidRand[i]++
+			if ulidRand[i] != 0 {
+				break
+			}
+		}
+	}
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], ulidMS<<16)
+	copy(id[6:], ulidRand[:])
+	ulidMu.Unlock()
+
+	// 128 bits as 26 base32 digits, the first holding 3 bits
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// RequestIDMiddleware reads the request ID from the X-Request-ID header,
+// generating one with NewRequestID when it is absent or malformed, adds
+// it to the request context with WithRequestID, and echoes it in the
+// response header:
+//
+//	http.ListenAndServe(":8080", xlog.RequestIDMiddleware(mux))
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !ValidRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
+
+// ValidRequestID reports whether an incoming request ID is safe to log:
+// short and made of printable ASCII without spaces or quotes. Middleware
+// for other routers uses it to decide whether to keep the ID.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c <= ' ' || c > '~' || c == '"' || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
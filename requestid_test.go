@@ -0,0 +1,59 @@
+package xlog_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestNewRequestID(t *testing.T) {
+	prev := ""
+	for range 1000 {
+		id := xlog.NewRequestID()
+		if len(id) != 26 || strings.Trim(id, "0123456789ABCDEFGHJKMNPQRSTVWXYZ") != "" {
+			t.Fatalf("invalid ULID %q", id)
+		}
+		if id <= prev {
+			t.Fatalf("expected increasing IDs, got %q after %q", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var got string
+	h := xlog.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = xlog.RequestIDFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		name, header string
+		keep         bool
+	}{
+		{"incoming", "req-123", true},
+		{"absent", "", false},
+		{"malformed", "bad id\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(xlog.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(xlog.RequestIDHeader)
+			if echoed == "" || echoed != got {
+				t.Errorf("expected the context ID %q to be echoed, got %q", got, echoed)
+			}
+			if (got == tt.header) != tt.keep {
+				t.Errorf("unexpected request ID %q for header %q", got, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package xlog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// RingBufferHandlerOptions configures a RingBufferHandler.
+type RingBufferHandlerOptions struct {
+	// Level is the minimum level to keep. Defaults to slog.LevelDebug.
+	Level slog.Leveler
+}
+
+// RingBufferHandler keeps the most recent records in memory, to be dumped
+// when something goes wrong, such as in a crash report. Run it beside the
+// main handler via Tee:
+//
+//	recent := xlog.NewRingBufferHandler(500, nil)
+//	xlog.Init(xlog.WithMiddleware(xlog.Tee(recent)))
+//	...
+//	defer func() {
+//		if p := recover(); p != nil {
+//			_, _ = recent.WriteTo(os.Stderr)
+//			panic(p)
+//		}
+//	}()
+type RingBufferHandler struct {
+	level slog.Leveler
+	state sinkState
+	ring  *ring
+}
+
+type ringEntry struct {
+	r    slog.Record
+	goas []groupOrAttrs
+}
+
+// ring is shared between a RingBufferHandler and its derived handlers.
+type ring struct {
+	mu      sync.Mutex
+	entries []ringEntry
+	next    int
+	full    bool
+}
+
+// NewRingBufferHandler creates a RingBufferHandler keeping the last size
+// records.
+func NewRingBufferHandler(size int, opts *RingBufferHandlerOptions) *RingBufferHandler {
+	var o RingBufferHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelDebug
+	}
+	return &RingBufferHandler{
+		level: o.Level,
+		ring:  &ring{entries: make([]ringEntry, max(size, 1))},
+	}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *RingBufferHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle stores the record, replacing the oldest one once the buffer is full.
+func (h *RingBufferHandler) Handle(_ context.Context, r slog.Record) error {
+	b := h.ring
+	b.mu.Lock()
+	b.entries[b.next] = ringEntry{r: r.Clone(), goas: h.state.goas}
+	b.next++
+	if b.next == len(b.entries) {
+		b.next, b.full = 0, true
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *RingBufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *RingBufferHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Len returns the number of records in the buffer.
+func (h *RingBufferHandler) Len() int {
+	b := h.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.full {
+		return len(b.entries)
+	}
+	return b.next
+}
+
+// Replay sends the buffered records, oldest first, to dst, along with the
+// attributes and groups they were logged with.
+func (h *RingBufferHandler) Replay(ctx context.Context, dst slog.Handler) error {
+	for _, e := range h.snapshot() {
+		dh := dst
+		for _, goa := range e.goas {
+			if goa.group != "" {
+				dh = dh.WithGroup(goa.group)
+			} else {
+				dh = dh.WithAttrs(goa.attrs)
+			}
+		}
+		if err := dh.Handle(ctx, e.r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTo writes the buffered records to w as JSON lines, oldest first.
+func (h *RingBufferHandler) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	err := h.Replay(context.Background(), slog.NewJSONHandler(cw, &slog.HandlerOptions{
+		AddSource: true,
+		Level:     lowestLevel,
+	}))
+	return cw.n, err
+}
+
+// Reset discards the buffered records.
+func (h *RingBufferHandler) Reset() {
+	b := h.ring
+	b.mu.Lock()
+	clear(b.entries)
+	b.next, b.full = 0, false
+	b.mu.Unlock()
+}
+
+func (h *RingBufferHandler) snapshot() []ringEntry {
+	b := h.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.full {
+		return append([]ringEntry(nil), b.entries[:b.next]...)
+	}
+	out := make([]ringEntry, 0, len(b.entries))
+	out = append(out, b.entries[b.next:]...)
+	return append(out, b.entries[:b.next]...)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,48 @@
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestRingBufferHandler(t *testing.T) {
+	h := xlog.NewRingBufferHandler(3, nil)
+	logger := slog.New(h).With("request_id", "r-1").WithGroup("db")
+
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		logger.Debug(msg, "n", len(msg))
+	}
+	if h.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", h.Len())
+	}
+
+	var buf bytes.Buffer
+	n, err := h.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, %v", n, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, want := range []string{"two", "three", "four"} {
+		var rec struct {
+			Msg       string
+			RequestID string `json:"request_id"`
+			DB        struct{ N int }
+		}
+		if err := json.Unmarshal([]byte(lines[i]), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Msg != want || rec.RequestID != "r-1" || rec.DB.N != len(want) {
+			t.Errorf("record %d: unexpected %s", i, lines[i])
+		}
+	}
+
+	h.Reset()
+	if h.Len() != 0 {
+		t.Errorf("expected empty buffer after Reset, got %d", h.Len())
+	}
+}
@@ -0,0 +1,232 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// TraceSamplerOptions configures SampleByTrace.
+type TraceSamplerOptions struct {
+	// Key is the context key holding the trace ID. Defaults to TraceIDKey.
+	Key ContextKey
+
+	// KeepLevel is the level at or above which records are always kept.
+	// Defaults to slog.LevelError.
+	KeepLevel slog.Leveler
+}
+
+// SampleByTrace keeps the records of a fraction rate of traces, deciding
+// from a hash of the trace ID so a trace is either logged completely or
+// not at all. The trace ID is taken from the context or from an attribute
+// added with With; records without one are always kept. Dropped records
+// are counted in Stats.
+func SampleByTrace(rate float64, opts *TraceSamplerOptions) HandlerMiddleware {
+	var o TraceSamplerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Key == "" {
+		o.Key = TraceIDKey
+	}
+	if o.KeepLevel == nil {
+		o.KeepLevel = slog.LevelError
+	}
+
+	return func(h slog.Handler) slog.Handler {
+		return &traceSampler{
+			handler:   h,
+			opts:      o,
+			threshold: sampleThreshold(rate),
+			keepAll:   rate >= 1,
+		}
+	}
+}
+
+// sampleThreshold maps rate to the largest hash value that is kept.
+func sampleThreshold(rate float64) uint64 {
+	if rate <= 0 {
+		return 0
+	}
+	if rate >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(rate * math.MaxUint64)
+}
+
+type traceSampler struct {
+	handler   slog.Handler
+	opts      TraceSamplerOptions
+	threshold uint64
+	keepAll   bool
+
+	// traceID is set when the trace ID was added with WithAttrs
+	traceID string
+	grouped bool
+}
+
+func (h *traceSampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle makes the sampling decision. It is taken here rather than in
+// Enabled, which callers may ask more than once per record, so each
+// record is counted once.
+func (h *traceSampler) Handle(ctx context.Context, r slog.Record) error {
+	if h.keepAll || r.Level >= h.opts.KeepLevel.Level() {
+		return h.handler.Handle(ctx, r)
+	}
+
+	id := h.traceID
+	if v, ok := ctx.Value(h.opts.Key).(string); ok && v != "" {
+		id = v
+	}
+	if id == "" || (h.threshold > 0 && traceHash(id) <= h.threshold) {
+		return h.handler.Handle(ctx, r)
+	}
+	recordDropped()
+	return nil
+}
+
+func (h *traceSampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.handler = h.handler.WithAttrs(attrs)
+	if !h.grouped {
+		for _, a := range attrs {
+			if a.Key == string(h.opts.Key) {
+				h2.traceID = a.Value.Resolve().String()
+			}
+		}
+	}
+	return &h2
+}
+
+func (h *traceSampler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.handler = h.handler.WithGroup(name)
+	h2.grouped = h.grouped || name != ""
+	return &h2
+}
+
+// traceHash is 64-bit FNV-1a, inlined to avoid allocating a hash.Hash,
+// followed by a final mix so IDs differing only in their last bytes still
+// spread across the whole range.
+func traceHash(id string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(id); i++ {
+		h ^= uint64(id[i])
+		h *= 1099511628211
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// AdaptiveSamplerOptions configures SampleAdaptive.
+type AdaptiveSamplerOptions struct {
+	// MaxPerSecond is the record rate above which sampling starts.
+	// Defaults to 1000.
+	MaxPerSecond int
+
+	// MinRate is the lowest fraction of records kept under load.
+	// Defaults to 0.01.
+	MinRate float64
+
+	// Window is how often the rate is measured and the ratio adjusted.
+	// Defaults to 1s.
+	Window time.Duration
+
+	// KeepLevel is the level at or above which records are always kept.
+	// Defaults to slog.LevelError.
+	KeepLevel slog.Leveler
+}
+
+// SampleAdaptive logs everything while the record rate stays below
+// MaxPerSecond. Above it, only enough records are kept to stay near the
+// limit, and full logging resumes once the volume drops. The current
+// ratio is reported as Stats().SampleRate.
+func SampleAdaptive(opts *AdaptiveSamplerOptions) HandlerMiddleware {
+	var o AdaptiveSamplerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxPerSecond <= 0 {
+		o.MaxPerSecond = 1000
+	}
+	if o.MinRate <= 0 {
+		o.MinRate = 0.01
+	}
+	if o.Window <= 0 {
+		o.Window = time.Second
+	}
+	if o.KeepLevel == nil {
+		o.KeepLevel = slog.LevelError
+	}
+
+	// State is shared by every handler the middleware wraps
+	s := &adaptiveState{opts: o}
+	s.start.Store(time.Now().UnixNano())
+	s.rate.Store(math.Float64bits(1))
+
+	return func(h slog.Handler) slog.Handler {
+		return &adaptiveSampler{handler: h, state: s}
+	}
+}
+
+type adaptiveState struct {
+	opts  AdaptiveSamplerOptions
+	start atomic.Int64  // start of the current window, in Unix nanoseconds
+	count atomic.Int64  // records seen in the current window
+	rate  atomic.Uint64 // float64 bits of the fraction of records kept
+}
+
+// observe counts a record and returns the ratio to sample it at.
+func (s *adaptiveState) observe() float64 {
+	now := time.Now().UnixNano()
+	start := s.start.Load()
+	if elapsed := now - start; elapsed >= int64(s.opts.Window) && s.start.CompareAndSwap(start, now) {
+		perSecond := float64(s.count.Swap(0)) / time.Duration(elapsed).Seconds()
+		rate := 1.0
+		if limit := float64(s.opts.MaxPerSecond); perSecond > limit {
+			rate = max(limit/perSecond, s.opts.MinRate)
+		}
+		s.rate.Store(math.Float64bits(rate))
+		setSampleRate(rate)
+	}
+	s.count.Add(1)
+	return math.Float64frombits(s.rate.Load())
+}
+
+type adaptiveSampler struct {
+	handler slog.Handler
+	state   *adaptiveState
+}
+
+func (h *adaptiveSampler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle counts the record and makes the sampling decision, here rather
+// than in Enabled, which callers may ask more than once per record.
+func (h *adaptiveSampler) Handle(ctx context.Context, r slog.Record) error {
+	rate := h.state.observe()
+	if rate >= 1 || r.Level >= h.state.opts.KeepLevel.Level() || rand.Float64() < rate {
+		return h.handler.Handle(ctx, r)
+	}
+	recordDropped()
+	return nil
+}
+
+func (h *adaptiveSampler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &adaptiveSampler{handler: h.handler.WithAttrs(attrs), state: h.state}
+}
+
+func (h *adaptiveSampler) WithGroup(name string) slog.Handler {
+	return &adaptiveSampler{handler: h.handler.WithGroup(name), state: h.state}
+}
@@ -0,0 +1,154 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestSampleByTrace(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithMiddleware(xlog.SampleByTrace(0.5, nil)),
+	)
+
+	before := xlog.Stats().Dropped
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		tctx := xlog.WithTraceID(ctx, fmt.Sprintf("trace-%d", i))
+		xlog.Info(tctx, "step 1")
+		xlog.Info(tctx, "step 2")
+		xlog.With("trace_id", fmt.Sprintf("trace-%d", i)).Info(ctx, "step 3")
+	}
+	xlog.Error(xlog.WithTraceID(ctx, "trace-error"), "always kept")
+	xlog.Info(ctx, "no trace")
+
+	perTrace := map[string]int{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON %q: %v", line, err)
+		}
+		id, _ := rec["trace_id"].(string)
+		perTrace[id]++
+	}
+
+	kept := 0
+	for id, n := range perTrace {
+		if strings.HasPrefix(id, "trace-") && id != "trace-error" {
+			kept++
+			if n != 3 {
+				t.Errorf("expected all 3 records of %s, got %d", id, n)
+			}
+		}
+	}
+	if kept < 20 || kept > 80 {
+		t.Errorf("expected about half of the traces to be kept, got %d", kept)
+	}
+	if perTrace["trace-error"] != 1 || perTrace[""] != 1 {
+		t.Errorf("expected errors and records without a trace to be kept, got: %v", perTrace)
+	}
+	if dropped := xlog.Stats().Dropped - before; dropped != uint64(3*(100-kept)) {
+		t.Errorf("expected %d dropped records, got %d", 3*(100-kept), dropped)
+	}
+}
+
+func TestSampleAdaptive(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithMiddleware(xlog.SampleAdaptive(&xlog.AdaptiveSamplerOptions{
+			MaxPerSecond: 100,
+			Window:       50 * time.Millisecond,
+		})),
+	)
+
+	ctx := context.Background()
+	burst := func() int {
+		buf.Reset()
+		for i := 0; i < 1000; i++ {
+			xlog.Info(ctx, "burst")
+		}
+		return strings.Count(buf.String(), "\n")
+	}
+
+	// The first window is measured before any sampling starts
+	if n := burst(); n != 1000 {
+		t.Fatalf("expected all records in the first window, got %d", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if n := burst(); n > 100 {
+		t.Errorf("expected sampling under load, got %d of 1000 records", n)
+	}
+	if rate := xlog.Stats().SampleRate; rate >= 0.1 {
+		t.Errorf("expected a reduced sample rate, got %g", rate)
+	}
+
+	buf.Reset()
+	xlog.Error(ctx, "kept")
+	if !strings.Contains(buf.String(), "kept") {
+		t.Errorf("expected errors to be kept under load, got: %s", buf.String())
+	}
+
+	// Two quiet windows: one to measure the burst, one to measure the quiet
+	for i := 0; i < 2; i++ {
+		time.Sleep(60 * time.Millisecond)
+		xlog.Info(ctx, "quiet")
+	}
+	if rate := xlog.Stats().SampleRate; rate != 1 {
+		t.Errorf("expected full logging after the load drops, got %g", rate)
+	}
+}
+
+func TestSampleByTraceCountsOnce(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.SampleByTrace(0, nil)(slog.NewJSONHandler(&buf, nil))
+	ctx := xlog.WithTraceID(context.Background(), "trace-1")
+
+	before := xlog.Stats().Dropped
+	for i := 0; i < 10; i++ {
+		// Callers such as Tee and the guard idiom ask Enabled more than once
+		_ = h.Enabled(ctx, slog.LevelInfo)
+		if h.Enabled(ctx, slog.LevelInfo) {
+			_ = h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "sampled out", 0))
+		}
+	}
+	if dropped := xlog.Stats().Dropped - before; dropped != 10 {
+		t.Errorf("expected 10 dropped records, got %d", dropped)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got: %s", buf.String())
+	}
+}
+
+func TestSampleAdaptiveCountsOnce(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlog.SampleAdaptive(&xlog.AdaptiveSamplerOptions{
+		MaxPerSecond: 1000,
+		Window:       time.Hour,
+	})(slog.NewJSONHandler(&buf, nil))
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		_ = h.Enabled(ctx, slog.LevelInfo)
+		if h.Enabled(ctx, slog.LevelInfo) {
+			_ = h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "kept", 0))
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 10 {
+		t.Errorf("expected 10 records, got %d", n)
+	}
+}
@@ -0,0 +1,58 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestPushScope(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf))
+	ctx := context.Background()
+
+	popJob := xlog.PushScope("job_id", "j-1")
+	popStep := xlog.PushScope("step", 2)
+	xlog.Info(ctx, "nested")
+	popStep()
+	xlog.Info(ctx, "outer")
+	popJob()
+	popJob() // already popped
+	xlog.Info(ctx, "none")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	pop := xlog.PushScope("job_id", "j-2")
+	go func() {
+		defer wg.Done()
+		xlog.Info(ctx, "other goroutine")
+	}()
+	wg.Wait()
+	pop()
+
+	// Popping a scope also pops those pushed after it
+	pop = xlog.PushScope("job_id", "j-3")
+	xlog.PushScope("step", 3)
+	pop()
+	xlog.Info(ctx, "unwound")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 records, got: %s", buf.String())
+	}
+	if !strings.Contains(lines[0], `"job_id":"j-1","step":2`) {
+		t.Errorf("expected both scopes, got: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"job_id":"j-1"`) || strings.Contains(lines[1], "step") {
+		t.Errorf("expected the outer scope only, got: %s", lines[1])
+	}
+	for _, line := range lines[2:] {
+		if strings.Contains(line, "job_id") || strings.Contains(line, "step") {
+			t.Errorf("expected no scope, got: %s", line)
+		}
+	}
+}
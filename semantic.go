@@ -0,0 +1,64 @@
+package xlog
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Group keys of the semantic attribute builders, so every service logs
+// requests, responses and queries under the same schema.
+const (
+	HTTPRequestKey  = "request"
+	HTTPResponseKey = "response"
+	DBKey           = "db"
+)
+
+// HTTPRequest returns a "request" group describing r: method, path, query,
+// host, proto, remote_addr, and, when present, the route pattern matched
+// by http.ServeMux, user_agent and content_length.
+func HTTPRequest(r *http.Request) slog.Attr {
+	attrs := make([]slog.Attr, 0, 9)
+	attrs = append(attrs,
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+	)
+	if r.Pattern != "" {
+		attrs = append(attrs, slog.String("route", r.Pattern))
+	}
+	if r.URL.RawQuery != "" {
+		attrs = append(attrs, slog.String("query", r.URL.RawQuery))
+	}
+	attrs = append(attrs,
+		slog.String("host", r.Host),
+		slog.String("proto", r.Proto),
+		slog.String("remote_addr", r.RemoteAddr),
+	)
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, slog.String("user_agent", ua))
+	}
+	if r.ContentLength > 0 {
+		attrs = append(attrs, slog.Int64("content_length", r.ContentLength))
+	}
+	return slog.Attr{Key: HTTPRequestKey, Value: slog.GroupValue(attrs...)}
+}
+
+// HTTPResponse returns a "response" group with the status code, the body
+// size in bytes, and the time taken to serve the request.
+func HTTPResponse(status int, size int64, dur time.Duration) slog.Attr {
+	return slog.Attr{Key: HTTPResponseKey, Value: slog.GroupValue(
+		slog.Int("status", status),
+		slog.Int64("size", size),
+		slog.Duration("duration", dur),
+	)}
+}
+
+// DB returns a "db" group with a query, the number of rows it returned
+// or affected, and its duration.
+func DB(query string, rows int64, dur time.Duration) slog.Attr {
+	return slog.Attr{Key: DBKey, Value: slog.GroupValue(
+		slog.String("statement", query),
+		slog.Int64("rows", rows),
+		slog.Duration("duration", dur),
+	)}
+}
@@ -0,0 +1,58 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestSemanticAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	req := httptest.NewRequest("GET", "/orders?limit=10", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	xlog.Info(context.Background(), "request",
+		xlog.HTTPRequest(req),
+		xlog.HTTPResponse(200, 512, 15*time.Millisecond),
+		xlog.DB("SELECT * FROM orders", 10, 3*time.Millisecond),
+	)
+
+	var rec struct {
+		Request struct {
+			Method, Path, Query, Host string
+			UserAgent                 string `json:"user_agent"`
+		}
+		Response struct {
+			Status   int
+			Size     int64
+			Duration time.Duration
+		}
+		DB struct {
+			Statement string
+			Rows      int64
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Request.Method != "GET" || rec.Request.Path != "/orders" || rec.Request.Query != "limit=10" ||
+		rec.Request.Host != "example.com" || rec.Request.UserAgent != "curl/8.0" {
+		t.Errorf("unexpected request group: %+v", rec.Request)
+	}
+	if rec.Response.Status != 200 || rec.Response.Size != 512 || rec.Response.Duration != 15*time.Millisecond {
+		t.Errorf("unexpected response group: %+v", rec.Response)
+	}
+	if rec.DB.Statement != "SELECT * FROM orders" || rec.DB.Rows != 10 {
+		t.Errorf("unexpected db group: %+v", rec.DB)
+	}
+}
@@ -0,0 +1,388 @@
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SentryHandlerOptions configures a SentryHandler.
+type SentryHandlerOptions struct {
+	// Level is the minimum level to report. Defaults to slog.LevelError.
+	Level slog.Leveler
+
+	// Environment and Release are attached to every event.
+	Environment string
+	Release     string
+
+	// BatchSize is the number of events queued before a flush. Defaults to 10.
+	BatchSize int
+
+	// FlushInterval is the maximum time an event waits in the queue. Defaults to 1s.
+	FlushInterval time.Duration
+
+	// MaxPerMinute limits the events reported per minute; the rest are
+	// dropped and counted in Stats.Dropped. Defaults to 60.
+	MaxPerMinute int
+
+	// Client sends the events. Defaults to a client with a 10s timeout.
+	Client *http.Client
+
+	// OnDeliveryError is called when an event cannot be delivered.
+	OnDeliveryError func(err error)
+}
+
+// SentryHandler reports records to Sentry as events, with their
+// attributes, the stack trace of the logging call, and the user and trace
+// IDs taken from the context. Events are sent in the background; call
+// Close to send the queued ones before exiting.
+//
+// It is meant to run beside the main handler, via Tee:
+//
+//	sentry, err := xlog.NewSentryHandler(dsn, nil)
+//	xlog.Init(xlog.WithMiddleware(xlog.Tee(sentry)))
+type SentryHandler struct {
+	endpoint string
+	auth     string
+	opts     SentryHandlerOptions
+	state    sinkState
+	queue    *sentryQueue
+}
+
+// sentryQueue is shared between a SentryHandler and its derived handlers.
+type sentryQueue struct {
+	mu          sync.Mutex
+	envelopes   [][]byte
+	windowStart time.Time
+	windowCount int
+	retryAfter  time.Time
+	flushMu     sync.Mutex
+	kick        chan struct{}
+	done        chan struct{}
+	once        sync.Once
+	wg          sync.WaitGroup
+}
+
+// NewSentryHandler creates a SentryHandler reporting to the project
+// identified by dsn, such as "https://key@o1.ingest.sentry.io/42".
+func NewSentryHandler(dsn string, opts *SentryHandlerOptions) (*SentryHandler, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("xlog: invalid sentry DSN: %w", err)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndexByte(path, '/')
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || i < 0 || i == len(path)-1 {
+		return nil, errors.New("xlog: invalid sentry DSN: expected scheme://key@host/project")
+	}
+	key, prefix, project := u.User.Username(), path[:i], path[i+1:]
+
+	var o SentryHandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelError
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 10
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = time.Second
+	}
+	if o.MaxPerMinute <= 0 {
+		o.MaxPerMinute = 60
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	h := &SentryHandler{
+		endpoint: u.Scheme + "://" + u.Host + prefix + "/api/" + project + "/envelope/",
+		auth:     "Sentry sentry_version=7, sentry_client=xlog, sentry_key=" + key,
+		opts:     o,
+		queue:    &sentryQueue{kick: make(chan struct{}, 1), done: make(chan struct{})},
+	}
+
+	h.queue.wg.Add(1)
+	go h.flushLoop()
+
+	return h, nil
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SentryHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+// Handle converts the record to a Sentry event and queues it, unless the
+// rate limit has been reached.
+func (h *SentryHandler) Handle(_ context.Context, r slog.Record) error {
+	q := h.queue
+	now := time.Now()
+	q.mu.Lock()
+	if now.Sub(q.windowStart) >= time.Minute {
+		q.windowStart, q.windowCount = now, 0
+	}
+	limited := q.windowCount >= h.opts.MaxPerMinute || now.Before(q.retryAfter)
+	if !limited {
+		q.windowCount++
+	}
+	q.mu.Unlock()
+	if limited {
+		recordDropped()
+		return nil
+	}
+
+	envelope, err := h.envelope(r)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	q.envelopes = append(q.envelopes, envelope)
+	full := len(q.envelopes) >= h.opts.BatchSize
+	q.mu.Unlock()
+
+	if full {
+		// Send from the flusher rather than blocking the logging call
+		select {
+		case q.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *SentryHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withAttrs(attrs)
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *SentryHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.state = h.state.withGroup(name)
+	return &h2
+}
+
+// Flush sends all queued events.
+func (h *SentryHandler) Flush(ctx context.Context) error {
+	q := h.queue
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	q.mu.Lock()
+	envelopes := q.envelopes
+	q.envelopes = nil
+	q.mu.Unlock()
+
+	var errs []error
+	for _, envelope := range envelopes {
+		if err := h.send(ctx, envelope); err != nil {
+			if h.opts.OnDeliveryError != nil {
+				h.opts.OnDeliveryError(err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops the background flusher and sends any queued events.
+func (h *SentryHandler) Close() error {
+	h.queue.once.Do(func() {
+		close(h.queue.done)
+	})
+	h.queue.wg.Wait()
+	return h.Flush(context.Background())
+}
+
+func (h *SentryHandler) flushLoop() {
+	defer h.queue.wg.Done()
+
+	ticker := time.NewTicker(h.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = h.Flush(context.Background())
+		case <-h.queue.kick:
+			_ = h.Flush(context.Background())
+		case <-h.queue.done:
+			return
+		}
+	}
+}
+
+func (h *SentryHandler) send(ctx context.Context, envelope []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(envelope))
+	if err != nil {
+		return fmt.Errorf("xlog: sentry: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", h.auth)
+
+	resp, err := h.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("xlog: sentry: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		// Respect the server's rate limit, as Sentry SDKs must
+		secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil || secs <= 0 {
+			secs = 60
+		}
+		h.queue.mu.Lock()
+		h.queue.retryAfter = time.Now().Add(time.Duration(secs) * time.Second)
+		h.queue.mu.Unlock()
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("xlog: sentry: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// sentryFrame is a stack frame in the Sentry event format.
+type sentryFrame struct {
+	Function string `json:"function,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+// envelope encodes r as a Sentry envelope holding a single event.
+func (h *SentryHandler) envelope(r slog.Record) ([]byte, error) {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	eventID := hex.EncodeToString(id[:])
+
+	fields := h.state.fields(r)
+	tags := map[string]string{}
+	for _, key := range []ContextKey{TraceIDKey, RequestIDKey, SpanIDKey} {
+		if v, ok := fields[string(key)]; ok {
+			tags[string(key)] = fmt.Sprint(v)
+			delete(fields, string(key))
+		}
+	}
+	event := map[string]any{
+		"event_id":  eventID,
+		"timestamp": r.Time.UTC().Format(time.RFC3339Nano),
+		"level":     sentryLevel(r.Level),
+		"logger":    "xlog",
+		"platform":  "go",
+		"message":   map[string]string{"formatted": r.Message},
+		"extra":     fields,
+		"tags":      tags,
+	}
+	if v, ok := fields[string(UserIDKey)]; ok {
+		event["user"] = map[string]string{"id": fmt.Sprint(v)}
+		delete(fields, string(UserIDKey))
+	}
+	if h.opts.Environment != "" {
+		event["environment"] = h.opts.Environment
+	}
+	if h.opts.Release != "" {
+		event["release"] = h.opts.Release
+	}
+
+	stacktrace := map[string]any{"frames": stackFrames(r.PC)}
+	var err error
+	r.Attrs(func(a slog.Attr) bool {
+		err, _ = a.Value.Resolve().Any().(error)
+		return err == nil
+	})
+	if err != nil {
+		event["exception"] = map[string]any{"values": []any{map[string]any{
+			"type":       fmt.Sprintf("%T", err),
+			"value":      err.Error(),
+			"stacktrace": stacktrace,
+		}}}
+	} else {
+		event["threads"] = map[string]any{"values": []any{map[string]any{
+			"current":    true,
+			"stacktrace": stacktrace,
+		}}}
+	}
+
+	payload, merr := json.Marshal(event)
+	if merr != nil {
+		return nil, fmt.Errorf("xlog: sentry encode: %w", merr)
+	}
+	buf := fmt.Appendf(nil, "{\"event_id\":%q}\n{\"type\":\"event\",\"length\":%d}\n", eventID, len(payload))
+	buf = append(buf, payload...)
+	return append(buf, '\n'), nil
+}
+
+// stackFrames returns the goroutine's stack from the frame at pc outward,
+// oldest first as Sentry expects. Without a matching frame, it returns
+// just the frame at pc.
+func stackFrames(pc uintptr) []sentryFrame {
+	if pc == 0 {
+		return nil
+	}
+	var pcs [64]uintptr
+	n := runtime.Callers(1, pcs[:])
+	stack := []uintptr{pc}
+	for i, p := range pcs[:n] {
+		if p == pc {
+			stack = pcs[i:n]
+			break
+		}
+	}
+
+	var frames []sentryFrame
+	it := runtime.CallersFrames(stack)
+	for {
+		f, more := it.Next()
+		if f.Function != "" && !strings.HasPrefix(f.Function, "runtime.") {
+			frames = append(frames, sentryFrame{
+				Function: f.Function,
+				AbsPath:  f.File,
+				Filename: f.File[strings.LastIndexByte(f.File, '/')+1:],
+				Lineno:   f.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+func sentryLevel(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError+4:
+		return "fatal"
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warning"
+	case level >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
@@ -0,0 +1,103 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestSentryHandler(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []map[string]any
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/42/envelope/" || !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=public") {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		lines := bytes.Split(bytes.TrimSpace(body), []byte("\n"))
+		var event map[string]any
+		if err := json.Unmarshal(lines[len(lines)-1], &event); err != nil {
+			t.Errorf("invalid event %q: %v", body, err)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "://", "://public@", 1) + "/42"
+	sentry, err := xlog.NewSentryHandler(dsn, &xlog.SentryHandlerOptions{
+		Release:      "v1.2.3",
+		MaxPerMinute: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&out),
+		xlog.WithMiddleware(xlog.Tee(sentry)),
+	)
+
+	ctx := xlog.WithTraceID(xlog.WithUserID(context.Background(), "alice"), "t-1")
+	xlog.Info(ctx, "not reported")
+	xlog.Error(ctx, "payment failed", "order_id", "o-1", "error", errors.New("card declined"))
+	xlog.Error(ctx, "second")
+	xlog.Error(ctx, "rate limited")
+	if err := sentry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(out.String(), "\n"); n != 4 {
+		t.Errorf("expected every record in the main output, got %d", n)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events after rate limiting, got %d", len(events))
+	}
+	e := events[0]
+	if e["level"] != "error" || e["release"] != "v1.2.3" {
+		t.Errorf("unexpected event: %v", e)
+	}
+	if msg := e["message"].(map[string]any)["formatted"]; msg != "payment failed" {
+		t.Errorf("expected message, got %v", msg)
+	}
+	if user := e["user"].(map[string]any)["id"]; user != "alice" {
+		t.Errorf("expected user alice, got %v", user)
+	}
+	if trace := e["tags"].(map[string]any)["trace_id"]; trace != "t-1" {
+		t.Errorf("expected trace tag, got %v", trace)
+	}
+	if order := e["extra"].(map[string]any)["order_id"]; order != "o-1" {
+		t.Errorf("expected order_id in extra, got %v", order)
+	}
+	exc := e["exception"].(map[string]any)["values"].([]any)[0].(map[string]any)
+	if exc["value"] != "card declined" {
+		t.Errorf("unexpected exception: %v", exc)
+	}
+	frames := exc["stacktrace"].(map[string]any)["frames"].([]any)
+	top := frames[len(frames)-1].(map[string]any)
+	if top["function"] != "github.com/taro33333/xlog_test.TestSentryHandler" {
+		t.Errorf("expected the logging call as the top frame, got %v", top)
+	}
+}
+
+func TestSentryHandlerInvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.io/42", "https://key@sentry.io/"} {
+		if _, err := xlog.NewSentryHandler(dsn, nil); err == nil {
+			t.Errorf("expected error for DSN %q", dsn)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// SeqKey is the attribute holding the sequence number from WithSequence.
+const SeqKey = "seq"
+
+// seq is the last sequence number, shared by every logger of the process.
+var seq atomic.Uint64
+
+// WithSequence stamps every record with a "seq" attribute numbering the
+// records of the process from 1, so their order can be reconstructed when
+// timestamps are equal, such as across buffered writers. Combined with
+// WithInstanceID, it orders the records of each replica.
+func WithSequence(enabled bool) Option {
+	return func(c *config) {
+		c.sequence = enabled
+	}
+}
+
+// seqHandler adds the next sequence number to each record. Attributes and
+// groups are passed to the wrapped handler until the first group is opened;
+// from then on they are held back in state, so the number stays at the top
+// level of the record like the base attributes.
+type seqHandler struct {
+	handler slog.Handler
+	state   sinkState
+}
+
+func (h *seqHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *seqHandler) Handle(ctx context.Context, r slog.Record) error {
+	n := slog.Uint64(SeqKey, seq.Add(1))
+	if len(h.state.goas) == 0 {
+		r.AddAttrs(n)
+		return h.handler.Handle(ctx, r)
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	// Nest the record attributes under the held-back groups, innermost first
+	for i := len(h.state.goas) - 1; i >= 0; i-- {
+		goa := h.state.goas[i]
+		if goa.group != "" {
+			attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			continue
+		}
+		attrs = append(append(make([]slog.Attr, 0, len(goa.attrs)+len(attrs)), goa.attrs...), attrs...)
+	}
+
+	r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r2.AddAttrs(attrs...)
+	r2.AddAttrs(n)
+	return h.handler.Handle(ctx, r2)
+}
+
+func (h *seqHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.state.goas) == 0 {
+		return &seqHandler{handler: h.handler.WithAttrs(attrs)}
+	}
+	return &seqHandler{handler: h.handler, state: h.state.withAttrs(attrs)}
+}
+
+func (h *seqHandler) WithGroup(name string) slog.Handler {
+	return &seqHandler{handler: h.handler, state: h.state.withGroup(name)}
+}
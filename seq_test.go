@@ -0,0 +1,58 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithSequence(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSequence(true),
+	)
+	ctx := context.Background()
+	xlog.Info(ctx, "first")
+	xlog.Named("db").Info(ctx, "second")
+
+	dec := json.NewDecoder(&buf)
+	var prev float64
+	for i := 0; i < 2; i++ {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		n, ok := rec[xlog.SeqKey].(float64)
+		if !ok || n <= prev {
+			t.Fatalf("expected increasing seq after %v, got: %v", prev, rec)
+		}
+		prev = n
+	}
+}
+
+func TestWithSequenceGroup(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSequence(true),
+	)
+	xlog.Default().WithGroup("req").With("id", 1).Info(context.Background(), "grouped", "path", "/")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rec[xlog.SeqKey].(float64); !ok {
+		t.Fatalf("expected top-level seq, got: %v", rec)
+	}
+	req, _ := rec["req"].(map[string]any)
+	if req["id"] != 1.0 || req["path"] != "/" || req[xlog.SeqKey] != nil {
+		t.Fatalf("expected grouped attributes without seq, got: %v", rec)
+	}
+}
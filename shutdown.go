@@ -0,0 +1,97 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	shutdownHooks   []func(context.Context) error
+	shutdownHooksMu sync.Mutex
+)
+
+// OnShutdown registers fn to run during Shutdown, after the default
+// logger's outputs are closed. Use it for handlers built outside Init,
+// such as a KafkaHandler or FluentHandler.
+func OnShutdown(fn func(context.Context) error) {
+	shutdownHooksMu.Lock()
+	defer shutdownHooksMu.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+// Flush writes any records buffered by the default logger's outputs.
+// It returns ctx.Err() if ctx is done before flushing completes.
+func Flush(ctx context.Context) error {
+	return Default().Flush(ctx)
+}
+
+// Flush writes any records buffered by the logger's outputs, such as
+// those of a logger from New.
+func (l *Logger) Flush(ctx context.Context) error {
+	sinks := l.sinks
+	return runWithContext(ctx, func() error {
+		return flushSinks(ctx, sinks)
+	})
+}
+
+// Shutdown flushes and closes the default logger's outputs and runs the
+// hooks registered with OnShutdown. Call it before the process exits so
+// no records are lost; standard output and error are never closed.
+func Shutdown(ctx context.Context) error {
+	sinks := Default().sinks
+
+	shutdownHooksMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownHooksMu.Unlock()
+
+	return runWithContext(ctx, func() error {
+		errs := []error{flushSinks(ctx, sinks)}
+		for _, s := range sinks {
+			c, ok := s.(io.Closer)
+			if !ok || s == os.Stdout || s == os.Stderr {
+				continue
+			}
+			errs = append(errs, c.Close())
+		}
+		for _, fn := range hooks {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	})
+}
+
+func flushSinks(ctx context.Context, sinks []any) error {
+	var errs []error
+	for _, s := range sinks {
+		switch f := s.(type) {
+		case interface{ Flush(context.Context) error }:
+			errs = append(errs, f.Flush(ctx))
+		case interface{ Flush() error }:
+			errs = append(errs, f.Flush())
+		case interface{ Sync() error }:
+			// *os.File; syncing a terminal or pipe fails harmlessly
+			if s != os.Stdout && s != os.Stderr {
+				errs = append(errs, f.Sync())
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runWithContext runs fn, returning early with ctx.Err() if ctx is done first.
+func runWithContext(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
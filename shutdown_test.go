@@ -0,0 +1,63 @@
+package xlog_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+// closingWriter records whether Close was called.
+type closingWriter struct {
+	syncBuffer
+	closed bool
+}
+
+func (w *closingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestFlushAndShutdown(t *testing.T) {
+	out := &closingWriter{}
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(out),
+		xlog.WithBuffering(&xlog.BufferedWriterOptions{FlushInterval: time.Hour}),
+	)
+
+	ctx := context.Background()
+	xlog.Info(ctx, "buffered")
+	if out.String() != "" {
+		t.Fatalf("expected record to be buffered, got: %s", out.String())
+	}
+
+	if err := xlog.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "buffered") {
+		t.Errorf("expected record after Flush, got: %s", out.String())
+	}
+
+	var hookRan bool
+	xlog.OnShutdown(func(context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	xlog.Info(ctx, "at exit")
+	if err := xlog.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "at exit") {
+		t.Errorf("expected record after Shutdown, got: %s", out.String())
+	}
+	if !out.closed {
+		t.Error("expected output to be closed")
+	}
+	if !hookRan {
+		t.Error("expected shutdown hook to run")
+	}
+}
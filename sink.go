@@ -0,0 +1,129 @@
+package xlog
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// groupOrAttrs holds either a group name or a list of attributes added via
+// WithGroup/WithAttrs, in the order they were applied.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// sinkState tracks WithAttrs/WithGroup calls for handlers that ship records
+// to external systems as nested maps rather than formatted text.
+type sinkState struct {
+	goas []groupOrAttrs
+}
+
+func (s sinkState) withAttrs(attrs []slog.Attr) sinkState {
+	if len(attrs) == 0 {
+		return s
+	}
+	goas := make([]groupOrAttrs, len(s.goas), len(s.goas)+1)
+	copy(goas, s.goas)
+	return sinkState{goas: append(goas, groupOrAttrs{attrs: attrs})}
+}
+
+func (s sinkState) withGroup(name string) sinkState {
+	if name == "" {
+		return s
+	}
+	goas := make([]groupOrAttrs, len(s.goas), len(s.goas)+1)
+	copy(goas, s.goas)
+	return sinkState{goas: append(goas, groupOrAttrs{group: name})}
+}
+
+// fields returns the pre-set and record attributes as a nested map.
+// Groups that end up empty are omitted, matching slog's built-in handlers.
+func (s sinkState) fields(r slog.Record) map[string]any {
+	root := make(map[string]any, len(s.goas)+r.NumAttrs())
+	cur := root
+	for _, goa := range s.goas {
+		if goa.group != "" {
+			m := make(map[string]any)
+			cur[goa.group] = m
+			cur = m
+			continue
+		}
+		for _, a := range goa.attrs {
+			addField(cur, a)
+		}
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addField(cur, a)
+		return true
+	})
+	pruneEmpty(root)
+	return root
+}
+
+func addField(m map[string]any, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+		// Inline groups with an empty key, as slog does
+		dst := m
+		if a.Key != "" {
+			dst = make(map[string]any, len(attrs))
+			m[a.Key] = dst
+		}
+		for _, ga := range attrs {
+			addField(dst, ga)
+		}
+		return
+	}
+	m[a.Key] = valueToAny(a.Value)
+}
+
+func pruneEmpty(m map[string]any) bool {
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok && pruneEmpty(sub) {
+			delete(m, k)
+		}
+	}
+	return len(m) == 0
+}
+
+// valueToAny converts a resolved slog.Value into a plain Go value suitable
+// for generic encoders.
+func valueToAny(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return int64(v.Duration())
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	default:
+		switch x := v.Any().(type) {
+		case error:
+			return x.Error()
+		case encoding.TextMarshaler:
+			if b, err := x.MarshalText(); err == nil {
+				return string(b)
+			}
+		case fmt.Stringer:
+			return x.String()
+		}
+		return v.Any()
+	}
+}
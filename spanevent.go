@@ -0,0 +1,71 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SpanEventFunc adds a record to the tracing span active in ctx, if any.
+// The record's attributes are flat, with groups joined by dots, and
+// include those added with With.
+type SpanEventFunc func(ctx context.Context, r slog.Record)
+
+// WithSpanEvents passes every record written by the default logger to fn
+// as well, so traces carry the log narrative without logging twice. xlog
+// does not depend on OpenTelemetry; with it, fn would be:
+//
+//	func(ctx context.Context, r slog.Record) {
+//		span := trace.SpanFromContext(ctx)
+//		if !span.IsRecording() {
+//			return
+//		}
+//		attrs := []attribute.KeyValue{attribute.String("level", r.Level.String())}
+//		r.Attrs(func(a slog.Attr) bool {
+//			attrs = append(attrs, attribute.String(a.Key, a.Value.String()))
+//			return true
+//		})
+//		span.AddEvent(r.Message, trace.WithTimestamp(r.Time), trace.WithAttributes(attrs...))
+//	}
+//
+// fn runs before any middleware from WithMiddleware, so sampled out
+// records still reach the span.
+func WithSpanEvents(fn SpanEventFunc) Option {
+	return func(c *config) {
+		c.spanEvents = fn
+	}
+}
+
+// spanEventHandler passes flattened records to a SpanEventFunc; it runs
+// beneath a FlattenHandler, which never calls WithGroup.
+type spanEventHandler struct {
+	fn    SpanEventFunc
+	attrs []slog.Attr
+}
+
+func (h *spanEventHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *spanEventHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(h.attrs) > 0 {
+		r2 := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		r2.AddAttrs(h.attrs...)
+		r.Attrs(func(a slog.Attr) bool {
+			r2.AddAttrs(a)
+			return true
+		})
+		r = r2
+	}
+	h.fn(ctx, r)
+	return nil
+}
+
+func (h *spanEventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return &h2
+}
+
+func (h *spanEventHandler) WithGroup(string) slog.Handler {
+	return h
+}
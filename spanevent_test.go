@@ -0,0 +1,41 @@
+package xlog_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithSpanEvents(t *testing.T) {
+	type event struct {
+		msg   string
+		attrs map[string]string
+	}
+	var events []event
+	_ = xlog.Init(
+		xlog.WithOutput(io.Discard),
+		xlog.WithSpanEvents(func(ctx context.Context, r slog.Record) {
+			e := event{msg: r.Message, attrs: map[string]string{}}
+			r.Attrs(func(a slog.Attr) bool {
+				e.attrs[a.Key] = a.Value.String()
+				return true
+			})
+			events = append(events, e)
+		}),
+	)
+
+	ctx := xlog.WithTraceID(context.Background(), "t-1")
+	xlog.With("order", 7).Info(ctx, "charged", slog.Group("card", "brand", "visa"))
+	xlog.Debug(ctx, "below the level")
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got: %v", events)
+	}
+	e := events[0]
+	if e.msg != "charged" || e.attrs["order"] != "7" || e.attrs["card.brand"] != "visa" || e.attrs["trace_id"] != "t-1" {
+		t.Errorf("unexpected event: %v", e)
+	}
+}
@@ -0,0 +1,181 @@
+package xlog
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Statistics is a snapshot of the logger's own health counters.
+type Statistics struct {
+	// Records is the number of records successfully written, by level.
+	Records LevelCounts
+
+	// Dropped is the number of records discarded before reaching an
+	// output, for example by sampling or a full buffer.
+	Dropped uint64
+
+	// Errors is the number of records whose handler returned an error.
+	Errors uint64
+
+	// LastError is the message of the most recent handler error, if any.
+	LastError string
+
+	// SampleRate is the fraction of records currently kept by
+	// SampleAdaptive, or 1 when it isn't sampling.
+	SampleRate float64
+
+	// Buffered is the number of bytes of the default logger's output
+	// waiting to be written by the background flusher of WithBuffering or
+	// WithSharding.
+	Buffered int
+}
+
+// LevelCounts holds a counter per standard level. Custom levels are
+// counted under the nearest standard level below them.
+type LevelCounts struct {
+	Debug uint64
+	Info  uint64
+	Warn  uint64
+	Error uint64
+}
+
+// Total returns the sum of all levels.
+func (c LevelCounts) Total() uint64 {
+	return c.Debug + c.Info + c.Warn + c.Error
+}
+
+var stats struct {
+	debug   atomic.Uint64
+	info    atomic.Uint64
+	warn    atomic.Uint64
+	error   atomic.Uint64
+	dropped atomic.Uint64
+	errors  atomic.Uint64
+	lastErr atomic.Pointer[string]
+	// sampleRate holds float64 bits; zero means no adaptive sampler has run
+	sampleRate atomic.Uint64
+}
+
+// Stats returns a snapshot of the counters accumulated since process start.
+func Stats() Statistics {
+	return Statistics{
+		Records: LevelCounts{
+			Debug: stats.debug.Load(),
+			Info:  stats.info.Load(),
+			Warn:  stats.warn.Load(),
+			Error: stats.error.Load(),
+		},
+		Dropped:    stats.dropped.Load(),
+		Errors:     stats.errors.Load(),
+		LastError:  lastError(),
+		SampleRate: sampleRate(),
+		Buffered:   buffered(Default().sinks),
+	}
+}
+
+// buffered returns the number of bytes waiting in the buffers of sinks.
+func buffered(sinks []any) int {
+	n := 0
+	for _, s := range sinks {
+		if b, ok := s.(interface{ Buffered() int }); ok {
+			n += b.Buffered()
+		}
+	}
+	return n
+}
+
+func sampleRate() float64 {
+	if bits := stats.sampleRate.Load(); bits != 0 {
+		return math.Float64frombits(bits)
+	}
+	return 1
+}
+
+func setSampleRate(rate float64) {
+	stats.sampleRate.Store(math.Float64bits(rate))
+}
+
+func lastError() string {
+	if p := stats.lastErr.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+func recordWritten(level slog.Level) {
+	switch {
+	case level >= slog.LevelError:
+		stats.error.Add(1)
+	case level >= slog.LevelWarn:
+		stats.warn.Add(1)
+	case level >= slog.LevelInfo:
+		stats.info.Add(1)
+	default:
+		stats.debug.Add(1)
+	}
+}
+
+func recordDropped() {
+	stats.dropped.Add(1)
+}
+
+var expvarOnce sync.Once
+
+// publishExpvar publishes the statistics and current level under the
+// "xlog" expvar key. expvar panics on duplicate names, so it runs once.
+func publishExpvar() {
+	expvarOnce.Do(func() {
+		expvar.Publish("xlog", expvar.Func(func() any {
+			s := Stats()
+			return map[string]any{
+				"level": Default().Level().String(),
+				"records": map[string]uint64{
+					"debug": s.Records.Debug,
+					"info":  s.Records.Info,
+					"warn":  s.Records.Warn,
+					"error": s.Records.Error,
+				},
+				"dropped":     s.Dropped,
+				"errors":      s.Errors,
+				"last_error":  s.LastError,
+				"sample_rate": s.SampleRate,
+				"buffered":    s.Buffered,
+			}
+		}))
+	})
+}
+
+// statsHandler counts written and failed records for the handler it wraps.
+// Init installs it outermost so records logged through slog.Default and
+// the standard log package are counted too.
+type statsHandler struct {
+	handler slog.Handler
+}
+
+func (h *statsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *statsHandler) Handle(ctx context.Context, r slog.Record) error {
+	err := h.handler.Handle(ctx, r)
+	if err != nil {
+		msg := err.Error()
+		stats.errors.Add(1)
+		stats.lastErr.Store(&msg)
+	} else {
+		recordWritten(r.Level)
+	}
+	return err
+}
+
+func (h *statsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &statsHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *statsHandler) WithGroup(name string) slog.Handler {
+	return &statsHandler{handler: h.handler.WithGroup(name)}
+}
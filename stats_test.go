@@ -0,0 +1,82 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestStats(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithLevel(slog.LevelDebug),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	before := xlog.Stats()
+
+	ctx := context.Background()
+	xlog.Debug(ctx, "debug")
+	xlog.Info(ctx, "info")
+	xlog.Warn(ctx, "warn")
+	slog.Error("via slog default")
+
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(failingWriter{}),
+	)
+	xlog.Error(ctx, "fails")
+
+	after := xlog.Stats()
+	if got := after.Records.Debug - before.Records.Debug; got != 1 {
+		t.Errorf("expected 1 debug record, got %d", got)
+	}
+	if got := after.Records.Info - before.Records.Info; got != 1 {
+		t.Errorf("expected 1 info record, got %d", got)
+	}
+	if got := after.Records.Warn - before.Records.Warn; got != 1 {
+		t.Errorf("expected 1 warn record, got %d", got)
+	}
+	if got := after.Records.Error - before.Records.Error; got != 1 {
+		t.Errorf("expected 1 error record, got %d", got)
+	}
+	if got := after.Errors - before.Errors; got != 1 {
+		t.Errorf("expected 1 write error, got %d", got)
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithLevel(slog.LevelWarn),
+		xlog.WithOutput(&buf),
+		xlog.WithExpvar(true),
+	)
+
+	v := expvar.Get("xlog")
+	if v == nil {
+		t.Fatal("expected xlog expvar to be published")
+	}
+
+	var got struct {
+		Level   string            `json:"level"`
+		Records map[string]uint64 `json:"records"`
+	}
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("invalid expvar JSON: %v", err)
+	}
+	if got.Level != "WARN" {
+		t.Errorf("expected level WARN, got %s", got.Level)
+	}
+	if _, ok := got.Records["error"]; !ok {
+		t.Errorf("expected per-level records, got: %v", got.Records)
+	}
+}
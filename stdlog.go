@@ -0,0 +1,84 @@
+package xlog
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// PrefixKey is the attribute holding the prefix of the standard logger,
+// set with log.SetPrefix, in records redirected from it.
+const PrefixKey = "prefix"
+
+// StdLogOptions configures how Init redirects the standard log package.
+type StdLogOptions struct {
+	// Level is the level of messages without a detected level. Defaults
+	// to slog.LevelInfo.
+	Level slog.Level
+
+	// DetectLevel reads the level from prefixes of the message such as
+	// "ERROR:" or "[warn]", as NewLevelWriter does.
+	DetectLevel bool
+}
+
+// WithStdLog configures the redirection of the standard log package to
+// the default logger. By default every message is logged at INFO; nil
+// leaves the standard logger untouched:
+//
+//	xlog.Init(xlog.WithStdLog(&xlog.StdLogOptions{Level: slog.LevelWarn, DetectLevel: true}))
+func WithStdLog(opts *StdLogOptions) Option {
+	return func(c *config) {
+		c.stdLog = opts
+	}
+}
+
+// WithSkipStdlogRedirect leaves the standard logger untouched, for
+// applications that manage its output themselves. It is WithStdLog(nil).
+func WithSkipStdlogRedirect() Option {
+	return WithStdLog(nil)
+}
+
+// WithSkipSlogDefault stops Init from installing the logger as
+// slog.Default, for applications that manage it themselves. xlog's own
+// default logger is still set.
+func WithSkipSlogDefault() Option {
+	return func(c *config) {
+		c.skipSlog = true
+	}
+}
+
+// stdLogWriter adapts a Logger to io.Writer for the standard logger. Each
+// write is one message.
+type stdLogWriter struct {
+	logger *Logger
+	opts   StdLogOptions
+}
+
+func (w *stdLogWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	msg := strings.TrimSuffix(string(p), "\n")
+
+	var attrs []slog.Attr
+	if prefix := log.Prefix(); prefix != "" && strings.HasPrefix(msg, prefix) {
+		msg = msg[len(prefix):]
+		attrs = append(attrs, slog.String(PrefixKey, strings.TrimSpace(prefix)))
+	}
+	level := w.opts.Level
+	if w.opts.DetectLevel {
+		if l, rest, ok := detectLevel(msg); ok {
+			level, msg = l, rest
+		}
+	}
+
+	h := w.logger.Handler()
+	if !h.Enabled(ctx, level) {
+		return len(p), nil
+	}
+	// Skip Write, log.(*Logger).output and the log function
+	r := slog.NewRecord(time.Now(), level, msg, callerPC(4))
+	r.AddAttrs(attrs...)
+	_ = h.Handle(ctx, r)
+	return len(p), nil
+}
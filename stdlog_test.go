@@ -0,0 +1,99 @@
+package xlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestStdLogRedirect(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+	defer log.SetPrefix("")
+	defer log.SetFlags(log.LstdFlags)
+
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithStdLog(&xlog.StdLogOptions{Level: slog.LevelWarn, DetectLevel: true}),
+	)
+	log.SetPrefix("legacy: ")
+	log.Println("plain")
+	log.Println("ERROR: failed")
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []struct{ level, msg string }{{"WARN", "plain"}, {"ERROR", "failed"}} {
+		var rec struct {
+			Level  string `json:"level"`
+			Msg    string `json:"msg"`
+			Prefix string `json:"prefix"`
+			Source struct {
+				File string `json:"file"`
+			} `json:"source"`
+		}
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Level != want.level || rec.Msg != want.msg || rec.Prefix != "legacy:" {
+			t.Errorf("expected %s %q with prefix, got: %+v", want.level, want.msg, rec)
+		}
+		if filepath.Base(rec.Source.File) != "stdlog_test.go" {
+			t.Errorf("expected the caller of log as source, got: %s", rec.Source.File)
+		}
+	}
+}
+
+func TestStdLogDisabled(t *testing.T) {
+	log.SetOutput(io.Discard)
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	log.SetPrefix("app: ")
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+	defer log.SetPrefix("")
+
+	_ = xlog.Init(xlog.WithOutput(io.Discard), xlog.WithStdLog(nil))
+	if log.Writer() != io.Discard || log.Flags() != log.LstdFlags|log.Lshortfile || log.Prefix() != "app: " {
+		t.Error("expected the standard logger to be left untouched")
+	}
+}
+
+func TestSkipSlogDefault(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+	defer log.SetOutput(os.Stderr)
+
+	// slog.SetDefault redirects the standard logger, so set its output after
+	custom := slog.New(slog.DiscardHandler)
+	slog.SetDefault(custom)
+	log.SetOutput(io.Discard)
+
+	var buf bytes.Buffer
+	logger := xlog.Init(xlog.WithOutput(&buf), xlog.WithSkipSlogDefault(), xlog.WithSkipStdlogRedirect())
+	if slog.Default() != custom {
+		t.Error("expected slog.Default to be left untouched")
+	}
+	if log.Writer() != io.Discard {
+		t.Error("expected the standard logger to be left untouched")
+	}
+	if xlog.Default() != logger {
+		t.Error("expected the xlog default to be set")
+	}
+}
+
+func TestSkipStdlogRedirectKeepsFlags(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+	defer log.SetFlags(log.LstdFlags)
+	log.SetFlags(log.LstdFlags)
+
+	_ = xlog.Init(xlog.WithOutput(io.Discard), xlog.WithSkipStdlogRedirect())
+	if log.Flags() != log.LstdFlags {
+		t.Errorf("expected the standard logger's flags to be kept, got %d", log.Flags())
+	}
+}
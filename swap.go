@@ -0,0 +1,81 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// defaultCore holds the handler of the default logger. Init swaps it, so
+// loggers derived from an earlier default logger, such as package-level
+// Named loggers created before Init, follow the new configuration.
+var defaultCore swapCore
+
+// defaultFlight reports whether the handler of defaultCore holds records
+// for StartFlightRecorder.
+var defaultFlight atomic.Bool
+
+// swapCore holds a handler that can be replaced while in use.
+type swapCore struct {
+	current atomic.Pointer[swapState]
+}
+
+// swapState is one handler held by a swapCore.
+type swapState struct {
+	handler slog.Handler
+}
+
+func (c *swapCore) store(h slog.Handler) {
+	c.current.Store(&swapState{handler: h})
+}
+
+// swapHandler forwards records to the current handler of its core, with
+// the attributes and groups added to it replayed on that handler.
+type swapHandler struct {
+	core  *swapCore
+	state sinkState
+	// cached is the current handler with state applied, rebuilt when the
+	// core is swapped
+	cached atomic.Pointer[swapCached]
+}
+
+type swapCached struct {
+	from    *swapState
+	handler slog.Handler
+}
+
+func (h *swapHandler) resolve() slog.Handler {
+	cur := h.core.current.Load()
+	if len(h.state.goas) == 0 {
+		return cur.handler
+	}
+	if c := h.cached.Load(); c != nil && c.from == cur {
+		return c.handler
+	}
+	handler := cur.handler
+	for _, goa := range h.state.goas {
+		if goa.group != "" {
+			handler = handler.WithGroup(goa.group)
+		} else {
+			handler = handler.WithAttrs(goa.attrs)
+		}
+	}
+	h.cached.Store(&swapCached{from: cur, handler: handler})
+	return handler
+}
+
+func (h *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.resolve().Enabled(ctx, level)
+}
+
+func (h *swapHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.resolve().Handle(ctx, r)
+}
+
+func (h *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &swapHandler{core: h.core, state: h.state.withAttrs(attrs)}
+}
+
+func (h *swapHandler) WithGroup(name string) slog.Handler {
+	return &swapHandler{core: h.core, state: h.state.withGroup(name)}
+}
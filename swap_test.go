@@ -0,0 +1,49 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestReinitSwapsDerivedLoggers(t *testing.T) {
+	var first, second bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&first))
+	db := xlog.Named("db").With("pool", "main").WithGroup("query")
+
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&second))
+	db.Info(context.Background(), "slow", "table", "users")
+
+	if first.Len() != 0 {
+		t.Errorf("expected nothing in the first output, got: %s", first.String())
+	}
+	out := second.String()
+	for _, want := range []string{`"logger":"db"`, `"pool":"main"`, `"query":{"table":"users"}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in the new output, got: %s", want, out)
+		}
+	}
+}
+
+func TestConcurrentInit(t *testing.T) {
+	ctx := context.Background()
+	logger := xlog.Named("worker")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				logger.Info(ctx, "tick")
+			}
+		})
+	}
+	for range 10 {
+		_ = xlog.Init(xlog.WithOutput(io.Discard))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,149 @@
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Throttled logs only some of the records passed to it, deciding per call
+// site. It is returned by Once, EveryN, Every and LogIf:
+//
+//	for i, item := range items {
+//		xlog.EveryN(1000).Info(ctx, "processing", "done", i)
+//	}
+type Throttled struct {
+	logger *Logger
+	kind   throttleKind
+	n      int64
+	cond   bool
+}
+
+type throttleKind uint8
+
+const (
+	throttleOnce throttleKind = iota
+	throttleEveryN
+	throttleEvery
+	throttleIf
+)
+
+// throttleKey identifies the state of one policy at one call site.
+type throttleKey struct {
+	pc   uintptr
+	kind throttleKind
+	n    int64
+}
+
+type throttleState struct {
+	count atomic.Int64
+	last  atomic.Int64
+}
+
+var throttles sync.Map // throttleKey -> *throttleState
+
+// Once logs only the first record from each call site.
+func Once() *Throttled {
+	return Default().Once()
+}
+
+// EveryN logs the first record from each call site and then every nth one.
+func EveryN(n int) *Throttled {
+	return Default().EveryN(n)
+}
+
+// Every logs at most one record per interval d from each call site.
+func Every(d time.Duration) *Throttled {
+	return Default().Every(d)
+}
+
+// LogIf logs only when cond is true.
+func LogIf(cond bool) *Throttled {
+	return Default().LogIf(cond)
+}
+
+// Once logs only the first record from each call site.
+func (l *Logger) Once() *Throttled {
+	return &Throttled{logger: l, kind: throttleOnce}
+}
+
+// EveryN logs the first record from each call site and then every nth one.
+func (l *Logger) EveryN(n int) *Throttled {
+	return &Throttled{logger: l, kind: throttleEveryN, n: int64(max(n, 1))}
+}
+
+// Every logs at most one record per interval d from each call site.
+func (l *Logger) Every(d time.Duration) *Throttled {
+	return &Throttled{logger: l, kind: throttleEvery, n: int64(d)}
+}
+
+// LogIf logs only when cond is true.
+func (l *Logger) LogIf(cond bool) *Throttled {
+	return &Throttled{logger: l, kind: throttleIf, cond: cond}
+}
+
+// Debug logs at DEBUG level if the policy allows it.
+func (t *Throttled) Debug(ctx context.Context, msg string, args ...any) {
+	if t.allow(ctx, slog.LevelDebug) {
+		logWithCaller(ctx, t.logger, slog.LevelDebug, msg, args...)
+	}
+}
+
+// Info logs at INFO level if the policy allows it.
+func (t *Throttled) Info(ctx context.Context, msg string, args ...any) {
+	if t.allow(ctx, slog.LevelInfo) {
+		logWithCaller(ctx, t.logger, slog.LevelInfo, msg, args...)
+	}
+}
+
+// Warn logs at WARN level if the policy allows it.
+func (t *Throttled) Warn(ctx context.Context, msg string, args ...any) {
+	if t.allow(ctx, slog.LevelWarn) {
+		logWithCaller(ctx, t.logger, slog.LevelWarn, msg, args...)
+	}
+}
+
+// Error logs at ERROR level if the policy allows it.
+func (t *Throttled) Error(ctx context.Context, msg string, args ...any) {
+	if t.allow(ctx, slog.LevelError) {
+		logWithCaller(ctx, t.logger, slog.LevelError, msg, args...)
+	}
+}
+
+// allow reports whether a record from the caller of the logging method
+// should be written. Records below the logger's level don't count.
+func (t *Throttled) allow(ctx context.Context, level slog.Level) bool {
+	if t.kind == throttleIf {
+		return t.cond
+	}
+	if !t.logger.Logger.Enabled(ctx, level) {
+		return false
+	}
+
+	// Skip runtime.Callers, allow, and the logging method
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	key := throttleKey{pc: pcs[0], kind: t.kind, n: t.n}
+	v, ok := throttles.Load(key)
+	if !ok {
+		v, _ = throttles.LoadOrStore(key, &throttleState{})
+	}
+	s := v.(*throttleState)
+
+	switch t.kind {
+	case throttleOnce:
+		return s.count.Add(1) == 1
+	case throttleEveryN:
+		return (s.count.Add(1)-1)%t.n == 0
+	default:
+		now := time.Now().UnixNano()
+		last := s.last.Load()
+		if last != 0 && now-last < t.n {
+			return false
+		}
+		return s.last.CompareAndSwap(last, now)
+	}
+}
@@ -0,0 +1,47 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestThrottled(t *testing.T) {
+	t.Cleanup(xlog.ResetThrottles)
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		xlog.Once().Info(ctx, "once")
+		xlog.EveryN(3).Info(ctx, "every-n")
+		xlog.Every(time.Hour).Info(ctx, "every")
+		xlog.LogIf(i == 5).Info(ctx, "if")
+		xlog.Once().Debug(ctx, "suppressed once")
+	}
+	xlog.Once().Info(ctx, "once")
+
+	output := buf.String()
+	counts := map[string]int{
+		`"msg":"once"`:            2,
+		`"msg":"every-n"`:         4,
+		`"msg":"every"`:           1,
+		`"msg":"if"`:              1,
+		`"msg":"suppressed once"`: 0,
+	}
+	for s, want := range counts {
+		if got := strings.Count(output, s); got != want {
+			t.Errorf("expected %d records with %s, got %d", want, s, got)
+		}
+	}
+	if !strings.Contains(output, "throttle_test.go") {
+		t.Errorf("expected source to point at the call site, got: %s", output)
+	}
+}
@@ -0,0 +1,101 @@
+package xlog
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// TimeFormat renders the record time as the value of the time attribute.
+type TimeFormat func(t time.Time) slog.Value
+
+// Built-in time formats for WithJSONTimeFormat.
+var (
+	// TimeRFC3339Nano renders times as RFC 3339 strings with nanoseconds,
+	// trailing zeros removed.
+	TimeRFC3339Nano TimeFormat = TimeLayout(time.RFC3339Nano)
+
+	// TimeEpochMillis renders times as milliseconds since the Unix epoch.
+	TimeEpochMillis TimeFormat = func(t time.Time) slog.Value {
+		return slog.Int64Value(t.UnixMilli())
+	}
+)
+
+// processStart is the time the package was initialized, for
+// TimeSinceStart.
+var processStart = time.Now()
+
+// TimeSinceStart renders times as the time elapsed since the process
+// started, such as "+1.234s". It suits local development, with
+// ColorOptions.Time.
+var TimeSinceStart TimeFormat = func(t time.Time) slog.Value {
+	return slog.StringValue(formatElapsed(t.Sub(processStart)))
+}
+
+// TimeSincePrevious returns a TimeFormat rendering times as the time
+// elapsed since the previous record it rendered, such as "+12ms"; the
+// first record gets "+0s".
+func TimeSincePrevious() TimeFormat {
+	var last atomic.Int64
+	return func(t time.Time) slog.Value {
+		prev := last.Swap(t.UnixNano())
+		if prev == 0 {
+			prev = t.UnixNano()
+		}
+		return slog.StringValue(formatElapsed(time.Duration(t.UnixNano() - prev)))
+	}
+}
+
+// formatElapsed renders d to the millisecond, treating records out of
+// order as simultaneous.
+func formatElapsed(d time.Duration) string {
+	d = max(d, 0)
+	return "+" + d.Round(time.Millisecond).String()
+}
+
+// TimeLayout returns a TimeFormat rendering times as strings with the
+// given time.Format layout.
+func TimeLayout(layout string) TimeFormat {
+	return func(t time.Time) slog.Value {
+		return slog.StringValue(t.Format(layout))
+	}
+}
+
+// WithJSONTimeFormat sets how the JSON formats render the record time.
+// By default StdJSON uses RFC 3339 with milliseconds and FastJSON RFC 3339
+// with nanoseconds. It takes precedence over WithTimeFormat, which then
+// only applies to ColorText. Like WithAttrTransformers, it does not apply
+// to a handler set with WithHandler.
+func WithJSONTimeFormat(f TimeFormat) Option {
+	return func(c *config) {
+		c.jsonTime = f
+	}
+}
+
+// WithUTC converts the record time to UTC in every format instead of
+// keeping the local time zone.
+func WithUTC(enabled bool) Option {
+	return func(c *config) {
+		c.utc = enabled
+	}
+}
+
+// timeTransformer converts the top-level time attribute to UTC if utc is
+// set and renders it with f if it is not nil.
+func timeTransformer(utc bool, f TimeFormat) AttrTransformer {
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key != slog.TimeKey || len(groups) != 0 || a.Value.Kind() != slog.KindTime {
+			return a
+		}
+		t := a.Value.Time()
+		if utc {
+			t = t.UTC()
+		}
+		if f != nil {
+			a.Value = f(t)
+		} else {
+			a.Value = slog.TimeValue(t)
+		}
+		return a
+	}
+}
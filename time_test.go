@@ -0,0 +1,93 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithJSONTimeFormat(t *testing.T) {
+	for _, format := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		var buf bytes.Buffer
+		_ = xlog.Init(
+			xlog.WithFormat(format),
+			xlog.WithOutput(&buf),
+			xlog.WithJSONTimeFormat(xlog.TimeEpochMillis),
+		)
+		before := time.Now().UnixMilli()
+		xlog.Info(context.Background(), "hello")
+
+		var rec map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		ms, ok := rec["time"].(float64)
+		if !ok || int64(ms) < before || int64(ms) > time.Now().UnixMilli() {
+			t.Errorf("%s: expected epoch millis, got: %s", format, buf.String())
+		}
+	}
+}
+
+func TestWithUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("test", 9*60*60)
+	defer func() { time.Local = local }()
+
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithUTC(true),
+		xlog.WithJSONTimeFormat(xlog.TimeRFC3339Nano),
+	)
+	xlog.Info(context.Background(), "hello")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	ts, _ := rec["time"].(string)
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil || ts[len(ts)-1] != 'Z' {
+		t.Errorf("expected an RFC 3339 time in UTC, got: %s", buf.String())
+	}
+}
+
+func TestTimeSincePrevious(t *testing.T) {
+	f := xlog.TimeSincePrevious()
+	start := time.Now()
+	for _, tt := range []struct {
+		t    time.Time
+		want string
+	}{
+		{start, "+0s"},
+		{start.Add(12 * time.Millisecond), "+12ms"},
+		{start.Add(1512 * time.Millisecond), "+1.5s"},
+		{start, "+0s"},
+	} {
+		if got := f(tt.t).String(); got != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, got)
+		}
+	}
+}
+
+func TestColorOptionsTime(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithEnvironment(xlog.Development),
+		xlog.WithOutput(&buf),
+		xlog.WithColorOptions(&xlog.ColorOptions{Time: xlog.TimeSinceStart}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info(context.Background(), "elapsed")
+
+	if line := ansi.ReplaceAllString(buf.String(), ""); !strings.HasPrefix(line, "+") {
+		t.Errorf("expected an elapsed time, got %q", line)
+	}
+}
@@ -0,0 +1,119 @@
+package xlog
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// verboseKey is the context key set by WithVerbose.
+type verboseKey struct{}
+
+// WithVerbose returns a context whose records are logged down to DEBUG,
+// whatever the logger's level, so a single request can be investigated
+// without changing the global level:
+//
+//	if r.Header.Get("X-Debug") == token {
+//		ctx = xlog.WithVerbose(ctx)
+//	}
+func WithVerbose(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verboseKey{}, true)
+}
+
+// IsVerbose reports whether ctx was returned by WithVerbose.
+func IsVerbose(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(verboseKey{}).(bool)
+	return v
+}
+
+// DebugTarget is a context value, such as a user or tenant ID, for which
+// DEBUG records are logged whatever the logger's level.
+type DebugTarget struct {
+	Key   ContextKey `json:"key"`
+	Value string     `json:"value"`
+}
+
+// debugTargets holds the current targets. The map is replaced, never
+// modified, so it can be read without locking.
+var (
+	debugTargets   atomic.Pointer[map[DebugTarget]struct{}]
+	debugTargetsMu sync.Mutex
+)
+
+// AddDebugTarget enables DEBUG records logged with a context in which key
+// holds the string value, for investigating a single customer's issue in
+// production:
+//
+//	xlog.AddDebugTarget(xlog.UserIDKey, "alice")
+//
+// Targets can also be managed at runtime through AdminHandler.
+func AddDebugTarget(key ContextKey, value string) {
+	updateDebugTargets(func(m map[DebugTarget]struct{}) {
+		m[DebugTarget{Key: key, Value: value}] = struct{}{}
+	})
+}
+
+// RemoveDebugTarget removes a target added with AddDebugTarget.
+func RemoveDebugTarget(key ContextKey, value string) {
+	updateDebugTargets(func(m map[DebugTarget]struct{}) {
+		delete(m, DebugTarget{Key: key, Value: value})
+	})
+}
+
+// DebugTargets returns the current targets, sorted by key and value.
+func DebugTargets() []DebugTarget {
+	var targets []DebugTarget
+	if p := debugTargets.Load(); p != nil {
+		for t := range *p {
+			targets = append(targets, t)
+		}
+	}
+	slices.SortFunc(targets, func(a, b DebugTarget) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Value, b.Value))
+	})
+	return targets
+}
+
+func updateDebugTargets(fn func(map[DebugTarget]struct{})) {
+	debugTargetsMu.Lock()
+	defer debugTargetsMu.Unlock()
+
+	m := make(map[DebugTarget]struct{})
+	if p := debugTargets.Load(); p != nil {
+		for t := range *p {
+			m[t] = struct{}{}
+		}
+	}
+	fn(m)
+	if len(m) == 0 {
+		debugTargets.Store(nil)
+		return
+	}
+	debugTargets.Store(&m)
+}
+
+// targeted reports whether ctx matches a debug target.
+func targeted(ctx context.Context) bool {
+	p := debugTargets.Load()
+	if p == nil || ctx == nil {
+		return false
+	}
+	for t := range *p {
+		if v, ok := ctx.Value(t.Key).(string); ok && v == t.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// contextEnabled reports whether ctx enables records at level even if
+// they are below the logger's level.
+func contextEnabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug && (IsVerbose(ctx) || targeted(ctx))
+}
@@ -0,0 +1,71 @@
+package xlog_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestWithVerbose(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithLevel(slog.LevelWarn),
+	)
+
+	ctx := context.Background()
+	xlog.Debug(ctx, "quiet")
+	vctx := xlog.WithVerbose(ctx)
+	xlog.Debug(vctx, "verbose debug")
+	xlog.Named("db").Info(vctx, "verbose info")
+
+	output := buf.String()
+	if strings.Contains(output, "quiet") {
+		t.Errorf("expected DEBUG to stay off without WithVerbose, got: %s", output)
+	}
+	for _, want := range []string{"verbose debug", "verbose info"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q with WithVerbose, got: %s", want, output)
+		}
+	}
+	if xlog.IsVerbose(ctx) || !xlog.IsVerbose(vctx) {
+		t.Error("IsVerbose reported the wrong state")
+	}
+}
+
+func TestDebugTargets(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+	)
+	tenant := xlog.ContextKey("tenant_id")
+	xlog.AddDebugTarget(xlog.UserIDKey, "alice")
+	xlog.AddDebugTarget(tenant, "acme")
+	defer xlog.RemoveDebugTarget(tenant, "acme")
+
+	want := []xlog.DebugTarget{{Key: tenant, Value: "acme"}, {Key: xlog.UserIDKey, Value: "alice"}}
+	if got := xlog.DebugTargets(); !slices.Equal(got, want) {
+		t.Errorf("DebugTargets() = %v, want %v", got, want)
+	}
+
+	ctx := context.Background()
+	xlog.Debug(xlog.WithUserID(ctx, "bob"), "bob")
+	xlog.Debug(xlog.WithUserID(ctx, "alice"), "alice")
+	xlog.Debug(context.WithValue(ctx, tenant, "acme"), "acme")
+	xlog.RemoveDebugTarget(xlog.UserIDKey, "alice")
+	xlog.Debug(xlog.WithUserID(ctx, "alice"), "removed")
+
+	output := buf.String()
+	for msg, want := range map[string]bool{"bob": false, "alice": true, "acme": true, "removed": false} {
+		if got := strings.Contains(output, `"msg":"`+msg+`"`); got != want {
+			t.Errorf("%s: expected written=%v, got: %s", msg, want, output)
+		}
+	}
+}
@@ -0,0 +1,621 @@
+package xlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrWriterClosed is returned by writers after Close has been called.
+var ErrWriterClosed = errors.New("xlog: writer closed")
+
+// NetWriterOptions configures a NetWriter.
+type NetWriterOptions struct {
+	// Timeout bounds each dial and write. Defaults to 5s.
+	Timeout time.Duration
+
+	// RetryInterval is the minimum time between reconnect attempts. Defaults to 1s.
+	RetryInterval time.Duration
+
+	// BufferSize is the number of writes retained in memory while
+	// disconnected; the oldest are dropped once it is full. Defaults to 1024.
+	// A negative value disables buffering, so writes fail while disconnected.
+	BufferSize int
+}
+
+// NetWriter is an io.Writer that sends each write to a TCP, UDP, or Unix
+// socket, reconnecting automatically. Writes made while disconnected are
+// kept in a ring buffer and replayed in order once the connection is back.
+// It is intended for use with WithOutput, where each write is one record.
+type NetWriter struct {
+	network string
+	addr    string
+	opts    NetWriterOptions
+
+	mu       sync.Mutex
+	conn     net.Conn
+	lastDial time.Time
+	pending  [][]byte
+	dropped  uint64
+	closed   bool
+}
+
+// NewNetWriter creates a NetWriter for the given network and address.
+// The connection is dialed on the first write.
+func NewNetWriter(network, addr string, opts *NetWriterOptions) *NetWriter {
+	var o NetWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = time.Second
+	}
+	if o.BufferSize == 0 {
+		o.BufferSize = 1024
+	}
+	return &NetWriter{
+		network: network,
+		addr:    addr,
+		opts:    o,
+	}
+}
+
+// Write sends p, replaying any buffered writes first. If the connection is
+// down, p is buffered and Write returns nil unless buffering is disabled.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	err := w.connectLocked()
+	if err == nil {
+		if err = w.replayLocked(); err == nil {
+			err = w.sendLocked(p)
+		}
+	}
+	if err == nil {
+		return len(p), nil
+	}
+
+	if w.opts.BufferSize < 0 {
+		return 0, err
+	}
+	w.bufferLocked(p)
+	return len(p), nil
+}
+
+// Dropped returns the number of buffered writes discarded because the
+// ring buffer was full.
+func (w *NetWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Close closes the connection. Buffered writes that could not be
+// delivered are discarded.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	w.pending = nil
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *NetWriter) connectLocked() error {
+	if w.conn != nil {
+		return nil
+	}
+	if time.Since(w.lastDial) < w.opts.RetryInterval {
+		return errors.New("xlog: net writer disconnected")
+	}
+	w.lastDial = time.Now()
+
+	conn, err := net.DialTimeout(w.network, w.addr, w.opts.Timeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *NetWriter) sendLocked(p []byte) error {
+	_ = w.conn.SetWriteDeadline(time.Now().Add(w.opts.Timeout))
+	if _, err := w.conn.Write(p); err != nil {
+		_ = w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// replayLocked sends buffered writes in order, stopping at the first error.
+func (w *NetWriter) replayLocked() error {
+	for len(w.pending) > 0 {
+		if err := w.sendLocked(w.pending[0]); err != nil {
+			return err
+		}
+		w.pending = w.pending[1:]
+	}
+	return nil
+}
+
+// bufferLocked keeps a copy of p, discarding the oldest entry when full.
+func (w *NetWriter) bufferLocked(p []byte) {
+	if len(w.pending) >= w.opts.BufferSize {
+		w.pending = w.pending[1:]
+		w.dropped++
+		recordDropped()
+	}
+	b := make([]byte, len(p))
+	copy(b, p)
+	w.pending = append(w.pending, b)
+}
+
+// FailoverWriterOptions configures a FailoverWriter.
+type FailoverWriterOptions struct {
+	// RetryInterval is how long to stay on the fallback before trying the
+	// primary again. Defaults to 30s.
+	RetryInterval time.Duration
+}
+
+// FailoverWriter writes to a primary writer and switches to a fallback
+// once the primary returns an error. The primary is retried after
+// RetryInterval; switching in either direction is reported through the
+// default logger so the failover itself shows up in the logs.
+type FailoverWriter struct {
+	primary  io.Writer
+	fallback io.Writer
+	opts     FailoverWriterOptions
+
+	mu       sync.Mutex
+	failed   bool
+	failedAt time.Time
+	closed   bool
+
+	reports chan failoverReport
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// NewFailoverWriter creates a FailoverWriter that prefers primary and
+// starts the goroutine reporting switches. Call Close to stop it.
+func NewFailoverWriter(primary, fallback io.Writer, opts *FailoverWriterOptions) *FailoverWriter {
+	var o FailoverWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = 30 * time.Second
+	}
+
+	w := &FailoverWriter{
+		primary:  primary,
+		fallback: fallback,
+		opts:     o,
+		reports:  make(chan failoverReport, 8),
+		done:     make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.reportLoop()
+
+	return w
+}
+
+// Write writes p to the primary, or to the fallback if the primary is
+// failing. The fallback's result is returned when it is used.
+func (w *FailoverWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, ErrWriterClosed
+	}
+
+	if !w.failed || time.Since(w.failedAt) >= w.opts.RetryInterval {
+		n, err := w.primary.Write(p)
+		if err == nil {
+			if w.failed {
+				w.failed = false
+				w.report(slog.LevelInfo, "xlog: output recovered to primary", nil)
+			}
+			return n, nil
+		}
+		if !w.failed {
+			w.report(slog.LevelWarn, "xlog: output failed over to fallback", err)
+		}
+		w.failed = true
+		w.failedAt = time.Now()
+	}
+
+	return w.fallback.Write(p)
+}
+
+// Failed reports whether the writer is currently using the fallback.
+func (w *FailoverWriter) Failed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.failed
+}
+
+// Close stops the reporting goroutine and closes the primary and the
+// fallback if they implement io.Closer, except standard output and error.
+func (w *FailoverWriter) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var errs []error
+	for _, out := range []io.Writer{w.primary, w.fallback} {
+		if c, ok := out.(io.Closer); ok && out != os.Stdout && out != os.Stderr {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// report queues a self-diagnostic record for reportLoop, since Write is
+// usually called while a handler holds its output lock. Reports are
+// dropped if the queue is full.
+func (w *FailoverWriter) report(level slog.Level, msg string, err error) {
+	select {
+	case w.reports <- failoverReport{level: level, msg: msg, err: err}:
+	default:
+	}
+}
+
+// failoverReport is a switch queued by FailoverWriter.report.
+type failoverReport struct {
+	level slog.Level
+	msg   string
+	err   error
+}
+
+func (w *FailoverWriter) reportLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case r := <-w.reports:
+			args := []any{}
+			if r.err != nil {
+				args = append(args, "error", r.err)
+			}
+			Default().Log(context.Background(), r.level, r.msg, args...)
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// ShardedWriterOptions configures a ShardedWriter.
+type ShardedWriterOptions struct {
+	// Shards is the number of independent buffers. Defaults to GOMAXPROCS.
+	Shards int
+
+	// BufferSize is the size at which a shard is flushed. Defaults to 32KB.
+	BufferSize int
+
+	// FlushInterval is how often the background goroutine flushes all
+	// shards. Defaults to 100ms.
+	FlushInterval time.Duration
+}
+
+// ShardedWriter spreads writes across per-P buffers so concurrent
+// goroutines rarely contend on a lock. Each write is appended whole to one
+// shard, so records are never interleaved, but records from different
+// goroutines may reach the underlying writer out of order.
+//
+// ColorHandler and FastJSONHandler skip their own output lock when writing
+// to a ShardedWriter; slog.JSONHandler always serializes writes.
+type ShardedWriter struct {
+	out   io.Writer
+	outMu sync.Mutex
+	opts  ShardedWriterOptions
+
+	shards []*writerShard
+	next   atomic.Uint32
+	pool   sync.Pool
+	closed atomic.Bool
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+type writerShard struct {
+	mu  sync.Mutex
+	buf []byte
+	_   [64]byte // avoid false sharing between shards
+}
+
+// NewShardedWriter creates a ShardedWriter over out and starts its
+// background flusher. Call Close to stop it and flush remaining data.
+func NewShardedWriter(out io.Writer, opts *ShardedWriterOptions) *ShardedWriter {
+	var o ShardedWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Shards <= 0 {
+		o.Shards = runtime.GOMAXPROCS(0)
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = 32 << 10
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 100 * time.Millisecond
+	}
+
+	w := &ShardedWriter{
+		out:    out,
+		opts:   o,
+		shards: make([]*writerShard, o.Shards),
+		done:   make(chan struct{}),
+	}
+	for i := range w.shards {
+		w.shards[i] = &writerShard{buf: make([]byte, 0, o.BufferSize)}
+	}
+	// sync.Pool keeps a per-P cache, which gives each P a sticky shard
+	w.pool.New = func() any {
+		return w.shards[int(w.next.Add(1))%len(w.shards)]
+	}
+
+	w.wg.Add(1)
+	go w.flushLoop()
+
+	return w
+}
+
+// Write appends p to a shard, flushing the shard if it is full. After
+// Close, p goes straight to the underlying writer.
+func (w *ShardedWriter) Write(p []byte) (int, error) {
+	s := w.pool.Get().(*writerShard)
+	defer w.pool.Put(s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Close sets closed before flushing each shard under its lock, so p
+	// is either flushed by Close or written here
+	if w.closed.Load() {
+		w.outMu.Lock()
+		defer w.outMu.Unlock()
+		return w.out.Write(p)
+	}
+	s.buf = append(s.buf, p...)
+	if len(s.buf) >= w.opts.BufferSize {
+		if err := w.flushShardLocked(s); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Buffered returns the number of bytes waiting to be flushed.
+func (w *ShardedWriter) Buffered() int {
+	n := 0
+	for _, s := range w.shards {
+		s.mu.Lock()
+		n += len(s.buf)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Flush writes all buffered data to the underlying writer.
+func (w *ShardedWriter) Flush() error {
+	var firstErr error
+	for _, s := range w.shards {
+		s.mu.Lock()
+		err := w.flushShardLocked(s)
+		s.mu.Unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Close stops the background flusher and flushes all shards. Loggers
+// still holding the writer then write through unbuffered.
+func (w *ShardedWriter) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+	w.closed.Store(true)
+	return w.Flush()
+}
+
+func (w *ShardedWriter) flushShardLocked(s *writerShard) error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	w.outMu.Lock()
+	_, err := w.out.Write(s.buf)
+	w.outMu.Unlock()
+	s.buf = s.buf[:0]
+	return err
+}
+
+func (w *ShardedWriter) flushLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// concurrentWrites marks ShardedWriter as safe for unsynchronized,
+// record-atomic writes.
+func (w *ShardedWriter) concurrentWrites() {}
+
+// concurrentWriter is implemented by writers whose Write may be called
+// concurrently without interleaving, letting handlers skip their lock.
+type concurrentWriter interface {
+	concurrentWrites()
+}
+
+// BufferedWriterOptions configures a BufferedWriter.
+type BufferedWriterOptions struct {
+	// Size is the buffer size that triggers a flush. Defaults to 64KB.
+	Size int
+
+	// FlushInterval is the maximum time data waits in the buffer.
+	// Defaults to 100ms.
+	FlushInterval time.Duration
+}
+
+// BufferedWriter accumulates writes and passes them to the underlying
+// writer in large batches, flushing when the buffer fills and on every
+// FlushInterval. This greatly reduces syscalls for file and network outputs.
+type BufferedWriter struct {
+	out  io.Writer
+	opts BufferedWriterOptions
+
+	mu     sync.Mutex
+	buf    []byte
+	closed bool
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewBufferedWriter creates a BufferedWriter over out and starts its
+// background flusher. Call Close to stop it and flush remaining data.
+func NewBufferedWriter(out io.Writer, opts *BufferedWriterOptions) *BufferedWriter {
+	var o BufferedWriterOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Size <= 0 {
+		o.Size = 64 << 10
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = 100 * time.Millisecond
+	}
+
+	w := &BufferedWriter{
+		out:  out,
+		opts: o,
+		buf:  make([]byte, 0, o.Size),
+		done: make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.flushLoop()
+
+	return w
+}
+
+// Write buffers p. Data that does not fit triggers a flush first, and
+// writes larger than the buffer, or made after Close, go straight to the
+// underlying writer.
+func (w *BufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return w.out.Write(p)
+	}
+	if len(w.buf)+len(p) > w.opts.Size {
+		if err := w.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	if len(p) > w.opts.Size {
+		return w.out.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+// Buffered returns the number of bytes waiting to be flushed.
+func (w *BufferedWriter) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.buf)
+}
+
+// Flush writes all buffered data to the underlying writer.
+func (w *BufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+// Close stops the background flusher and flushes remaining data. Loggers
+// still holding the writer then write through unbuffered.
+func (w *BufferedWriter) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return w.flushLocked()
+}
+
+func (w *BufferedWriter) flushLocked() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *BufferedWriter) flushLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = w.Flush()
+		case <-w.done:
+			return
+		}
+	}
+}
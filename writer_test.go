@@ -0,0 +1,345 @@
+package xlog_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+)
+
+func TestNetWriterBuffersWhileDisconnected(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := xlog.NewNetWriter("tcp", addr, &xlog.NetWriterOptions{
+		Timeout:       time.Second,
+		RetryInterval: time.Millisecond,
+		BufferSize:    2,
+	})
+	defer w.Close()
+
+	// Nothing is listening: all three writes are buffered, the oldest dropped
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("unexpected error while disconnected: %v", err)
+		}
+	}
+	if w.Dropped() != 1 {
+		t.Errorf("expected 1 dropped write, got %d", w.Dropped())
+	}
+
+	ln, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot rebind %s: %v", addr, err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string, 3)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+	}()
+
+	time.Sleep(2 * time.Millisecond)
+	if _, err := w.Write([]byte("four\n")); err != nil {
+		t.Fatalf("unexpected error after reconnect: %v", err)
+	}
+
+	for _, want := range []string{"two", "three", "four"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
+
+func TestNetWriterUnbuffered(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w := xlog.NewNetWriter("tcp", addr, &xlog.NetWriterOptions{BufferSize: -1})
+	if _, err := w.Write([]byte("lost\n")); err == nil {
+		t.Error("expected error when buffering is disabled")
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("primary down")
+}
+
+func TestFailoverWriter(t *testing.T) {
+	var fallback syncBuffer
+	w := xlog.NewFailoverWriter(failingWriter{}, &fallback, nil)
+
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(w),
+		xlog.WithSource(false),
+	)
+	xlog.Info(context.Background(), "survives failover")
+
+	if !w.Failed() {
+		t.Error("expected writer to report failover")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(fallback.String(), "failed over") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	output := fallback.String()
+	if !strings.Contains(output, "survives failover") {
+		t.Errorf("expected record in fallback, got: %s", output)
+	}
+	if !strings.Contains(output, "failed over") {
+		t.Errorf("expected diagnostic record in fallback, got: %s", output)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("late\n")); !errors.Is(err, xlog.ErrWriterClosed) {
+		t.Errorf("expected ErrWriterClosed after Close, got: %v", err)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestShardedWriter(t *testing.T) {
+	var out syncBuffer
+	w := xlog.NewShardedWriter(&out, &xlog.ShardedWriterOptions{
+		Shards:        4,
+		BufferSize:    128,
+		FlushInterval: time.Hour,
+	})
+	logger := slog.New(xlog.NewFastJSONHandler(w, nil))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				logger.Info("sharded", "i", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 800 {
+		t.Fatalf("expected 800 records, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+			t.Fatalf("record was interleaved: %q", line)
+		}
+	}
+}
+
+func TestShardedWriterClose(t *testing.T) {
+	var out syncBuffer
+	w := xlog.NewShardedWriter(&out, &xlog.ShardedWriterOptions{FlushInterval: time.Hour})
+
+	// Writes racing with Close are either flushed by it or written through
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, _ = w.Write([]byte("record\n"))
+			}
+		}()
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	if got := strings.Count(out.String(), "\n"); got != 800 {
+		t.Errorf("expected 800 records, got %d", got)
+	}
+	if w.Buffered() != 0 {
+		t.Error("expected nothing buffered after Close")
+	}
+}
+
+func TestShardingReinit(t *testing.T) {
+	out := &closingWriter{}
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(out),
+		xlog.WithSharding(&xlog.ShardedWriterOptions{FlushInterval: time.Hour}),
+	)
+	xlog.Info(context.Background(), "before reinit")
+	if out.String() != "" {
+		t.Fatalf("expected the record to be buffered, got: %s", out.String())
+	}
+
+	_ = xlog.Init(xlog.WithOutput(io.Discard))
+	if !strings.Contains(out.String(), "before reinit") {
+		t.Errorf("expected Init to flush the replaced shards, got: %q", out.String())
+	}
+	if out.closed {
+		t.Error("expected the output itself to stay open")
+	}
+}
+
+func TestBufferingReinit(t *testing.T) {
+	out := &closingWriter{}
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(out),
+		xlog.WithBuffering(&xlog.BufferedWriterOptions{FlushInterval: time.Hour}),
+	)
+	xlog.Info(context.Background(), "before reinit")
+	if out.String() != "" {
+		t.Fatalf("expected the record to be buffered, got: %s", out.String())
+	}
+
+	_ = xlog.Init(xlog.WithOutput(io.Discard))
+	if !strings.Contains(out.String(), "before reinit") {
+		t.Errorf("expected Init to flush the replaced buffer, got: %q", out.String())
+	}
+	if out.closed {
+		t.Error("expected the output itself to stay open")
+	}
+}
+
+func BenchmarkInfoParallelSharded(b *testing.B) {
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithFormat(xlog.FastJSON),
+		xlog.WithOutput(io.Discard),
+		xlog.WithSource(false),
+		xlog.WithSharding(nil),
+	)
+
+	ctx := context.Background()
+	ctx = xlog.WithTraceID(ctx, "trace-123")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			xlog.Info(ctx, "parallel benchmark", "iteration", i)
+			i++
+		}
+	})
+}
+
+// countingWriter records how many times Write is called.
+type countingWriter struct {
+	syncBuffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.writes++
+	w.mu.Unlock()
+	return w.syncBuffer.Write(p)
+}
+
+func TestBufferedWriter(t *testing.T) {
+	out := &countingWriter{}
+	w := xlog.NewBufferedWriter(out, &xlog.BufferedWriterOptions{
+		Size:          64,
+		FlushInterval: time.Hour,
+	})
+
+	for i := 0; i < 10; i++ {
+		_, _ = w.Write([]byte("0123456789\n"))
+	}
+	if out.writes != 1 {
+		t.Errorf("expected 1 write after the buffer filled, got %d", out.writes)
+	}
+	if w.Buffered() == 0 {
+		t.Error("expected data to remain buffered")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(out.String(), "\n"); got != 10 {
+		t.Errorf("expected 10 lines after Close, got %d", got)
+	}
+
+	// Nothing is left in the stopped buffer
+	if _, err := w.Write([]byte("late\n")); err != nil || !strings.HasSuffix(out.String(), "late\n") {
+		t.Errorf("expected a write after Close to go through, got %q: %v", out.String(), err)
+	}
+	if w.Buffered() != 0 {
+		t.Error("expected nothing buffered after Close")
+	}
+}
+
+func TestBufferedWriterInterval(t *testing.T) {
+	out := &countingWriter{}
+	w := xlog.NewBufferedWriter(out, &xlog.BufferedWriterOptions{
+		FlushInterval: time.Millisecond,
+	})
+	defer w.Close()
+
+	_, _ = w.Write([]byte("tick\n"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for out.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if out.String() != "tick\n" {
+		t.Errorf("expected interval flush, got: %q", out.String())
+	}
+}
@@ -4,12 +4,15 @@ package xlog
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
-	"runtime"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,38 +24,114 @@ const (
 	Production  Environment = "production"
 )
 
+// Format selects the output encoding independently of the environment.
+type Format string
+
+const (
+	// ColorText is the colored, human-friendly format of ColorHandler.
+	ColorText Format = "color"
+	// StdJSON is slog.JSONHandler.
+	StdJSON Format = "json"
+	// FastJSON is FastJSONHandler, an allocation-free JSON encoder.
+	FastJSON Format = "fastjson"
+	// ColorJSON is the colored JSON of ColorJSONHandler, for developers
+	// who want JSON they can copy while keeping lines easy to scan.
+	ColorJSON Format = "colorjson"
+)
+
 // Logger wraps slog.Logger with additional functionality.
 type Logger struct {
 	*slog.Logger
 	handler slog.Handler
+	level   slog.Leveler
+	onError ErrorHook
+	sinks   []any
+	// flushers are the writers with background flushers that build added
+	// to the output, outermost first, stopped when Init replaces the logger
+	flushers   []io.Closer
+	name       string
+	callerSkip int
 }
 
+// AttrTransformer rewrites an attribute before it is written, with the same
+// contract as slog.HandlerOptions.ReplaceAttr: returning an empty Attr
+// drops it.
+type AttrTransformer func(groups []string, a slog.Attr) slog.Attr
+
+// ErrorHook is called when a handler fails to write a record.
+type ErrorHook func(err error, r slog.Record)
+
 // config holds the logger configuration.
 type config struct {
-	env         Environment
-	level       slog.Level
-	output      io.Writer
-	addSource   bool
-	timeFormat  string
-	contextKeys []ContextKey
+	env           Environment
+	level         slog.Level
+	output        io.Writer
+	levelOutputs  []levelOutput
+	addSource     bool
+	timeFormat    string
+	contextKeys   []ContextKey
+	expvar        bool
+	format        Format
+	sharding      *ShardedWriterOptions
+	buffering     *BufferedWriterOptions
+	handler       slog.Handler
+	middleware    []HandlerMiddleware
+	transforms    []AttrTransformer
+	dedup         *DedupPolicy
+	flatten       bool
+	security      io.Writer
+	events        io.Writer
+	loggerLevels  map[string]slog.Level
+	service       string
+	version       string
+	instanceID    string
+	buildInfo     bool
+	callerSkip    int
+	sourceFormat  SourceFormat
+	fieldNames    FieldNames
+	consoleLevels LevelFormat
+	jsonLevels    LevelFormat
+	jsonTime      TimeFormat
+	utc           bool
+	sequence      bool
+	flight        bool
+	stdLog        *StdLogOptions
+	colorOptions  *ColorOptions
+	maxValueLen   int
+	maxAttrs      int
+	skipSlog      bool
+	spanEvents    SpanEventFunc
+	timeItLevel   slog.Level
 }
 
 // Option is a functional option for configuring the logger.
 type Option func(*config)
 
 var (
-	defaultLogger *Logger
-	defaultMu     sync.RWMutex
+	defaultLogger  *Logger
+	securityLogger *Logger
+	eventLogger    *Logger
+	defaultMu      sync.RWMutex
+	// initMu serializes Init so the default logger and its core agree
+	initMu sync.Mutex
+
+	errorHook   ErrorHook
+	errorHookMu sync.RWMutex
 )
 
 func init() {
 	// Initialize with a basic logger; users should call Init() to configure properly.
+	// Init swaps its handler, so loggers derived from it before then follow.
+	defaultCore.store(slog.Default().Handler())
 	defaultLogger = &Logger{
-		Logger: slog.Default(),
+		Logger: slog.New(&levelHandler{handler: &swapHandler{core: &defaultCore}, level: namedLevel(""), flight: &defaultFlight}),
+		level:  namedLevel(""),
 	}
+	timeItLevel.Store(int64(slog.LevelDebug))
 }
 
-// WithEnvironment sets the logging environment.
+// WithEnvironment sets the logging environment. Without it, Init uses
+// DetectEnvironment, checking the configured output for a terminal.
 func WithEnvironment(env Environment) Option {
 	return func(c *config) {
 		c.env = env
@@ -73,6 +152,56 @@ func WithOutput(w io.Writer) Option {
 	}
 }
 
+// WithLevelOutput also writes the records at or above level to w, in the
+// format of the main output. The logger's level still decides which
+// records are written at all, so to send DEBUG to a file and INFO to
+// standard output:
+//
+//	xlog.Init(
+//		xlog.WithLevel(slog.LevelDebug),
+//		xlog.WithOutput(file),
+//		xlog.WithLevelOutput(slog.LevelInfo, os.Stdout),
+//		xlog.WithLevelOutput(slog.LevelError, alerts),
+//	)
+//
+// It has no effect with WithHandler.
+func WithLevelOutput(level slog.Level, w io.Writer) Option {
+	return func(c *config) {
+		c.levelOutputs = append(c.levelOutputs, levelOutput{level: level, w: w})
+	}
+}
+
+// levelOutput is an output added with WithLevelOutput.
+type levelOutput struct {
+	level slog.Level
+	w     io.Writer
+}
+
+// WithBuffering batches output writes, flushing when the buffer fills or
+// the flush interval elapses, to reduce syscalls for file and network
+// outputs. A later Init flushes the buffer and stops its flusher.
+func WithBuffering(opts *BufferedWriterOptions) Option {
+	return func(c *config) {
+		if opts == nil {
+			opts = &BufferedWriterOptions{}
+		}
+		c.buffering = opts
+	}
+}
+
+// WithSharding buffers output in per-P shards flushed by a background
+// goroutine, removing the handler's output lock from the logging path.
+// Records from different goroutines may be written out of order. A later
+// Init flushes the shards and stops the goroutine.
+func WithSharding(opts *ShardedWriterOptions) Option {
+	return func(c *config) {
+		if opts == nil {
+			opts = &ShardedWriterOptions{}
+		}
+		c.sharding = opts
+	}
+}
+
 // WithSource enables or disables source code location in logs.
 func WithSource(enabled bool) Option {
 	return func(c *config) {
@@ -80,13 +209,175 @@ func WithSource(enabled bool) Option {
 	}
 }
 
-// WithTimeFormat sets the time format for development environment.
+// WithTimeFormat sets the time format for development environment. See
+// WithJSONTimeFormat for the JSON formats.
 func WithTimeFormat(format string) Option {
 	return func(c *config) {
 		c.timeFormat = format
 	}
 }
 
+// WithFormat sets the output format. By default the format follows the
+// environment: ColorText for Development and StdJSON for Production.
+func WithFormat(format Format) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// WithHandler replaces the output handler selected by the environment and
+// format, for example with an xlogtest.Recorder. Context extraction and
+// the other features of Init still apply on top of it. The security and
+// event outputs keep a handler of the environment's format.
+func WithHandler(h slog.Handler) Option {
+	return func(c *config) {
+		c.handler = h
+	}
+}
+
+// WithMiddleware wraps the output handler with mws, the first being the
+// outermost. Middlewares run after context extraction, so records they
+// see already carry the context attributes.
+func WithMiddleware(mws ...HandlerMiddleware) Option {
+	return func(c *config) {
+		c.middleware = append(c.middleware, mws...)
+	}
+}
+
+// WithAttrTransformers adds transformers applied in order to every
+// attribute, including the built-in time, level, source and message
+// attributes. They apply to the handler selected by the format; a handler
+// set with WithHandler is left untouched.
+func WithAttrTransformers(fns ...AttrTransformer) Option {
+	return func(c *config) {
+		c.transforms = append(c.transforms, fns...)
+	}
+}
+
+// WithReplaceAttr adds fn as an attribute transformer, under the name of
+// its slog.HandlerOptions field. It is WithAttrTransformers(fn).
+func WithReplaceAttr(fn func(groups []string, a slog.Attr) slog.Attr) Option {
+	return WithAttrTransformers(fn)
+}
+
+// WithHandlerOptions applies slog handler options: AddSource, Level,
+// whose current value becomes the minimum level as with WithLevel, and
+// ReplaceAttr, added as with WithReplaceAttr. A false AddSource turns
+// source locations off; a nil opts changes nothing.
+func WithHandlerOptions(opts *slog.HandlerOptions) Option {
+	return func(c *config) {
+		if opts == nil {
+			return
+		}
+		c.addSource = opts.AddSource
+		if opts.Level != nil {
+			c.level = opts.Level.Level()
+		}
+		if opts.ReplaceAttr != nil {
+			c.transforms = append(c.transforms, opts.ReplaceAttr)
+		}
+	}
+}
+
+// FieldNames renames the built-in keys of the records; empty names keep
+// the defaults.
+type FieldNames struct {
+	Time    string // "time"
+	Level   string // "level"
+	Message string // "msg"
+	Source  string // "source"
+}
+
+// WithFieldNames renames the built-in keys, such as to match a log
+// backend's schema:
+//
+//	xlog.WithFieldNames(xlog.FieldNames{Time: "@timestamp", Level: "severity", Message: "message"})
+//
+// Like WithAttrTransformers, it does not apply to a handler set with
+// WithHandler.
+func WithFieldNames(names FieldNames) Option {
+	return func(c *config) {
+		c.fieldNames = names
+	}
+}
+
+// transformer returns an AttrTransformer applying the names, or nil if
+// none is set.
+func (n FieldNames) transformer() AttrTransformer {
+	if n == (FieldNames{}) {
+		return nil
+	}
+	names := map[string]string{
+		slog.TimeKey:    n.Time,
+		slog.LevelKey:   n.Level,
+		slog.MessageKey: n.Message,
+		slog.SourceKey:  n.Source,
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if name := names[a.Key]; name != "" && len(groups) == 0 {
+			a.Key = name
+		}
+		return a
+	}
+}
+
+// WithDedup removes attributes with repeated keys from each record, such
+// as a trace_id passed explicitly and also taken from the context.
+func WithDedup(policy DedupPolicy) Option {
+	return func(c *config) {
+		c.dedup = &policy
+	}
+}
+
+// WithFlatten replaces groups and map[string]any values with dotted keys,
+// such as "http.request.method", in every handler, for backends that
+// handle nested JSON poorly.
+func WithFlatten(enabled bool) Option {
+	return func(c *config) {
+		c.flatten = enabled
+	}
+}
+
+// WithSecurityOutput sends records from the Security logger to w. They are
+// written at every level, regardless of WithLevel, and are not sampled.
+func WithSecurityOutput(w io.Writer) Option {
+	return func(c *config) {
+		c.security = w
+	}
+}
+
+// WithService attaches the service name to every record, as service.name.
+func WithService(name string) Option {
+	return func(c *config) {
+		c.service = name
+	}
+}
+
+// WithVersion attaches the service version to every record, as service.version.
+func WithVersion(v string) Option {
+	return func(c *config) {
+		c.version = v
+	}
+}
+
+// WithInstanceID attaches an identifier of the running instance, such as
+// a hostname or pod name, to every record, as service.instance_id.
+func WithInstanceID(id string) Option {
+	return func(c *config) {
+		c.instanceID = id
+	}
+}
+
+// WithBuildInfo attaches the Go version and the VCS revision and commit
+// time of the binary to every record, in a "build" group, so logs identify
+// the exact build that wrote them. The VCS fields are only available in
+// binaries built from a repository checkout.
+func WithBuildInfo(enabled bool) Option {
+	return func(c *config) {
+		c.buildInfo = enabled
+	}
+}
+
 // WithContextKeys sets the context keys to extract from context.
 func WithContextKeys(keys ...ContextKey) Option {
 	return func(c *config) {
@@ -94,69 +385,402 @@ func WithContextKeys(keys ...ContextKey) Option {
 	}
 }
 
+// WithExpvar publishes the logger's statistics via expvar under the
+// "xlog" key, visible on the /debug/vars endpoint.
+func WithExpvar(enabled bool) Option {
+	return func(c *config) {
+		c.expvar = enabled
+	}
+}
+
 // Init initializes the global logger with the given options.
 // It also updates slog.SetDefault and redirects standard log output.
+// Invalid options fall back to their defaults; use InitE to see them.
 func Init(opts ...Option) *Logger {
+	logger, _ := InitE(opts...)
+	return logger
+}
+
+// InitE is like Init but also reports invalid options, such as a nil
+// output or an unknown environment, each with the default it fell back
+// to. The returned logger is installed and usable either way:
+//
+//	if _, err := xlog.InitE(xlog.WithEnvironment(xlog.Environment(cfg.Env))); err != nil {
+//		xlog.Warn(ctx, "logging configuration", "error", err)
+//	}
+func InitE(opts ...Option) (*Logger, error) {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	cfg := newConfig(opts)
+	err := cfg.validate()
+
+	levels := map[string]slog.Level{"": cfg.level}
+	for name, level := range cfg.loggerLevels {
+		levels[name] = level
+	}
+	loggerLevelsMu.Lock()
+	loggerLevels.Store(&levels)
+	loggerLevelsMu.Unlock()
+	timeItLevel.Store(int64(cfg.timeItLevel))
+
+	// SetOutput replaces the destination beneath the writers Init adds
+	out := &switchWriter{w: cfg.output}
+	cfg.output = out
+	defaultOutput.Store(out)
+
+	logger, security, events := cfg.build(namedLevel(""), &defaultCore)
+
+	// Set as default
+	defaultMu.Lock()
+	prev := defaultLogger
+	defaultLogger = logger
+	securityLogger = security
+	eventLogger = events
+	defaultMu.Unlock()
+
+	// Stop the flushers of the replaced logger, writing out what they hold
+	for _, w := range prev.flushers {
+		_ = w.Close()
+	}
+
+	if cfg.expvar {
+		publishExpvar()
+	}
+
+	// Update slog default, which also redirects the standard logger and
+	// clears its flags
+	stdOutput, stdFlags, stdPrefix := log.Writer(), log.Flags(), log.Prefix()
+	if !cfg.skipSlog {
+		slog.SetDefault(logger.Logger)
+	}
+
+	// Redirect standard log output
+	if cfg.stdLog != nil {
+		log.SetOutput(&stdLogWriter{logger: logger, opts: *cfg.stdLog})
+		log.SetFlags(0)
+	} else {
+		log.SetOutput(stdOutput)
+		log.SetFlags(stdFlags)
+		log.SetPrefix(stdPrefix)
+	}
+
+	return logger, err
+}
+
+// New builds a logger from the options like InitE, but leaves the global
+// state alone: the default logger, slog.Default and the standard logger
+// are unchanged. This suits libraries and tests that need their own
+// logger. The logger's level is fixed by WithLevel and shared by its
+// Named children. Options that configure process-wide state have no
+// effect: WithLoggerLevel, WithTimeItLevel, WithSecurityOutput,
+// WithEventOutput, WithExpvar and WithStdLog.
+func New(opts ...Option) (*Logger, error) {
+	cfg := newConfig(opts)
+	err := cfg.validate()
+	cfg.security, cfg.events = nil, nil
+	logger, _, _ := cfg.build(cfg.level, nil)
+	return logger, err
+}
+
+// newConfig returns the default configuration with opts applied.
+func newConfig(opts []Option) *config {
 	cfg := &config{
-		env:        Development,
-		level:      slog.LevelInfo,
-		output:     os.Stdout,
-		addSource:  true,
-		timeFormat: time.RFC3339,
+		level:       slog.LevelInfo,
+		output:      os.Stdout,
+		addSource:   true,
+		timeFormat:  time.RFC3339,
+		timeItLevel: slog.LevelDebug,
+		stdLog:      &StdLogOptions{},
 		contextKeys: []ContextKey{
 			TraceIDKey,
 			UserIDKey,
 			RequestIDKey,
+			MessageIDKey,
+			CloudTraceKey,
+			CloudSpanIDKey,
 		},
 	}
 
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	if cfg.env == "" {
+		cfg.env = detectEnvironment(cfg.output)
+	}
+	return cfg
+}
 
-	var baseHandler slog.Handler
+// build creates the loggers described by the configuration: the main
+// logger, gated by level, and the security and event loggers if their
+// outputs are set. With a core, the main logger's handler is stored in
+// it and the logger forwards to the core.
+func (cfg *config) build(level slog.Leveler, core *swapCore) (logger, security, events *Logger) {
+	format := cfg.format
+	if format == "" {
+		format = ColorText
+		if cfg.env == Production {
+			format = StdJSON
+		}
+	}
+
+	// Levels are checked per logger by levelHandler, so the handlers
+	// themselves let everything through
 	handlerOpts := &slog.HandlerOptions{
 		AddSource: cfg.addSource,
-		Level:     cfg.level,
-		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-			// Customize time format for development
-			if a.Key == slog.TimeKey && cfg.env == Development {
+		Level:     lowestLevel,
+	}
+	var transforms []AttrTransformer
+	jsonTime := cfg.jsonTime
+	if format == ColorText {
+		jsonTime = nil
+	}
+	if cfg.utc || jsonTime != nil {
+		transforms = append(transforms, timeTransformer(cfg.utc, jsonTime))
+	}
+	if cfg.env == Development {
+		// Customize time format for development
+		transforms = append(transforms, func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
 				if t, ok := a.Value.Any().(time.Time); ok {
 					return slog.String(slog.TimeKey, t.Format(cfg.timeFormat))
 				}
 			}
 			return a
-		},
+		})
 	}
-
-	switch cfg.env {
-	case Production:
-		baseHandler = slog.NewJSONHandler(cfg.output, handlerOpts)
+	transforms = append(transforms, cfg.transforms...)
+	if cfg.sourceFormat != nil {
+		transforms = append(transforms, sourceTransformer(cfg.sourceFormat))
+	}
+	levelFormat := cfg.jsonLevels
+	if format == ColorText {
+		levelFormat = cfg.consoleLevels
+	}
+	switch {
+	case levelFormat != nil:
+		transforms = append(transforms, levelTransformer(levelFormat))
+	case format == ColorText:
+		transforms = append(transforms, registeredLevelTransformer)
 	default:
-		baseHandler = NewColorHandler(cfg.output, handlerOpts)
+		transforms = append(transforms, levelTransformer(LevelUpper))
+	}
+	if rename := cfg.fieldNames.transformer(); rename != nil {
+		transforms = append(transforms, rename)
+	}
+	transforms = append(transforms, expandJoinedErrors)
+	handlerOpts.ReplaceAttr = chainTransformers(transforms)
+
+	// Track outputs outermost first so Flush drains wrappers before
+	// the writers beneath them
+	output := cfg.output
+	sinks := []any{output}
+	var flushers []io.Closer
+	if cfg.buffering != nil {
+		w := NewBufferedWriter(output, cfg.buffering)
+		output = w
+		sinks = append([]any{output}, sinks...)
+		flushers = append([]io.Closer{w}, flushers...)
+	}
+	if cfg.sharding != nil {
+		w := NewShardedWriter(output, cfg.sharding)
+		output = w
+		sinks = append([]any{output}, sinks...)
+		flushers = append([]io.Closer{w}, flushers...)
 	}
 
-	// Wrap with context handler
-	ctxHandler := NewContextHandler(baseHandler, cfg.contextKeys...)
+	// newHandler builds a handler of the configured format, also used for
+	// the dedicated outputs when the main one is set with WithHandler
+	newHandler := func(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+		switch {
+		case format == StdJSON:
+			return slog.NewJSONHandler(w, opts)
+		case format == FastJSON:
+			return NewFastJSONHandler(w, opts)
+		case format == ColorJSON:
+			return NewColorJSONHandler(w, opts)
+		default:
+			return NewColorHandler(w, opts).WithColorOptions(cfg.colorOptions)
+		}
+	}
+	// wrap adds the layers shared by the default and dedicated loggers
+	baseAttrs := cfg.baseAttrs()
+	wrap := func(h slog.Handler) slog.Handler {
+		if cfg.flatten {
+			h = NewFlattenHandler(h, "")
+		}
+		if cfg.dedup != nil {
+			h = NewDedupHandler(h, *cfg.dedup)
+		}
+		h = NewContextHandler(h, cfg.contextKeys...)
+		h = &scopeHandler{handler: h}
+		if cfg.sequence {
+			h = &seqHandler{handler: h}
+		}
+		if cfg.maxValueLen > 0 || cfg.maxAttrs > 0 {
+			h = &limitHandler{handler: h, maxLen: cfg.maxValueLen, maxAttrs: cfg.maxAttrs}
+		}
+		h = &statsHandler{handler: &hookHandler{handler: h}}
+		if len(baseAttrs) > 0 {
+			h = h.WithAttrs(baseAttrs)
+		}
+		return h
+	}
 
-	logger := &Logger{
-		Logger:  slog.New(ctxHandler),
-		handler: ctxHandler,
+	baseHandler := cfg.handler
+	if baseHandler == nil {
+		baseHandler = newHandler(output, handlerOpts)
+	}
+	if len(cfg.levelOutputs) > 0 && cfg.handler == nil {
+		hs := make([]slog.Handler, len(cfg.levelOutputs))
+		for i, lo := range cfg.levelOutputs {
+			opts := *handlerOpts
+			opts.Level = lo.level
+			hs[i] = newHandler(lo.w, &opts)
+			sinks = append(sinks, lo.w)
+		}
+		baseHandler = Tee(hs...)(baseHandler)
+	}
+	middleware := cfg.middleware
+	if cfg.spanEvents != nil {
+		spans := NewFlattenHandler(&spanEventHandler{fn: cfg.spanEvents}, "")
+		middleware = append([]HandlerMiddleware{Tee(spans)}, middleware...)
+	}
+	if len(middleware) > 0 {
+		baseHandler = Chain(middleware...)(baseHandler)
+	}
+	if cfg.flight {
+		baseHandler = &flightHandler{handler: baseHandler}
+	}
+	inner := wrap(baseHandler)
+	flight := new(atomic.Bool)
+	if core != nil {
+		core.store(inner)
+		inner = &swapHandler{core: core}
+		flight = &defaultFlight
+	}
+	flight.Store(cfg.flight)
+	handler := &levelHandler{handler: inner, level: level, flight: flight}
+
+	logger = &Logger{
+		Logger:     slog.New(handler),
+		handler:    handler,
+		level:      level,
+		sinks:      sinks,
+		flushers:   flushers,
+		callerSkip: cfg.callerSkip,
 	}
 
-	// Set as default
-	defaultMu.Lock()
-	defaultLogger = logger
-	defaultMu.Unlock()
+	// dedicated returns a logger that writes every record to w, regardless
+	// of the configured levels
+	dedicated := func(w io.Writer) *Logger {
+		opts := *handlerOpts
+		opts.Level = slog.LevelDebug
+		h := wrap(newHandler(w, &opts))
+		logger.sinks = append(logger.sinks, w)
+		return &Logger{
+			Logger:  slog.New(h),
+			handler: h,
+			level:   slog.LevelDebug,
+			sinks:   []any{w},
+		}
+	}
+	if cfg.security != nil {
+		security = dedicated(cfg.security).With(securityChannel)
+	}
+	if cfg.events != nil {
+		events = dedicated(cfg.events)
+	}
+	return logger, security, events
+}
 
-	// Update slog default
-	slog.SetDefault(logger.Logger)
+// validate replaces invalid settings with their defaults, returning an
+// error describing each.
+func (c *config) validate() error {
+	var errs []error
+	if c.env != Development && c.env != Production {
+		env := detectEnvironment(c.output)
+		errs = append(errs, fmt.Errorf("xlog: unknown environment %q, using %q", c.env, env))
+		c.env = env
+	}
+	if c.output == nil {
+		errs = append(errs, errors.New("xlog: nil output, using standard output"))
+		c.output = os.Stdout
+	}
+	switch c.format {
+	case "", ColorText, StdJSON, FastJSON, ColorJSON:
+	default:
+		errs = append(errs, fmt.Errorf("xlog: unknown format %q, using the environment's", c.format))
+		c.format = ""
+	}
+	if c.handler != nil && c.format != "" && c.security == nil && c.events == nil {
+		errs = append(errs, errors.New("xlog: WithFormat has no effect with WithHandler"))
+	}
+	if c.handler != nil && len(c.levelOutputs) > 0 {
+		errs = append(errs, errors.New("xlog: WithLevelOutput has no effect with WithHandler"))
+	}
+	return errors.Join(errs...)
+}
 
-	// Redirect standard log output to slog
-	log.SetOutput(&slogWriter{logger: logger.Logger})
-	log.SetFlags(0)
+// baseAttrs returns the attributes Init attaches to every record.
+func (c *config) baseAttrs() []slog.Attr {
+	var service []any
+	if c.service != "" {
+		service = append(service, slog.String("name", c.service))
+	}
+	if c.version != "" {
+		service = append(service, slog.String("version", c.version))
+	}
+	if c.instanceID != "" {
+		service = append(service, slog.String("instance_id", c.instanceID))
+	}
 
-	return logger
+	var attrs []slog.Attr
+	if len(service) > 0 {
+		attrs = append(attrs, slog.Group("service", service...))
+	}
+	if c.buildInfo {
+		if build := buildAttrs(); len(build) > 0 {
+			attrs = append(attrs, slog.Group("build", build...))
+		}
+	}
+	return attrs
+}
+
+// buildAttrs reads the binary's build information once.
+var buildAttrs = sync.OnceValue(func() []any {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	attrs := []any{slog.String("go_version", info.GoVersion)}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision", "vcs.time":
+			attrs = append(attrs, slog.String(s.Key, s.Value))
+		}
+	}
+	return attrs
+})
+
+// chainTransformers combines fns into a single ReplaceAttr function,
+// or returns nil if there are none so handlers can skip the call.
+func chainTransformers(fns []AttrTransformer) func([]string, slog.Attr) slog.Attr {
+	switch len(fns) {
+	case 0:
+		return nil
+	case 1:
+		return fns[0]
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		for _, fn := range fns {
+			a = fn(groups, a)
+			if a.Key == "" {
+				break
+			}
+		}
+		return a
+	}
 }
 
 // Default returns the default logger.
@@ -166,19 +790,54 @@ func Default() *Logger {
 	return defaultLogger
 }
 
-// slogWriter adapts slog.Logger to io.Writer for standard log integration.
-type slogWriter struct {
-	logger *slog.Logger
+// loggerContextKey is the context key for a logger stored by IntoContext.
+type loggerContextKey struct{}
+
+// IntoContext returns a copy of ctx carrying l, so code handling a request
+// can log through a logger already enriched for it.
+func IntoContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
 }
 
-func (w *slogWriter) Write(p []byte) (n int, err error) {
-	// Remove trailing newline if present
-	msg := string(p)
-	if len(msg) > 0 && msg[len(msg)-1] == '\n' {
-		msg = msg[:len(msg)-1]
+// FromContext returns the logger stored in ctx by IntoContext, or the
+// default logger if there is none.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*Logger); ok {
+		return l
 	}
-	w.logger.Info(msg)
-	return len(p), nil
+	return Default()
+}
+
+// securityChannel marks records from the Security logger.
+var securityChannel = slog.String("channel", "security")
+
+// Security returns the logger for security events such as failed logins
+// and privilege changes. With WithSecurityOutput its records go to their
+// own output at every level; otherwise they go through the default
+// logger. Either way they carry channel=security.
+func Security() *Logger {
+	defaultMu.RLock()
+	l := securityLogger
+	defaultMu.RUnlock()
+	if l == nil {
+		return Default().With(securityChannel)
+	}
+	return l
+}
+
+// OnError sets the global hook called when a handler returns an error.
+// Loggers with their own hook (see Logger.OnError) use that instead.
+// Passing nil restores the default behavior of discarding errors.
+func OnError(fn ErrorHook) {
+	errorHookMu.Lock()
+	defer errorHookMu.Unlock()
+	errorHook = fn
+}
+
+func globalErrorHook() ErrorHook {
+	errorHookMu.RLock()
+	defer errorHookMu.RUnlock()
+	return errorHook
 }
 
 // callerSkip is the number of stack frames to skip when determining the caller.
@@ -186,92 +845,112 @@ func (w *slogWriter) Write(p []byte) (n int, err error) {
 const callerSkip = 3
 
 // logWithCaller logs a message with correct caller information.
-func logWithCaller(ctx context.Context, logger *slog.Logger, level slog.Level, msg string, args ...any) {
-	if !logger.Enabled(ctx, level) {
+func logWithCaller(ctx context.Context, l *Logger, level slog.Level, msg string, args ...any) {
+	if !l.Logger.Enabled(ctx, level) {
 		return
 	}
 
-	var pcs [1]uintptr
-	runtime.Callers(callerSkip, pcs[:])
+	// callerPC adds a frame of its own
+	logPC(ctx, l, level, callerPC(callerSkip+l.callerSkip), msg, args...)
+}
 
-	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+// logPC logs a message with the source location pc.
+func logPC(ctx context.Context, l *Logger, level slog.Level, pc uintptr, msg string, args ...any) {
+	r := slog.NewRecord(time.Now(), level, msg, pc)
 	r.Add(args...)
 
-	_ = logger.Handler().Handle(ctx, r)
+	if err := l.Logger.Handler().Handle(ctx, r); err != nil {
+		hook := l.onError
+		if hook == nil {
+			hook = globalErrorHook()
+		}
+		if hook != nil {
+			hook(err, r)
+		}
+	}
 }
 
 // Debug logs at DEBUG level with context.
 func Debug(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, Default().Logger, slog.LevelDebug, msg, args...)
+	logWithCaller(ctx, Default(), slog.LevelDebug, msg, args...)
 }
 
 // Info logs at INFO level with context.
 func Info(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, Default().Logger, slog.LevelInfo, msg, args...)
+	logWithCaller(ctx, Default(), slog.LevelInfo, msg, args...)
 }
 
 // Warn logs at WARN level with context.
 func Warn(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, Default().Logger, slog.LevelWarn, msg, args...)
+	logWithCaller(ctx, Default(), slog.LevelWarn, msg, args...)
 }
 
 // Error logs at ERROR level with context.
 func Error(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, Default().Logger, slog.LevelError, msg, args...)
+	logWithCaller(ctx, Default(), slog.LevelError, msg, args...)
 }
 
 // With returns a new Logger with the given attributes.
 func With(args ...any) *Logger {
-	l := Default()
-	return &Logger{
-		Logger:  l.Logger.With(args...),
-		handler: l.handler,
-	}
+	return Default().With(args...)
 }
 
 // WithGroup returns a new Logger with the given group name.
 func WithGroup(name string) *Logger {
-	l := Default()
-	return &Logger{
-		Logger:  l.Logger.WithGroup(name),
-		handler: l.handler,
-	}
+	return Default().WithGroup(name)
 }
 
 // Logger methods
 
 // Debug logs at DEBUG level with context.
 func (l *Logger) Debug(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, l.Logger, slog.LevelDebug, msg, args...)
+	logWithCaller(ctx, l, slog.LevelDebug, msg, args...)
 }
 
 // Info logs at INFO level with context.
 func (l *Logger) Info(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, l.Logger, slog.LevelInfo, msg, args...)
+	logWithCaller(ctx, l, slog.LevelInfo, msg, args...)
 }
 
 // Warn logs at WARN level with context.
 func (l *Logger) Warn(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, l.Logger, slog.LevelWarn, msg, args...)
+	logWithCaller(ctx, l, slog.LevelWarn, msg, args...)
 }
 
 // Error logs at ERROR level with context.
 func (l *Logger) Error(ctx context.Context, msg string, args ...any) {
-	logWithCaller(ctx, l.Logger, slog.LevelError, msg, args...)
+	logWithCaller(ctx, l, slog.LevelError, msg, args...)
 }
 
 // With returns a new Logger with the given attributes.
 func (l *Logger) With(args ...any) *Logger {
-	return &Logger{
-		Logger:  l.Logger.With(args...),
-		handler: l.handler,
-	}
+	return l.derive(l.Logger.With(args...))
 }
 
 // WithGroup returns a new Logger with the given group name.
 func (l *Logger) WithGroup(name string) *Logger {
-	return &Logger{
-		Logger:  l.Logger.WithGroup(name),
-		handler: l.handler,
+	return l.derive(l.Logger.WithGroup(name))
+}
+
+// OnError returns a new Logger that calls fn when a handler fails to write
+// a record, overriding the global hook set with OnError.
+func (l *Logger) OnError(fn ErrorHook) *Logger {
+	l2 := l.derive(l.Logger)
+	l2.onError = fn
+	return l2
+}
+
+// Level returns the minimum level configured by Init.
+func (l *Logger) Level() slog.Level {
+	if l.level == nil {
+		return slog.LevelInfo
 	}
+	return l.level.Level()
+}
+
+// derive returns a copy of l that logs through sl.
+func (l *Logger) derive(sl *slog.Logger) *Logger {
+	l2 := *l
+	l2.Logger = sl
+	return &l2
 }
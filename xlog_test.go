@@ -3,11 +3,14 @@ package xlog_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log/slog"
 	"strings"
 	"testing"
 
 	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogtest"
 )
 
 func TestInit(t *testing.T) {
@@ -30,6 +33,63 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestInitE(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.InitE(
+		xlog.WithOutput(&buf),
+		xlog.WithEnvironment("staging"),
+		xlog.WithFormat("xml"),
+	)
+	if err == nil || !strings.Contains(err.Error(), `unknown environment "staging"`) || !strings.Contains(err.Error(), `unknown format "xml"`) {
+		t.Errorf("expected errors for the environment and format, got: %v", err)
+	}
+	if logger == nil || xlog.Default() != logger {
+		t.Fatal("expected the logger to be installed despite the errors")
+	}
+	logger.Info(context.Background(), "still logging")
+	if !strings.Contains(buf.String(), "still logging") {
+		t.Errorf("expected output, got: %q", buf.String())
+	}
+
+	if _, err := xlog.InitE(xlog.WithOutput(&buf), xlog.WithEnvironment(xlog.Production)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	var global bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&global))
+	def := xlog.Default()
+
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithLevel(slog.LevelWarn),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xlog.Default() != def || slog.Default() != def.Logger {
+		t.Error("expected New to leave the defaults alone")
+	}
+
+	ctx := context.Background()
+	logger.Info(ctx, "dropped")
+	logger.Named("db").Info(ctx, "dropped too")
+	logger.Warn(ctx, "kept")
+	xlog.SetLoggerLevel("", slog.LevelError)
+	defer xlog.SetLoggerLevel("", slog.LevelInfo)
+	logger.Named("db").Warn(ctx, "kept too")
+
+	if strings.Contains(buf.String(), "dropped") || !strings.Contains(buf.String(), "kept") || !strings.Contains(buf.String(), "kept too") {
+		t.Errorf("expected the logger's own level, got: %s", buf.String())
+	}
+	if global.Len() > 0 {
+		t.Errorf("expected nothing on the default output, got: %s", global.String())
+	}
+}
+
 func TestContextPropagation(t *testing.T) {
 	var buf bytes.Buffer
 	_ = xlog.Init(
@@ -53,6 +113,26 @@ func TestContextPropagation(t *testing.T) {
 	}
 }
 
+func TestContextAttrsOrder(t *testing.T) {
+	for _, format := range []xlog.Format{xlog.ColorText, xlog.StdJSON, xlog.FastJSON} {
+		var buf bytes.Buffer
+		_ = xlog.Init(
+			xlog.WithFormat(format),
+			xlog.WithOutput(&buf),
+			xlog.WithSource(false),
+		)
+
+		ctx := xlog.WithTraceID(context.Background(), "trace-123")
+		xlog.WithGroup("g").Info(ctx, "ordered", "key", "value")
+
+		output := buf.String()
+		i, j := strings.Index(output, "trace-123"), strings.Index(output, "value")
+		if i < 0 || j < 0 || i > j {
+			t.Errorf("%s: expected context attrs before record attrs, got: %s", format, output)
+		}
+	}
+}
+
 func TestProductionJSON(t *testing.T) {
 	var buf bytes.Buffer
 	_ = xlog.Init(
@@ -116,6 +196,315 @@ func TestLogLevels(t *testing.T) {
 	}
 }
 
+func TestOnError(t *testing.T) {
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(failingWriter{}),
+		xlog.WithSource(false),
+	)
+
+	var global []string
+	xlog.OnError(func(err error, r slog.Record) {
+		global = append(global, r.Message)
+	})
+	defer xlog.OnError(nil)
+
+	ctx := context.Background()
+	xlog.Error(ctx, "global hook")
+	if len(global) != 1 || global[0] != "global hook" {
+		t.Errorf("expected global hook to see the record, got: %v", global)
+	}
+
+	var local []string
+	logger := xlog.Default().OnError(func(err error, r slog.Record) {
+		local = append(local, err.Error())
+	}).With("k", "v")
+	logger.Error(ctx, "local hook")
+	if len(local) != 1 || local[0] != "primary down" {
+		t.Errorf("expected logger hook to see the error, got: %v", local)
+	}
+	if len(global) != 1 {
+		t.Errorf("expected logger hook to override global hook, got: %v", global)
+	}
+}
+
+func TestServiceMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithService("api"),
+		xlog.WithVersion("1.2.3"),
+		xlog.WithInstanceID("pod-7"),
+	)
+
+	xlog.Named("db").Info(context.Background(), "with metadata")
+
+	want := `"service":{"name":"api","version":"1.2.3","instance_id":"pod-7"}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("expected output to contain %s, got: %s", want, buf.String())
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+		xlog.WithBuildInfo(true),
+	)
+
+	xlog.Info(context.Background(), "with build info")
+
+	if !strings.Contains(buf.String(), `"build":{"go_version":"go`) {
+		t.Errorf("expected build info, got: %s", buf.String())
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithSource(false),
+	)
+
+	ctx := context.Background()
+	if xlog.FromContext(ctx) != xlog.Default() {
+		t.Error("expected the default logger without one in the context")
+	}
+
+	ctx = xlog.IntoContext(ctx, xlog.With("request_id", "req-1"))
+	xlog.FromContext(ctx).Info(ctx, "from context")
+	if !strings.Contains(buf.String(), `"request_id":"req-1"`) {
+		t.Errorf("expected the request-scoped logger, got: %s", buf.String())
+	}
+}
+
+func TestSecurity(t *testing.T) {
+	var app, sec bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithLevel(slog.LevelError),
+		xlog.WithOutput(&app),
+		xlog.WithSecurityOutput(&sec),
+		xlog.WithSource(false),
+	)
+
+	ctx := xlog.WithUserID(context.Background(), "alice")
+	xlog.Security().Info(ctx, "login failed", "reason", "bad password")
+
+	if app.Len() > 0 {
+		t.Errorf("expected security events to bypass the application output, got: %s", app.String())
+	}
+	output := sec.String()
+	for _, want := range []string{`"msg":"login failed"`, `"channel":"security"`, `"user_id":"alice"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected security output to contain %s, got: %s", want, output)
+		}
+	}
+
+	// Without a security output, events go through the default logger
+	_ = xlog.Init(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&app),
+		xlog.WithSource(false),
+	)
+	xlog.Security().Warn(ctx, "privilege changed")
+	if !strings.Contains(app.String(), `"channel":"security"`) {
+		t.Errorf("expected security events in the default output, got: %s", app.String())
+	}
+}
+
+func TestSecurityWithHandler(t *testing.T) {
+	var app, sec bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithHandler(slog.NewJSONHandler(&app, nil)),
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithSecurityOutput(&sec),
+	)
+
+	xlog.Security().Info(context.Background(), "login failed")
+	if app.Len() > 0 {
+		t.Errorf("expected security events to bypass the handler, got: %s", app.String())
+	}
+	if !strings.Contains(sec.String(), `"channel":"security"`) {
+		t.Errorf("expected the event in the security output, got: %s", sec.String())
+	}
+}
+
+func TestWithAttrTransformers(t *testing.T) {
+	redact := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == "password" {
+			return slog.String(a.Key, "***")
+		}
+		return a
+	}
+	drop := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == "internal" {
+			return slog.Attr{}
+		}
+		return a
+	}
+	upper := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.MessageKey {
+			return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+		}
+		return a
+	}
+
+	for _, format := range []xlog.Format{xlog.ColorText, xlog.StdJSON, xlog.FastJSON} {
+		var buf bytes.Buffer
+		_ = xlog.Init(
+			xlog.WithFormat(format),
+			xlog.WithOutput(&buf),
+			xlog.WithSource(false),
+			xlog.WithAttrTransformers(redact, drop),
+			xlog.WithAttrTransformers(upper),
+		)
+
+		xlog.Info(context.Background(), "login", "password", "hunter2", "internal", 1)
+
+		output := buf.String()
+		if strings.Contains(output, "hunter2") || !strings.Contains(output, "***") {
+			t.Errorf("%s: expected password to be redacted, got: %s", format, output)
+		}
+		if strings.Contains(output, "internal") {
+			t.Errorf("%s: expected attribute to be dropped, got: %s", format, output)
+		}
+		if !strings.Contains(output, "LOGIN") {
+			t.Errorf("%s: expected message to be transformed, got: %s", format, output)
+		}
+	}
+}
+
+func TestWithHandlerOptions(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithFormat(xlog.StdJSON),
+		xlog.WithOutput(&buf),
+		xlog.WithHandlerOptions(&slog.HandlerOptions{
+			Level: slog.LevelWarn,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == "token" {
+					return slog.String(a.Key, "***")
+				}
+				return a
+			},
+		}),
+		xlog.WithReplaceAttr(func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.MessageKey {
+				return slog.String(a.Key, strings.ToUpper(a.Value.String()))
+			}
+			return a
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	logger.Info(ctx, "dropped")
+	logger.Warn(ctx, "kept", "token", "secret")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected a single record, got: %s", buf.String())
+	}
+	if rec["msg"] != "KEPT" || rec["token"] != "***" {
+		t.Errorf("expected both ReplaceAttr functions to apply, got: %s", buf.String())
+	}
+	if _, ok := rec["source"]; ok {
+		t.Errorf("expected AddSource false to drop the source, got: %s", buf.String())
+	}
+}
+
+func TestWithLevelOutput(t *testing.T) {
+	var all, info, errs bytes.Buffer
+	logger, err := xlog.New(
+		xlog.WithLevel(slog.LevelDebug),
+		xlog.WithOutput(&all),
+		xlog.WithLevelOutput(slog.LevelInfo, &info),
+		xlog.WithLevelOutput(slog.LevelError, &errs),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	logger.Debug(ctx, "cache miss")
+	logger.Info(ctx, "request")
+	logger.Error(ctx, "failed")
+
+	counts := map[*bytes.Buffer]int{&all: 3, &info: 2, &errs: 1}
+	for buf, want := range counts {
+		if got := strings.Count(buf.String(), "\n"); got != want {
+			t.Errorf("expected %d records, got %d: %s", want, got, buf.String())
+		}
+	}
+	if strings.Contains(info.String(), "cache miss") || !strings.Contains(errs.String(), "failed") {
+		t.Errorf("unexpected routing: info=%q errors=%q", info.String(), errs.String())
+	}
+}
+
+func TestWithFieldNames(t *testing.T) {
+	for _, format := range []xlog.Format{xlog.StdJSON, xlog.FastJSON} {
+		var buf bytes.Buffer
+		_ = xlog.Init(
+			xlog.WithFormat(format),
+			xlog.WithOutput(&buf),
+			xlog.WithFieldNames(xlog.FieldNames{Time: "@timestamp", Level: "severity", Message: "message"}),
+		)
+
+		xlog.Info(context.Background(), "renamed", slog.Group("g", "msg", "nested"))
+
+		var rec map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"@timestamp", "severity", "message", "source"} {
+			if _, ok := rec[key]; !ok {
+				t.Errorf("%s: expected key %q, got: %s", format, key, buf.String())
+			}
+		}
+		if g, _ := rec["g"].(map[string]any); g["msg"] != "nested" {
+			t.Errorf("%s: expected keys in groups to be kept, got: %s", format, buf.String())
+		}
+	}
+}
+
+func TestGoldenFormats(t *testing.T) {
+	formats := map[string]func(w *bytes.Buffer) slog.Handler{
+		"color": func(w *bytes.Buffer) slog.Handler {
+			return xlog.NewColorHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		},
+		"fastjson": func(w *bytes.Buffer) slog.Handler {
+			return xlog.NewFastJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		},
+		"json": func(w *bytes.Buffer) slog.Handler {
+			return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+		},
+	}
+
+	for name, newHandler := range formats {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := xlogtest.Deterministic(newHandler(&buf))
+			logger := slog.New(xlog.NewContextHandler(h, xlog.TraceIDKey)).With("service", "api")
+
+			ctx := xlog.WithTraceID(context.Background(), "trace-123")
+			logger.DebugContext(ctx, "debug message", "count", 42, "ratio", 0.5)
+			logger.InfoContext(ctx, "info message", "quoted", "a b", "ok", true)
+			logger.WithGroup("req").WarnContext(ctx, "warn message", "path", "/users", "status", 404)
+			logger.ErrorContext(ctx, "error message", "error", errors.New("boom"))
+
+			xlogtest.AssertGolden(t, "format_"+name, buf.Bytes())
+		})
+	}
+}
+
 func BenchmarkInfo(b *testing.B) {
 	var buf bytes.Buffer
 	_ = xlog.Init(
@@ -135,6 +524,25 @@ func BenchmarkInfo(b *testing.B) {
 	}
 }
 
+func BenchmarkInfoDevelopment(b *testing.B) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Development),
+		xlog.WithOutput(&buf),
+	)
+
+	ctx := context.Background()
+	ctx = xlog.WithTraceID(ctx, "trace-123")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		xlog.Info(ctx, "benchmark message", "iteration", i)
+	}
+}
+
 func BenchmarkInfoParallel(b *testing.B) {
 	var buf bytes.Buffer
 	_ = xlog.Init(
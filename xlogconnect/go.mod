@@ -0,0 +1,9 @@
+module github.com/taro33333/xlog/xlogconnect
+
+go 1.25.5
+
+require (
+	connectrpc.com/connect v1.21.0
+	github.com/taro33333/xlog v0.1.0
+	google.golang.org/protobuf v1.36.11
+)
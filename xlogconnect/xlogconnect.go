@@ -0,0 +1,128 @@
+// Package xlogconnect provides a connect-go interceptor carrying request
+// IDs in headers and logging one record per RPC with xlog. It is a
+// separate module, so xlog itself does not depend on connect-go.
+package xlogconnect
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/taro33333/xlog"
+)
+
+// RPCKey is the group holding the procedure, status code and duration of
+// an RPC.
+const RPCKey = "rpc"
+
+// Interceptor returns a connect.Interceptor for both handlers and clients:
+//
+//	path, handler := greetv1connect.NewGreetServiceHandler(svc,
+//		connect.WithInterceptors(xlogconnect.Interceptor()))
+//	client := greetv1connect.NewGreetServiceClient(http.DefaultClient, url,
+//		connect.WithInterceptors(xlogconnect.Interceptor()))
+//
+// Handlers read the request ID from the X-Request-ID header, or generate
+// one, add it to the context and log one record per RPC, once streams
+// end. The record holds the procedure, status code and duration under
+// RPCKey, and the error, at INFO for success, WARN for errors caused by
+// the client and ERROR for the others. Clients copy the request ID of the
+// context into the header, so the server logs the calls of a request
+// under the same ID.
+func Interceptor() connect.Interceptor {
+	return interceptor{}
+}
+
+type interceptor struct{}
+
+func (interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			outgoing(ctx, req.Header())
+			return next(ctx, req)
+		}
+		start := time.Now()
+		ctx = withRequestID(ctx, req.Header())
+		resp, err := next(ctx, req)
+		logRPC(ctx, req.Spec().Procedure, time.Since(start), err)
+		return resp, err
+	}
+}
+
+func (interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		outgoing(ctx, conn.RequestHeader())
+		return conn
+	}
+}
+
+func (interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		start := time.Now()
+		ctx = withRequestID(ctx, conn.RequestHeader())
+		err := next(ctx, conn)
+		logRPC(ctx, conn.Spec().Procedure, time.Since(start), err)
+		return err
+	}
+}
+
+// withRequestID returns ctx with the request ID of header, or a new one.
+func withRequestID(ctx context.Context, header http.Header) context.Context {
+	id := header.Get(xlog.RequestIDHeader)
+	if !xlog.ValidRequestID(id) {
+		id = xlog.NewRequestID()
+	}
+	return xlog.WithRequestID(ctx, id)
+}
+
+// outgoing sets the request ID of ctx, if any, in header.
+func outgoing(ctx context.Context, header http.Header) {
+	if id := xlog.RequestIDFromContext(ctx); id != "" {
+		header.Set(xlog.RequestIDHeader, id)
+	}
+}
+
+// logRPC logs the call of procedure, served in dur with error err.
+func logRPC(ctx context.Context, procedure string, dur time.Duration, err error) {
+	code := "ok"
+	level := slog.LevelInfo
+	if err != nil {
+		c := connect.CodeOf(err)
+		if errors.Is(err, context.Canceled) {
+			c = connect.CodeCanceled
+		}
+		code = c.String()
+		level = levelOf(c)
+	}
+	l := xlog.FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), level, "rpc", 0)
+	r.AddAttrs(slog.Group(RPCKey,
+		slog.String("procedure", procedure),
+		slog.String("code", code),
+		slog.Duration("duration", dur),
+	))
+	if err != nil {
+		r.AddAttrs(xlog.Err(err))
+	}
+	_ = l.Logger.Handler().Handle(ctx, r)
+}
+
+// levelOf returns the level of an RPC failing with code.
+func levelOf(code connect.Code) slog.Level {
+	switch code {
+	case connect.CodeCanceled, connect.CodeInvalidArgument, connect.CodeNotFound, connect.CodeAlreadyExists,
+		connect.CodePermissionDenied, connect.CodeUnauthenticated, connect.CodeResourceExhausted,
+		connect.CodeFailedPrecondition, connect.CodeAborted, connect.CodeOutOfRange:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
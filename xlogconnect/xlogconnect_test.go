@@ -0,0 +1,85 @@
+package xlogconnect_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogconnect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type record struct {
+	Level     string `json:"level"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"request_id"`
+	Error     string `json:"error"`
+	RPC       struct {
+		Procedure string `json:"procedure"`
+		Code      string `json:"code"`
+	} `json:"rpc"`
+}
+
+const procedure = "/greet.v1.GreetService/Greet"
+
+func TestInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	var got string
+	mux := http.NewServeMux()
+	mux.Handle(procedure, connect.NewUnaryHandler(procedure,
+		func(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+			got = xlog.RequestIDFromContext(ctx)
+			switch req.Msg.Value {
+			case "missing":
+				return nil, connect.NewError(connect.CodeNotFound, errors.New("no such user"))
+			case "broken":
+				return nil, connect.NewError(connect.CodeInternal, errors.New("database down"))
+			}
+			return connect.NewResponse(wrapperspb.String("hello " + req.Msg.Value)), nil
+		},
+		connect.WithInterceptors(xlogconnect.Interceptor()),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+		srv.Client(), srv.URL+procedure, connect.WithInterceptors(xlogconnect.Interceptor()))
+	ctx := xlog.WithRequestID(context.Background(), "req-1")
+	if _, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("gopher"))); err != nil {
+		t.Fatal(err)
+	}
+	if got != "req-1" {
+		t.Errorf("expected the request ID of the client, got %q", got)
+	}
+	for _, name := range []string{"missing", "broken"} {
+		if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String(name))); err == nil {
+			t.Errorf("expected %s to fail", name)
+		}
+	}
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []struct{ level, code, id string }{
+		{"INFO", "ok", "req-1"},
+		{"WARN", "not_found", ""},
+		{"ERROR", "internal", ""},
+	} {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Msg != "rpc" || rec.Level != want.level || rec.RPC.Code != want.code || rec.RPC.Procedure != procedure {
+			t.Errorf("expected %s at %s, got: %+v", want.code, want.level, rec)
+		}
+		if rec.RequestID == "" || want.id != "" && rec.RequestID != want.id {
+			t.Errorf("expected request ID %q, got %q", want.id, rec.RequestID)
+		}
+	}
+}
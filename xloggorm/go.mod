@@ -0,0 +1,14 @@
+module github.com/taro33333/xlog/xloggorm
+
+go 1.25.5
+
+require (
+	github.com/taro33333/xlog v0.1.0
+	gorm.io/gorm v1.31.2
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/text v0.20.0 // indirect
+)
@@ -0,0 +1,114 @@
+// Package xloggorm logs GORM's queries and messages through xlog. Its
+// Logger implements GORM's logger.Interface:
+//
+//	db, err := gorm.Open(dialector, &gorm.Config{
+//		Logger: xloggorm.New(&xloggorm.Options{
+//			IgnoreErrors: []error{gorm.ErrRecordNotFound},
+//		}),
+//	})
+//
+// Records use the logger from the context GORM is given with
+// db.WithContext, so request IDs and other context attributes follow. It
+// is a separate module, so xlog itself does not depend on GORM.
+package xloggorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"gorm.io/gorm/logger"
+)
+
+// Options configures a Logger.
+type Options struct {
+	// Queries logs the queries, with their slow query thresholds. By
+	// default slow queries take over 200ms.
+	Queries *xlog.QueryLoggerOptions
+
+	// IgnoreErrors lists errors that are not failures, such as
+	// gorm.ErrRecordNotFound; queries returning them are logged as if
+	// they had succeeded.
+	IgnoreErrors []error
+
+	// LogMode is the initial log mode, Info by default: every query is
+	// logged, at DEBUG unless slow or failed. Warn logs only slow and
+	// failed queries, Error only failed ones.
+	LogMode logger.LogLevel
+}
+
+// Logger implements GORM's logger.Interface.
+type Logger struct {
+	queries *xlog.QueryLogger
+	ignore  []error
+	mode    logger.LogLevel
+}
+
+var _ logger.Interface = (*Logger)(nil)
+
+// New creates a Logger.
+func New(opts *Options) *Logger {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.LogMode == 0 {
+		o.LogMode = logger.Info
+	}
+	return &Logger{queries: xlog.NewQueryLogger(o.Queries), ignore: o.IgnoreErrors, mode: o.LogMode}
+}
+
+// LogMode returns a copy of l in the given log mode.
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	l2 := *l
+	l2.mode = level
+	return &l2
+}
+
+// Info logs a message formatted by GORM at INFO.
+func (l *Logger) Info(ctx context.Context, msg string, data ...any) {
+	if l.mode >= logger.Info {
+		xlog.FromContext(ctx).Info(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn logs a message formatted by GORM at WARN.
+func (l *Logger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.mode >= logger.Warn {
+		xlog.FromContext(ctx).Warn(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error logs a message formatted by GORM at ERROR.
+func (l *Logger) Error(ctx context.Context, msg string, data ...any) {
+	if l.mode >= logger.Error {
+		xlog.FromContext(ctx).Error(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs the query begun at begin, as xlog.QueryLogger does, if the
+// log mode allows it.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.mode <= logger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	for _, ignored := range l.ignore {
+		if errors.Is(err, ignored) {
+			err = nil
+			break
+		}
+	}
+
+	if err == nil && l.mode < logger.Warn {
+		return
+	}
+
+	sql, rows := fc()
+	if threshold := l.queries.Threshold(sql); err == nil && l.mode < logger.Info && (threshold < 0 || elapsed <= threshold) {
+		return
+	}
+	l.queries.Log(ctx, sql, nil, rows, elapsed, err)
+}
@@ -0,0 +1,65 @@
+package xloggorm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xloggorm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(slog.LevelDebug), xlog.WithSource(false))
+	ctx := xlog.WithRequestID(context.Background(), "req-1")
+
+	l := xloggorm.New(&xloggorm.Options{
+		Queries:      &xlog.QueryLoggerOptions{SlowThreshold: time.Second},
+		IgnoreErrors: []error{gorm.ErrRecordNotFound},
+	})
+	query := func(sql string) func() (string, int64) {
+		return func() (string, int64) { return sql, 1 }
+	}
+	slow := time.Now().Add(-2 * time.Second)
+
+	tests := []struct {
+		mode logger.LogLevel
+		want []string
+	}{
+		{logger.Info, []string{`"level":"DEBUG","msg":"query"`, `"level":"DEBUG","msg":"query"`, `"level":"WARN","msg":"slow query"`, `"level":"ERROR","msg":"query failed"`}},
+		{logger.Warn, []string{`"level":"WARN","msg":"slow query"`, `"level":"ERROR","msg":"query failed"`}},
+		{logger.Error, []string{`"level":"ERROR","msg":"query failed"`}},
+		{logger.Silent, nil},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		ml := l.LogMode(tt.mode)
+		ml.Trace(ctx, time.Now(), query("SELECT 1"), nil)
+		ml.Trace(ctx, time.Now(), query("SELECT 2"), gorm.ErrRecordNotFound)
+		ml.Trace(ctx, slow, query("SELECT 3"), nil)
+		ml.Trace(ctx, time.Now(), query("SELECT 4"), errors.New("deadlock"))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(tt.want) == 0 {
+			if buf.Len() != 0 {
+				t.Errorf("mode %d: expected no records, got: %s", tt.mode, buf.String())
+			}
+			continue
+		}
+		if len(lines) != len(tt.want) {
+			t.Fatalf("mode %d: expected %d records, got: %s", tt.mode, len(tt.want), buf.String())
+		}
+		for i, w := range tt.want {
+			if !strings.Contains(lines[i], w) || !strings.Contains(lines[i], `"request_id":"req-1"`) {
+				t.Errorf("mode %d: expected %s with the request ID, got: %s", tt.mode, w, lines[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package xloggrpc provides gRPC interceptors carrying request IDs in
+// metadata and logging one record per RPC with xlog. It is a separate
+// module, so xlog itself does not depend on gRPC.
+package xloggrpc
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata is the metadata key carrying the request ID.
+const RequestIDMetadata = "x-request-id"
+
+// RPCKey is the group holding the method, status code and duration of an
+// RPC.
+const RPCKey = "rpc"
+
+// UnaryServerInterceptor returns an interceptor that reads the request ID
+// from the incoming metadata, or generates one, adds it to the context and
+// logs one record per call, as StreamServerInterceptor does for streams:
+//
+//	s := grpc.NewServer(
+//		grpc.UnaryInterceptor(xloggrpc.UnaryServerInterceptor()),
+//		grpc.StreamInterceptor(xloggrpc.StreamServerInterceptor()),
+//	)
+//
+// The record holds the method, status code and duration under RPCKey,
+// and the error, at INFO for OK, WARN for errors caused by the client and
+// ERROR for the others.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withRequestID(ctx)
+		resp, err := next(ctx, req)
+		logRPC(ctx, info.FullMethod, time.Since(start), err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns the streaming counterpart of
+// UnaryServerInterceptor, logging one record per stream once it ends.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withRequestID(ss.Context())
+		err := next(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logRPC(ctx, info.FullMethod, time.Since(start), err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns an interceptor copying the request ID of
+// the context into the outgoing metadata, so the server logs the calls of
+// a request under the same ID.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of
+// UnaryClientInterceptor.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// GatewayMetadata returns metadata carrying the request ID of r, as set by
+// xlog.RequestIDMiddleware. It has the signature of gRPC-Gateway's
+// runtime.WithMetadata option:
+//
+//	gw := runtime.NewServeMux(runtime.WithMetadata(xloggrpc.GatewayMetadata))
+//	http.ListenAndServe(":8080", xlog.RequestIDMiddleware(gw))
+func GatewayMetadata(_ context.Context, r *http.Request) metadata.MD {
+	id := xlog.RequestIDFromContext(r.Context())
+	if id == "" {
+		return nil
+	}
+	return metadata.Pairs(RequestIDMetadata, id)
+}
+
+// serverStream is a grpc.ServerStream with the context of the interceptor.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context { return s.ctx }
+
+// withRequestID returns ctx with the request ID of its incoming metadata,
+// or a new one.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if ids := metadata.ValueFromIncomingContext(ctx, RequestIDMetadata); len(ids) > 0 {
+		id = ids[0]
+	}
+	if !xlog.ValidRequestID(id) {
+		id = xlog.NewRequestID()
+	}
+	return xlog.WithRequestID(ctx, id)
+}
+
+// outgoing returns ctx with its request ID, if any, in the outgoing
+// metadata.
+func outgoing(ctx context.Context) context.Context {
+	if id := xlog.RequestIDFromContext(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, id)
+	}
+	return ctx
+}
+
+// logRPC logs the call of method, served in dur with error err.
+func logRPC(ctx context.Context, method string, dur time.Duration, err error) {
+	code := status.Code(err)
+	level := levelOf(code)
+	l := xlog.FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), level, "rpc", 0)
+	r.AddAttrs(slog.Group(RPCKey,
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", dur),
+	))
+	if err != nil {
+		r.AddAttrs(xlog.Err(err))
+	}
+	_ = l.Logger.Handler().Handle(ctx, r)
+}
+
+// levelOf returns the level of an RPC ending with code.
+func levelOf(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Canceled, codes.InvalidArgument, codes.NotFound, codes.AlreadyExists,
+		codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted,
+		codes.FailedPrecondition, codes.Aborted, codes.OutOfRange:
+		return slog.LevelWarn
+	default:
+		return slog.LevelError
+	}
+}
@@ -0,0 +1,122 @@
+package xloggrpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xloggrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type record struct {
+	Level     string `json:"level"`
+	Msg       string `json:"msg"`
+	RequestID string `json:"request_id"`
+	Error     string `json:"error"`
+	RPC       struct {
+		Method string `json:"method"`
+		Code   string `json:"code"`
+	} `json:"rpc"`
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	intercept := xloggrpc.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/greet.v1.GreetService/Greet"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(xloggrpc.RequestIDMetadata, "req-1"))
+
+	var got string
+	_, _ = intercept(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		got = xlog.RequestIDFromContext(ctx)
+		return nil, nil
+	})
+	if got != "req-1" {
+		t.Errorf("expected the request ID of the metadata, got %q", got)
+	}
+	_, _ = intercept(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.NotFound, "no such user")
+	})
+	_, _ = intercept(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.Internal, "database down")
+	})
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []struct{ level, code, id string }{
+		{"INFO", "OK", "req-1"},
+		{"WARN", "NotFound", ""},
+		{"ERROR", "Internal", ""},
+	} {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Msg != "rpc" || rec.Level != want.level || rec.RPC.Code != want.code || rec.RPC.Method != info.FullMethod {
+			t.Errorf("expected %s at %s, got: %+v", want.code, want.level, rec)
+		}
+		if rec.RequestID == "" || want.id != "" && rec.RequestID != want.id {
+			t.Errorf("expected request ID %q, got %q", want.id, rec.RequestID)
+		}
+	}
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithSource(false))
+
+	intercept := xloggrpc.StreamServerInterceptor()
+	info := &grpc.StreamServerInfo{FullMethod: "/greet.v1.GreetService/Chat"}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(xloggrpc.RequestIDMetadata, "req-2"))
+
+	var got string
+	_ = intercept(nil, stream{ctx: ctx}, info, func(srv any, ss grpc.ServerStream) error {
+		got = xlog.RequestIDFromContext(ss.Context())
+		return nil
+	})
+	if got != "req-2" {
+		t.Errorf("expected the request ID of the metadata, got %q", got)
+	}
+	var rec record
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.RPC.Method != info.FullMethod || rec.RequestID != "req-2" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	intercept := xloggrpc.UnaryClientInterceptor()
+	ctx := xlog.WithRequestID(context.Background(), "req-3")
+	_ = intercept(ctx, "/greet.v1.GreetService/Greet", nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if got := md.Get(xloggrpc.RequestIDMetadata); len(got) != 1 || got[0] != "req-3" {
+			t.Errorf("expected the request ID in the outgoing metadata, got %v", got)
+		}
+		return nil
+	})
+}
+
+func TestGatewayMetadata(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/greet", nil)
+	r = r.WithContext(xlog.WithRequestID(r.Context(), "req-4"))
+	md := xloggrpc.GatewayMetadata(r.Context(), r)
+	if got := md.Get(xloggrpc.RequestIDMetadata); len(got) != 1 || got[0] != "req-4" {
+		t.Errorf("expected the request ID, got %v", got)
+	}
+}
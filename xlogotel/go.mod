@@ -0,0 +1,17 @@
+module github.com/taro33333/xlog/xlogotel
+
+go 1.25.5
+
+require (
+	github.com/taro33333/xlog v0.1.0
+	go.opentelemetry.io/otel/log v0.11.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+)
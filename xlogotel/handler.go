@@ -0,0 +1,193 @@
+// Package xlogotel bridges xlog and the OpenTelemetry logs API in both
+// directions: NewHandler sends xlog records to an OTel LoggerProvider, and
+// NewLoggerProvider routes records emitted through the OTel API into xlog
+// handlers. It is a separate module, so xlog itself does not depend on
+// OpenTelemetry.
+package xlogotel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/log"
+)
+
+// DefaultName is the instrumentation scope of the OTel logger used by
+// NewHandler.
+const DefaultName = "github.com/taro33333/xlog"
+
+// HandlerOptions configures a Handler.
+type HandlerOptions struct {
+	// Name is the instrumentation scope of the OTel logger. Defaults to
+	// DefaultName.
+	Name string
+
+	// Level is the minimum level to emit. Defaults to slog.LevelInfo.
+	Level slog.Leveler
+}
+
+// Handler is an slog.Handler emitting records to an OTel logger, so they
+// reach the processors and exporters of its provider. Tee sends xlog
+// records there beside the main output:
+//
+//	xlog.Init(xlog.WithMiddleware(xlog.Tee(xlogotel.NewHandler(provider, nil))))
+//
+// Levels map to severities as the OTel specification does for slog, so
+// xlog.LevelCritical is FATAL. Groups become maps, and the context is
+// passed to the logger, which reads the span from it.
+type Handler struct {
+	logger log.Logger
+	level  slog.Leveler
+	goas   []groupOrAttrs
+}
+
+// groupOrAttrs is a group opened by WithGroup, or attributes added by
+// WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewHandler creates a Handler emitting to a logger of provider.
+func NewHandler(provider log.LoggerProvider, opts *HandlerOptions) *Handler {
+	var o HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Name == "" {
+		o.Name = DefaultName
+	}
+	if o.Level == nil {
+		o.Level = slog.LevelInfo
+	}
+	return &Handler{logger: provider.Logger(o.Name), level: o.Level}
+}
+
+// Enabled reports whether records at level reach the level of the handler
+// and are accepted by the logger.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.logger.Enabled(ctx, log.EnabledParameters{Severity: severity(level)})
+}
+
+// Handle emits r to the logger.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	var rec log.Record
+	rec.SetTimestamp(r.Time)
+	rec.SetSeverity(severity(r.Level))
+	rec.SetSeverityText(r.Level.String())
+	rec.SetBody(log.StringValue(r.Message))
+
+	kvs := make([]log.KeyValue, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		kvs = appendAttr(kvs, a)
+		return true
+	})
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		if g := h.goas[i].group; g != "" {
+			if len(kvs) > 0 {
+				kvs = []log.KeyValue{log.Map(g, kvs...)}
+			}
+			continue
+		}
+		var attrs []log.KeyValue
+		for _, a := range h.goas[i].attrs {
+			attrs = appendAttr(attrs, a)
+		}
+		kvs = append(attrs, kvs...)
+	}
+	rec.AddAttributes(kvs...)
+
+	h.logger.Emit(ctx, rec)
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return h.with(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.with(groupOrAttrs{group: name})
+}
+
+func (h *Handler) with(goa groupOrAttrs) *Handler {
+	h2 := *h
+	h2.goas = append(h.goas[:len(h.goas):len(h.goas)], goa)
+	return &h2
+}
+
+// severity returns the OTel severity of level: DEBUG, INFO, WARN and ERROR
+// map to the first severity of their range, and levels in between to the
+// ones in between.
+func severity(level slog.Level) log.Severity {
+	return log.Severity(min(max(int(level)+9, int(log.SeverityTrace1)), int(log.SeverityFatal4)))
+}
+
+// appendAttr appends a to kvs as an OTel key-value, skipping empty
+// attributes and inlining groups with empty keys.
+func appendAttr(kvs []log.KeyValue, a slog.Attr) []log.KeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return kvs
+	}
+	if a.Value.Kind() == slog.KindGroup && a.Key == "" {
+		for _, ga := range a.Value.Group() {
+			kvs = appendAttr(kvs, ga)
+		}
+		return kvs
+	}
+	return append(kvs, log.KeyValue{Key: a.Key, Value: otelValue(a.Value)})
+}
+
+// otelValue converts v to an OTel value. Durations are logged in
+// nanoseconds, times as RFC 3339 strings, errors and Stringers as their
+// text and other values as %+v.
+func otelValue(v slog.Value) log.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return log.StringValue(v.String())
+	case slog.KindInt64:
+		return log.Int64Value(v.Int64())
+	case slog.KindUint64:
+		if u := v.Uint64(); u <= math.MaxInt64 {
+			return log.Int64Value(int64(u))
+		}
+		return log.StringValue(v.String())
+	case slog.KindFloat64:
+		return log.Float64Value(v.Float64())
+	case slog.KindBool:
+		return log.BoolValue(v.Bool())
+	case slog.KindDuration:
+		return log.Int64Value(v.Duration().Nanoseconds())
+	case slog.KindTime:
+		return log.StringValue(v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		var kvs []log.KeyValue
+		for _, a := range v.Group() {
+			kvs = appendAttr(kvs, a)
+		}
+		return log.MapValue(kvs...)
+	}
+	switch x := v.Any().(type) {
+	case nil:
+		return log.Value{}
+	case error:
+		return log.StringValue(x.Error())
+	case fmt.Stringer:
+		return log.StringValue(x.String())
+	case []byte:
+		return log.BytesValue(x)
+	default:
+		return log.StringValue(fmt.Sprintf("%+v", x))
+	}
+}
@@ -0,0 +1,134 @@
+package xlogotel
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+)
+
+// LoggerProvider is an OTel LoggerProvider handing out loggers that write
+// through an slog.Handler, so libraries logging with the OTel API share
+// the outputs of xlog:
+//
+//	global.SetLoggerProvider(xlogotel.NewLoggerProvider(nil))
+//
+// Severities map back to levels, the body becomes the message, or the
+// "body" attribute if it is not a string, and the instrumentation scope is
+// logged under xlog.LoggerKey.
+type LoggerProvider struct {
+	embedded.LoggerProvider
+
+	h slog.Handler
+}
+
+// NewLoggerProvider creates a LoggerProvider writing to h, or to the
+// handler of the default logger at the time of each record if h is nil.
+func NewLoggerProvider(h slog.Handler) *LoggerProvider {
+	return &LoggerProvider{h: h}
+}
+
+// Logger returns a logger for the instrumentation scope name.
+func (p *LoggerProvider) Logger(name string, _ ...log.LoggerOption) log.Logger {
+	return &logger{p: p, name: name}
+}
+
+func (p *LoggerProvider) handler() slog.Handler {
+	if p.h != nil {
+		return p.h
+	}
+	return xlog.Default().Handler()
+}
+
+// logger is the log.Logger of a LoggerProvider.
+type logger struct {
+	embedded.Logger
+
+	p    *LoggerProvider
+	name string
+}
+
+// Emit writes r to the handler of the provider.
+func (l *logger) Emit(ctx context.Context, r log.Record) {
+	h := l.p.handler()
+	level := slogLevel(r.Severity())
+	if !h.Enabled(ctx, level) {
+		return
+	}
+
+	t := r.Timestamp()
+	if t.IsZero() {
+		t = r.ObservedTimestamp()
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var msg string
+	body := r.Body()
+	if body.Kind() == log.KindString {
+		msg = body.AsString()
+	}
+	rec := slog.NewRecord(t, level, msg, 0)
+	if l.name != "" {
+		rec.AddAttrs(slog.String(xlog.LoggerKey, l.name))
+	}
+	if body.Kind() != log.KindString && body.Kind() != log.KindEmpty {
+		rec.AddAttrs(slog.Attr{Key: "body", Value: slogValue(body)})
+	}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		rec.AddAttrs(slog.Attr{Key: kv.Key, Value: slogValue(kv.Value)})
+		return true
+	})
+	_ = h.Handle(ctx, rec)
+}
+
+// Enabled reports whether the handler of the provider handles records at
+// the severity of param.
+func (l *logger) Enabled(ctx context.Context, param log.EnabledParameters) bool {
+	return l.p.handler().Enabled(ctx, slogLevel(param.Severity))
+}
+
+// slogLevel returns the level of sev, the inverse of severity. Records
+// without a severity are logged at INFO.
+func slogLevel(sev log.Severity) slog.Level {
+	if sev == log.SeverityUndefined {
+		return slog.LevelInfo
+	}
+	return slog.Level(int(sev) - 9)
+}
+
+// slogValue converts v to an slog value. Slices are logged as []any, and
+// maps as groups.
+func slogValue(v log.Value) slog.Value {
+	switch v.Kind() {
+	case log.KindBool:
+		return slog.BoolValue(v.AsBool())
+	case log.KindFloat64:
+		return slog.Float64Value(v.AsFloat64())
+	case log.KindInt64:
+		return slog.Int64Value(v.AsInt64())
+	case log.KindString:
+		return slog.StringValue(v.AsString())
+	case log.KindBytes:
+		return slog.AnyValue(v.AsBytes())
+	case log.KindSlice:
+		vs := v.AsSlice()
+		s := make([]any, len(vs))
+		for i, e := range vs {
+			s[i] = slogValue(e).Any()
+		}
+		return slog.AnyValue(s)
+	case log.KindMap:
+		kvs := v.AsMap()
+		attrs := make([]slog.Attr, len(kvs))
+		for i, kv := range kvs {
+			attrs[i] = slog.Attr{Key: kv.Key, Value: slogValue(kv.Value)}
+		}
+		return slog.GroupValue(attrs...)
+	default:
+		return slog.Value{}
+	}
+}
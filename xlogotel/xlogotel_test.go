@@ -0,0 +1,113 @@
+package xlogotel_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogotel"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/embedded"
+)
+
+// recorder is an OTel LoggerProvider keeping the records emitted to its
+// loggers.
+type recorder struct {
+	embedded.LoggerProvider
+
+	scope   string
+	records []log.Record
+}
+
+func (p *recorder) Logger(name string, _ ...log.LoggerOption) log.Logger {
+	p.scope = name
+	return recordingLogger{p: p}
+}
+
+type recordingLogger struct {
+	embedded.Logger
+
+	p *recorder
+}
+
+func (l recordingLogger) Emit(_ context.Context, r log.Record) { l.p.records = append(l.p.records, r) }
+
+func (recordingLogger) Enabled(context.Context, log.EnabledParameters) bool { return true }
+
+func TestHandler(t *testing.T) {
+	p := &recorder{}
+	l := slog.New(xlogotel.NewHandler(p, nil))
+	l.Debug("hidden")
+	l.With("service", "api").WithGroup("order").Error("payment failed", "id", 42, "err", errors.New("declined"))
+	l.Log(context.Background(), xlog.LevelCritical, "out of disk")
+
+	if p.scope != xlogotel.DefaultName {
+		t.Errorf("expected scope %q, got %q", xlogotel.DefaultName, p.scope)
+	}
+	if len(p.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(p.records))
+	}
+	r := p.records[0]
+	if r.Severity() != log.SeverityError || r.SeverityText() != "ERROR" || r.Body().AsString() != "payment failed" {
+		t.Errorf("unexpected record: %v %q %v", r.Severity(), r.SeverityText(), r.Body())
+	}
+	got := map[string]log.Value{}
+	r.WalkAttributes(func(kv log.KeyValue) bool {
+		got[kv.Key] = kv.Value
+		return true
+	})
+	if got["service"].AsString() != "api" {
+		t.Errorf("expected the attributes of With, got %v", got)
+	}
+	order := map[string]log.Value{}
+	for _, kv := range got["order"].AsMap() {
+		order[kv.Key] = kv.Value
+	}
+	if order["id"].AsInt64() != 42 || order["err"].AsString() != "declined" {
+		t.Errorf("expected the attributes in the order map, got %v", order)
+	}
+	if p.records[1].Severity() != log.SeverityFatal {
+		t.Errorf("expected LevelCritical as FATAL, got %v", p.records[1].Severity())
+	}
+}
+
+func TestLoggerProvider(t *testing.T) {
+	var buf bytes.Buffer
+	p := xlogotel.NewLoggerProvider(slog.NewJSONHandler(&buf, nil))
+	l := p.Logger("github.com/acme/lib")
+
+	var r log.Record
+	r.SetTimestamp(time.Now())
+	r.SetSeverity(log.SeverityDebug)
+	r.SetBody(log.StringValue("hidden"))
+	l.Emit(context.Background(), r)
+	if l.Enabled(context.Background(), log.EnabledParameters{Severity: log.SeverityDebug}) {
+		t.Error("expected DEBUG to be disabled")
+	}
+
+	r.SetSeverity(log.SeverityWarn)
+	r.SetBody(log.StringValue("retrying"))
+	r.AddAttributes(log.Int("attempt", 2), log.Map("peer", log.String("host", "db1")))
+	l.Emit(context.Background(), r)
+
+	var got struct {
+		Level   string `json:"level"`
+		Msg     string `json:"msg"`
+		Logger  string `json:"logger"`
+		Attempt int    `json:"attempt"`
+		Peer    struct {
+			Host string `json:"host"`
+		} `json:"peer"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected one record, got %q: %v", buf.String(), err)
+	}
+	if got.Level != "WARN" || got.Msg != "retrying" || got.Logger != "github.com/acme/lib" || got.Attempt != 2 || got.Peer.Host != "db1" {
+		t.Errorf("unexpected record: %+v", got)
+	}
+}
@@ -0,0 +1,14 @@
+module github.com/taro33333/xlog/xlogpgx
+
+go 1.25.5
+
+require (
+	github.com/jackc/pgx/v5 v5.11.0
+	github.com/taro33333/xlog v0.1.0
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	golang.org/x/text v0.29.0 // indirect
+)
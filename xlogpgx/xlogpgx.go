@@ -0,0 +1,118 @@
+// Package xlogpgx logs pgx queries and batches through xlog.QueryLogger,
+// since pgx bypasses database/sql. Its Tracer implements pgx's
+// QueryTracer and BatchTracer:
+//
+//	cfg.ConnConfig.Tracer = xlogpgx.New(&xlog.QueryLoggerOptions{
+//		SlowThreshold: 100 * time.Millisecond,
+//	})
+//
+// Records use the logger from the query's context, so request IDs and
+// other context attributes follow. It is a separate module, so xlog
+// itself does not depend on pgx.
+package xlogpgx
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/taro33333/xlog"
+)
+
+// Tracer implements pgx's QueryTracer and BatchTracer, keeping each
+// query's state in the context pgx passes along.
+type Tracer struct {
+	queries *xlog.QueryLogger
+}
+
+var (
+	_ pgx.QueryTracer = (*Tracer)(nil)
+	_ pgx.BatchTracer = (*Tracer)(nil)
+)
+
+// New creates a Tracer logging queries as opts describes.
+func New(opts *xlog.QueryLoggerOptions) *Tracer {
+	return &Tracer{queries: xlog.NewQueryLogger(opts)}
+}
+
+type queryKey struct{}
+
+// queryStart is the query started by TraceQueryStart.
+type queryStart struct {
+	sql   string
+	args  []any
+	start time.Time
+}
+
+// batchKey holds a *batchState in the context.
+type batchKey struct{}
+
+// batchState times the queries of a batch, each from the end of the one
+// before.
+type batchState struct {
+	start, last time.Time
+	size        int
+	failed      int
+}
+
+// TraceQueryStart records the start of a query.
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryKey{}, &queryStart{sql: data.SQL, args: data.Args, start: time.Now()})
+}
+
+// TraceQueryEnd logs the query started with TraceQueryStart.
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	q, ok := ctx.Value(queryKey{}).(*queryStart)
+	if !ok {
+		return
+	}
+	t.queries.Log(ctx, q.sql, q.args, data.CommandTag.RowsAffected(), time.Since(q.start), data.Err)
+}
+
+// TraceBatchStart records the start of a batch.
+func (t *Tracer) TraceBatchStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchStartData) context.Context {
+	now := time.Now()
+	size := 0
+	if data.Batch != nil {
+		size = data.Batch.Len()
+	}
+	return context.WithValue(ctx, batchKey{}, &batchState{start: now, last: now, size: size})
+}
+
+// TraceBatchQuery logs a query of the batch started with TraceBatchStart,
+// timed from the previous query's result.
+func (t *Tracer) TraceBatchQuery(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchQueryData) {
+	b, ok := ctx.Value(batchKey{}).(*batchState)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	dur := now.Sub(b.last)
+	b.last = now
+	if data.Err != nil {
+		b.failed++
+	}
+	t.queries.Log(ctx, data.SQL, data.Args, data.CommandTag.RowsAffected(), dur, data.Err)
+}
+
+// TraceBatchEnd logs the batch started with TraceBatchStart: at DEBUG with
+// its size and duration, or at ERROR if it or any of its queries failed.
+func (t *Tracer) TraceBatchEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceBatchEndData) {
+	b, ok := ctx.Value(batchKey{}).(*batchState)
+	if !ok {
+		return
+	}
+	l := xlog.FromContext(ctx)
+	args := []any{
+		xlog.Group("batch",
+			xlog.Int("size", b.size),
+			xlog.Int("failed", b.failed),
+			xlog.Dur("duration", time.Since(b.start)),
+		),
+	}
+	if data.Err != nil || b.failed > 0 {
+		l.Error(ctx, "batch failed", append(args, xlog.Err(data.Err))...)
+		return
+	}
+	l.Debug(ctx, "batch", args...)
+}
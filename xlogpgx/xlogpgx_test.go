@@ -0,0 +1,69 @@
+package xlogpgx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogpgx"
+)
+
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(slog.LevelDebug))
+	ctx := xlog.WithRequestID(context.Background(), "req-1")
+	tr := xlogpgx.New(&xlog.QueryLoggerOptions{SlowThreshold: time.Hour})
+
+	qctx := tr.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: "SELECT * FROM users WHERE id = $1", Args: []any{7}})
+	tr.TraceQueryEnd(qctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	batch := &pgx.Batch{}
+	batch.Queue("INSERT INTO a VALUES (1)")
+	batch.Queue("INSERT INTO b VALUES (1)")
+	bctx := tr.TraceBatchStart(ctx, nil, pgx.TraceBatchStartData{Batch: batch})
+	tr.TraceBatchQuery(bctx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO a VALUES (1)", CommandTag: pgconn.NewCommandTag("INSERT 0 1")})
+	tr.TraceBatchQuery(bctx, nil, pgx.TraceBatchQueryData{SQL: "INSERT INTO b VALUES (1)", Err: errors.New("duplicate key")})
+	tr.TraceBatchEnd(bctx, nil, pgx.TraceBatchEndData{})
+
+	type record struct {
+		Level     string `json:"level"`
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		DB        struct {
+			Statement string `json:"statement"`
+		} `json:"db"`
+		Batch struct {
+			Size   int `json:"size"`
+			Failed int `json:"failed"`
+		} `json:"batch"`
+	}
+	want := []record{
+		{Level: "DEBUG", Msg: "query"},
+		{Level: "DEBUG", Msg: "query"},
+		{Level: "ERROR", Msg: "query failed"},
+		{Level: "ERROR", Msg: "batch failed"},
+	}
+	want[0].DB.Statement = "SELECT * FROM users WHERE id = $1"
+	want[1].DB.Statement = "INSERT INTO a VALUES (1)"
+	want[2].DB.Statement = "INSERT INTO b VALUES (1)"
+	want[3].Batch.Size, want[3].Batch.Failed = 2, 1
+
+	dec := json.NewDecoder(&buf)
+	for i, w := range want {
+		w.RequestID = "req-1"
+		var got record
+		if err := dec.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != w {
+			t.Errorf("record %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
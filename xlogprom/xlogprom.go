@@ -0,0 +1,68 @@
+// Package xlogprom exposes xlog's logging statistics to Prometheus. Its
+// Collector is registered like any other:
+//
+//	prometheus.MustRegister(xlogprom.NewCollector())
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// It is a separate module, so xlog itself does not depend on the
+// Prometheus client library.
+package xlogprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/taro33333/xlog"
+)
+
+// Collector is a prometheus.Collector reporting xlog.Stats at each
+// scrape.
+type Collector struct {
+	records    *prometheus.Desc
+	errors     *prometheus.Desc
+	dropped    *prometheus.Desc
+	sampleRate *prometheus.Desc
+	buffered   *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates a Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		records: prometheus.NewDesc("log_records_total",
+			"Number of log records written, by level.", []string{"level"}, nil),
+		errors: prometheus.NewDesc("log_write_errors_total",
+			"Number of log records whose handler returned an error.", nil, nil),
+		dropped: prometheus.NewDesc("log_dropped_records_total",
+			"Number of log records dropped before reaching an output.", nil, nil),
+		sampleRate: prometheus.NewDesc("log_sample_rate",
+			"Fraction of log records currently kept by adaptive sampling.", nil, nil),
+		buffered: prometheus.NewDesc("log_buffered_bytes",
+			"Bytes of log output waiting in buffers to be written.", nil, nil),
+	}
+}
+
+// Describe sends the descriptors of the metrics to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.records
+	ch <- c.errors
+	ch <- c.dropped
+	ch <- c.sampleRate
+	ch <- c.buffered
+}
+
+// Collect sends the current logging statistics to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := xlog.Stats()
+	for level, n := range map[string]uint64{
+		"debug": s.Records.Debug,
+		"info":  s.Records.Info,
+		"warn":  s.Records.Warn,
+		"error": s.Records.Error,
+	} {
+		ch <- prometheus.MustNewConstMetric(c.records, prometheus.CounterValue, float64(n), level)
+	}
+	ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(s.Errors))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped))
+	ch <- prometheus.MustNewConstMetric(c.sampleRate, prometheus.GaugeValue, s.SampleRate)
+	ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(s.Buffered))
+}
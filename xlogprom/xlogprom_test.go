@@ -0,0 +1,85 @@
+package xlogprom_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogprom"
+)
+
+// gather returns the value of the metric called name, with the given level
+// label if it is not empty.
+func gather(t *testing.T, reg *prometheus.Registry, name, level string) float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != name {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			if level != "" && (len(m.GetLabel()) != 1 || m.GetLabel()[0].GetValue() != level) {
+				continue
+			}
+			if c := m.GetCounter(); c != nil {
+				return c.GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s{level=%q} not found", name, level)
+	return 0
+}
+
+func TestCollector(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(&buf),
+	)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(xlogprom.NewCollector())
+
+	// The statistics are global, so compare against the values before
+	before := gather(t, reg, "log_records_total", "error")
+	xlog.Error(context.Background(), "counted")
+	if got := gather(t, reg, "log_records_total", "error"); got != before+1 {
+		t.Errorf("expected log_records_total{level=\"error\"} %v, got %v", before+1, got)
+	}
+	for _, name := range []string{"log_write_errors_total", "log_dropped_records_total", "log_sample_rate"} {
+		gather(t, reg, name, "")
+	}
+}
+
+func TestBufferedBytes(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(
+		xlog.WithEnvironment(xlog.Production),
+		xlog.WithOutput(&buf),
+		xlog.WithBuffering(&xlog.BufferedWriterOptions{FlushInterval: time.Hour}),
+	)
+	defer func() { _ = xlog.Init(xlog.WithOutput(io.Discard)) }()
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(xlogprom.NewCollector())
+	xlog.Info(context.Background(), "waiting")
+
+	n := xlog.Stats().Buffered
+	if n == 0 || buf.Len() != 0 {
+		t.Fatalf("expected the record to be buffered, got %d bytes buffered and %d written", n, buf.Len())
+	}
+	if got := gather(t, reg, "log_buffered_bytes", ""); got != float64(n) {
+		t.Errorf("expected log_buffered_bytes %d, got %v", n, got)
+	}
+
+	_ = xlog.Flush(context.Background())
+	if got := gather(t, reg, "log_buffered_bytes", ""); got != 0 {
+		t.Errorf("expected nothing buffered after Flush, got %v", got)
+	}
+}
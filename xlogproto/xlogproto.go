@@ -0,0 +1,64 @@
+// Package xlogproto logs protocol buffer messages readably: as protojson
+// in JSON output and in the compact text format elsewhere, such as in the
+// colored development format. It has no dependency on the protobuf
+// module; plug protojson in once at startup:
+//
+//	xlogproto.SetJSONMarshaler(func(m any) ([]byte, error) {
+//		return protojson.Marshal(m.(proto.Message))
+//	})
+//
+//	xlog.Info(ctx, "order received", xlogproto.Message("order", req))
+package xlogproto
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Msg is implemented by generated protocol buffer messages, whose String
+// method returns the compact text format.
+type Msg interface {
+	fmt.Stringer
+	ProtoMessage()
+}
+
+var marshalJSON atomic.Pointer[func(any) ([]byte, error)]
+
+// SetJSONMarshaler sets the function encoding messages in JSON output,
+// normally protojson.Marshal. Without one, messages are encoded with
+// encoding/json, which handles plain fields but not oneofs, enums by name
+// or well-known types as protojson does.
+func SetJSONMarshaler(fn func(m any) ([]byte, error)) {
+	if fn == nil {
+		marshalJSON.Store(nil)
+		return
+	}
+	marshalJSON.Store(&fn)
+}
+
+// Message returns an attribute logging m as a nested JSON object in JSON
+// output and in the compact text format elsewhere.
+func Message(key string, m Msg) slog.Attr {
+	if m == nil {
+		return slog.Any(key, nil)
+	}
+	return slog.Any(key, messageValue{m})
+}
+
+type messageValue struct{ m Msg }
+
+// MarshalJSON encodes the message with the marshaler set with
+// SetJSONMarshaler.
+func (v messageValue) MarshalJSON() ([]byte, error) {
+	if fn := marshalJSON.Load(); fn != nil {
+		return (*fn)(v.m)
+	}
+	return json.Marshal(v.m)
+}
+
+// String returns the message in the compact text format.
+func (v messageValue) String() string {
+	return v.m.String()
+}
@@ -0,0 +1,52 @@
+package xlogproto_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogproto"
+)
+
+// order stands in for a generated message.
+type order struct {
+	ID    int64  `json:"id,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+func (o *order) ProtoMessage() {}
+
+func (o *order) String() string {
+	return fmt.Sprintf("id:%d state:%q", o.ID, o.State)
+}
+
+func TestMessage(t *testing.T) {
+	msg := &order{ID: 7, State: "PAID"}
+
+	var buf bytes.Buffer
+	logger := slog.New(xlog.NewFastJSONHandler(&buf, nil))
+	logger.Info("order", xlogproto.Message("order", msg))
+	if !strings.Contains(buf.String(), `"order":{"id":7,"state":"PAID"}`) {
+		t.Errorf("expected encoding/json fallback, got: %s", buf.String())
+	}
+
+	xlogproto.SetJSONMarshaler(func(m any) ([]byte, error) {
+		return json.Marshal(map[string]any{"orderId": fmt.Sprint(m.(*order).ID)})
+	})
+	defer xlogproto.SetJSONMarshaler(nil)
+	buf.Reset()
+	logger.Info("order", xlogproto.Message("order", msg))
+	if !strings.Contains(buf.String(), `"order":{"orderId":"7"}`) {
+		t.Errorf("expected the marshaler's output, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	slog.New(xlog.NewColorHandler(&buf, nil)).Info("order", xlogproto.Message("order", msg))
+	if !strings.Contains(buf.String(), `id:7 state:"PAID"`) {
+		t.Errorf("expected the text format, got: %s", buf.String())
+	}
+}
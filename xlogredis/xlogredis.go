@@ -0,0 +1,224 @@
+// Package xlogredis logs go-redis commands and pipelines through xlog,
+// with sanitized key patterns rather than keys or values. Its Hook
+// implements redis.Hook:
+//
+//	rdb.AddHook(xlogredis.New(&xlogredis.Options{IgnoreErrors: []error{redis.Nil}}))
+//
+// Records use the logger from the command's context, so request IDs and
+// other context attributes follow. It is a separate module, so xlog
+// itself does not depend on go-redis.
+package xlogredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/taro33333/xlog"
+)
+
+// Options configures a Hook.
+type Options struct {
+	// SlowThreshold is the duration above which commands are logged at
+	// WARN instead of DEBUG. Zero means 100ms; negative never escalates.
+	SlowThreshold time.Duration
+
+	// IgnoreErrors lists errors that are not failures, such as
+	// redis.Nil.
+	IgnoreErrors []error
+
+	// KeyPattern turns a key into the pattern logged. By default segments
+	// between ':' that hold IDs are replaced with '*', so "user:42:cart"
+	// is logged as "user:*:cart".
+	KeyPattern func(key string) string
+}
+
+// Hook logs commands at DEBUG, escalating slow ones to WARN and failed
+// ones to ERROR, with the command name, key pattern and duration in a
+// "redis" group. Argument values are never logged.
+type Hook struct {
+	opts Options
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+// New creates a Hook.
+func New(opts *Options) *Hook {
+	h := &Hook{}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.SlowThreshold == 0 {
+		h.opts.SlowThreshold = 100 * time.Millisecond
+	}
+	if h.opts.KeyPattern == nil {
+		h.opts.KeyPattern = KeyPattern
+	}
+	return h
+}
+
+// DialHook returns next, as connections are not logged.
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook returns a hook running commands with next and logging them.
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.logCommand(ctx, cmd, time.Since(start), err)
+		return err
+	}
+}
+
+// ProcessPipelineHook returns a hook running pipelines with next and
+// logging each as one record, failing if any command failed.
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.logPipeline(ctx, cmds, time.Since(start), err)
+		return err
+	}
+}
+
+func (h *Hook) logCommand(ctx context.Context, cmd redis.Cmder, dur time.Duration, err error) {
+	attrs := []slog.Attr{slog.String("command", strings.ToLower(cmd.Name()))}
+	if key := h.key(cmd); key != "" {
+		attrs = append(attrs, slog.String("key", key))
+	}
+	h.log(ctx, "redis command", attrs, dur, h.failure(err))
+}
+
+func (h *Hook) logPipeline(ctx context.Context, cmds []redis.Cmder, dur time.Duration, err error) {
+	failure := h.failure(err)
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = strings.ToLower(cmd.Name())
+		if failure == nil {
+			failure = h.failure(cmd.Err())
+		}
+	}
+	attrs := []slog.Attr{
+		slog.Int("pipeline", len(cmds)),
+		slog.Any("commands", names),
+	}
+	h.log(ctx, "redis pipeline", attrs, dur, failure)
+}
+
+func (h *Hook) log(ctx context.Context, msg string, attrs []slog.Attr, dur time.Duration, err error) {
+	level := slog.LevelDebug
+	switch {
+	case err != nil:
+		level, msg = slog.LevelError, msg+" failed"
+	case h.opts.SlowThreshold >= 0 && dur > h.opts.SlowThreshold:
+		level, msg = slog.LevelWarn, "slow "+msg
+	}
+	l := xlog.FromContext(ctx)
+	if !l.Logger.Enabled(ctx, level) {
+		return
+	}
+	attrs = append(attrs, slog.Duration("duration", dur))
+	l.Log(ctx, level, msg, slog.Attr{Key: "redis", Value: slog.GroupValue(attrs...)}, xlog.Err(err))
+}
+
+// failure returns err unless it is nil or ignored.
+func (h *Hook) failure(err error) error {
+	for _, ignored := range h.opts.IgnoreErrors {
+		if errors.Is(err, ignored) {
+			return nil
+		}
+	}
+	return err
+}
+
+// key returns the pattern of the first key of cmd, if it is one of
+// keyCommands. The first argument of other commands, such as the password
+// of AUTH or the script of EVAL, is not a key and is never logged.
+func (h *Hook) key(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) < 2 || !keyCommands[strings.ToLower(cmd.Name())] {
+		return ""
+	}
+	key, ok := args[1].(string)
+	if !ok {
+		key = fmt.Sprint(args[1])
+	}
+	return h.opts.KeyPattern(key)
+}
+
+// keyCommands lists the commands whose first argument is a key.
+var keyCommands = setOf(
+	// Keys and strings.
+	"append", "copy", "decr", "decrby", "del", "dump", "exists", "expire",
+	"expireat", "expiretime", "get", "getdel", "getex", "getrange", "getset",
+	"incr", "incrby", "incrbyfloat", "mget", "mset", "msetnx", "persist",
+	"pexpire", "pexpireat", "pexpiretime", "psetex", "pttl", "rename",
+	"renamenx", "restore", "set", "setex", "setnx", "setrange", "sort",
+	"strlen", "touch", "ttl", "type", "unlink", "watch",
+	// Bitmaps and HyperLogLogs.
+	"bitcount", "bitfield", "bitpos", "getbit", "setbit", "pfadd", "pfcount",
+	"pfmerge",
+	// Hashes.
+	"hdel", "hexists", "hget", "hgetall", "hincrby", "hincrbyfloat", "hkeys",
+	"hlen", "hmget", "hmset", "hrandfield", "hscan", "hset", "hsetnx",
+	"hstrlen", "hvals",
+	// Lists.
+	"blmove", "blpop", "brpop", "brpoplpush", "lindex", "linsert", "llen",
+	"lmove", "lpop", "lpos", "lpush", "lpushx", "lrange", "lrem", "lset",
+	"ltrim", "rpop", "rpoplpush", "rpush", "rpushx",
+	// Sets.
+	"sadd", "scard", "sdiff", "sdiffstore", "sinter", "sinterstore",
+	"sismember", "smembers", "smismember", "smove", "spop", "srandmember",
+	"srem", "sscan", "sunion", "sunionstore",
+	// Sorted sets.
+	"bzpopmax", "bzpopmin", "zadd", "zcard", "zcount", "zincrby",
+	"zlexcount", "zmscore", "zpopmax", "zpopmin", "zrandmember", "zrange",
+	"zrangebylex", "zrangebyscore", "zrank", "zrem", "zremrangebylex",
+	"zremrangebyrank", "zremrangebyscore", "zrevrange", "zrevrangebylex",
+	"zrevrangebyscore", "zrevrank", "zscan", "zscore",
+	// Streams and geospatial indexes.
+	"xack", "xadd", "xautoclaim", "xclaim", "xdel", "xlen", "xpending",
+	"xrange", "xrevrange", "xtrim", "geoadd", "geodist", "geohash", "geopos",
+	"georadius", "georadiusbymember", "geosearch", "geosearchstore",
+)
+
+func setOf(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
+
+// KeyPattern replaces the segments of key between ':' that hold IDs with
+// '*': segments made of digits, hexadecimal digits and dashes, such as
+// numbers and UUIDs, and longer segments containing digits.
+func KeyPattern(key string) string {
+	segments := strings.Split(key, ":")
+	for i, s := range segments {
+		if isID(s) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, ":")
+}
+
+func isID(s string) bool {
+	digits, hex := 0, true
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F', c == '-':
+		default:
+			hex = false
+		}
+	}
+	return digits > 0 && (hex || len(s) > 8)
+}
@@ -0,0 +1,127 @@
+package xlogredis_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogredis"
+)
+
+// process runs cmd through the hook with a next hook returning err.
+func process(h redis.Hook, cmd redis.Cmder, err error) {
+	cmd.SetErr(err)
+	_ = h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return cmd.Err() })(context.Background(), cmd)
+}
+
+func TestKeyPattern(t *testing.T) {
+	tests := map[string]string{
+		"user:42:cart": "user:*:cart",
+		"session:550e8400-e29b-41d4-a716-446655440000": "session:*",
+		"v2:config":        "v2:config",
+		"token:a8Zk29xQpL": "token:*",
+		"cafe:menu":        "cafe:menu",
+	}
+	for key, want := range tests {
+		if got := xlogredis.KeyPattern(key); got != want {
+			t.Errorf("KeyPattern(%q) = %q, expected %q", key, got, want)
+		}
+	}
+}
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(slog.LevelDebug))
+	ctx := xlog.WithRequestID(context.Background(), "req-1")
+	h := xlogredis.New(&xlogredis.Options{IgnoreErrors: []error{redis.Nil}})
+	run := func(cmd redis.Cmder) {
+		_ = h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return cmd.Err() })(ctx, cmd)
+	}
+
+	get := redis.NewStringCmd(ctx, "GET", "user:42:cart")
+	get.SetErr(redis.Nil)
+	run(get)
+	set := redis.NewStatusCmd(ctx, "SET", "user:42:cart", "secret")
+	set.SetErr(errors.New("OOM"))
+	run(set)
+	cmds := []redis.Cmder{redis.NewIntCmd(ctx, "INCR", "hits"), redis.NewBoolCmd(ctx, "EXPIRE", "hits", 60)}
+	_ = h.ProcessPipelineHook(func(context.Context, []redis.Cmder) error { return nil })(ctx, cmds)
+
+	type record struct {
+		Level     string `json:"level"`
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Error     string `json:"error"`
+		Redis     struct {
+			Command  string   `json:"command"`
+			Key      string   `json:"key"`
+			Pipeline int      `json:"pipeline"`
+			Commands []string `json:"commands"`
+		} `json:"redis"`
+	}
+	output := buf.String()
+	dec := json.NewDecoder(&buf)
+	var recs []record
+	for range 3 {
+		var r record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, r)
+	}
+
+	if r := recs[0]; r.Level != "DEBUG" || r.Msg != "redis command" || r.Redis.Command != "get" ||
+		r.Redis.Key != "user:*:cart" || r.RequestID != "req-1" || r.Error != "" {
+		t.Errorf("unexpected GET record: %+v", r)
+	}
+	if r := recs[1]; r.Level != "ERROR" || r.Msg != "redis command failed" || r.Error != "OOM" {
+		t.Errorf("unexpected SET record: %+v", r)
+	}
+	if strings.Contains(output, "secret") {
+		t.Error("expected no argument values in the output")
+	}
+	if r := recs[2]; r.Msg != "redis pipeline" || r.Redis.Pipeline != 2 || len(r.Redis.Commands) != 2 || r.Redis.Commands[1] != "expire" {
+		t.Errorf("unexpected pipeline record: %+v", r)
+	}
+}
+
+func TestHookKeylessCommands(t *testing.T) {
+	var buf bytes.Buffer
+	_ = xlog.Init(xlog.WithFormat(xlog.StdJSON), xlog.WithOutput(&buf), xlog.WithLevel(slog.LevelDebug))
+	h := xlogredis.New(nil)
+
+	ctx := context.Background()
+	for _, c := range []redis.Cmder{
+		redis.NewStatusCmd(ctx, "auth", "hunter2"),
+		redis.NewMapStringInterfaceCmd(ctx, "hello", 3, "AUTH", "default", "hunter2"),
+		redis.NewCmd(ctx, "eval", "return redis.call('GET', KEYS[1])", 1, "user:42"),
+		redis.NewStatusCmd(ctx, "client", "setname", "worker-7"),
+	} {
+		process(h, c, nil)
+	}
+
+	output := buf.String()
+	dec := json.NewDecoder(&buf)
+	for range 4 {
+		var r struct {
+			Redis map[string]any `json:"redis"`
+		}
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		if key, ok := r.Redis["key"]; ok {
+			t.Errorf("expected no key for %v, got %v", r.Redis["command"], key)
+		}
+	}
+	for _, s := range []string{"hunter2", "redis.call", "worker"} {
+		if strings.Contains(output, s) {
+			t.Errorf("expected %q not to be logged:\n%s", s, output)
+		}
+	}
+}
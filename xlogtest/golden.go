@@ -0,0 +1,128 @@
+package xlogtest
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// FixedTime is the timestamp Deterministic stamps on every record.
+var FixedTime = time.Date(2024, 1, 15, 10, 30, 45, 0, time.UTC)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden
+// rewrite golden files instead of comparing against them.
+const UpdateGoldenEnv = "XLOG_UPDATE_GOLDEN"
+
+// Deterministic wraps h so its output is stable across runs: every record
+// gets FixedTime, source locations are removed, and attributes (including
+// those from With) are sorted by key within each group.
+func Deterministic(h slog.Handler) slog.Handler {
+	return &deterministicHandler{handler: h}
+}
+
+type deterministicHandler struct {
+	handler slog.Handler
+	// goas records WithAttrs and WithGroup calls so they can be rebuilt
+	// as one sorted attribute tree per record.
+	goas []groupOrAttrs
+}
+
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (h *deterministicHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *deterministicHandler) Handle(ctx context.Context, r slog.Record) error {
+	var recAttrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		recAttrs = append(recAttrs, a)
+		return true
+	})
+
+	// Build the tree from the innermost group outwards
+	attrs := recAttrs
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group != "" {
+			attrs = []slog.Attr{slog.Attr{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			continue
+		}
+		attrs = append(slices.Clone(goa.attrs), attrs...)
+	}
+
+	r2 := slog.NewRecord(FixedTime, r.Level, r.Message, 0)
+	r2.AddAttrs(sortAttrs(attrs)...)
+	return h.handler.Handle(ctx, r2)
+}
+
+func (h *deterministicHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &deterministicHandler{
+		handler: h.handler,
+		goas:    append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{attrs: attrs}),
+	}
+}
+
+func (h *deterministicHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &deterministicHandler{
+		handler: h.handler,
+		goas:    append(h.goas[:len(h.goas):len(h.goas)], groupOrAttrs{group: name}),
+	}
+}
+
+// sortAttrs sorts attrs by key, recursively, keeping equal keys in order.
+func sortAttrs(attrs []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		a.Value = a.Value.Resolve()
+		if a.Value.Kind() == slog.KindGroup {
+			a.Value = slog.GroupValue(sortAttrs(a.Value.Group())...)
+		}
+		out[i] = a
+	}
+	slices.SortStableFunc(out, func(a, b slog.Attr) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return out
+}
+
+// AssertGolden compares got with the contents of testdata/<name>.golden.
+// When the XLOG_UPDATE_GOLDEN environment variable is set, the golden
+// file is written instead.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (set %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s (set %s=1 to update)\n got:\n%s\nwant:\n%s", path, UpdateGoldenEnv, got, want)
+	}
+}
@@ -0,0 +1,22 @@
+package xlogtest_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog/xlogtest"
+)
+
+func TestDeterministic(t *testing.T) {
+	var buf bytes.Buffer
+	h := xlogtest.Deterministic(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true}))
+	logger := slog.New(h).With("z", 1).WithGroup("g").With("y", 2)
+
+	logger.Info("sorted", "b", 3, "a", 4)
+
+	want := `{"time":"2024-01-15T10:30:45Z","level":"INFO","msg":"sorted","g":{"a":4,"b":3,"y":2},"z":1}` + "\n"
+	if buf.String() != want {
+		t.Errorf("unexpected output\n got: %s\nwant: %s", buf.String(), want)
+	}
+}
@@ -0,0 +1,69 @@
+package xlogtest
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/taro33333/xlog"
+)
+
+// Mock is an xlog.Interface that records every call, for testing code
+// that accepts an xlog.Interface. Mocks derived through With and
+// WithGroup share its entries.
+type Mock struct {
+	rec    *Recorder
+	logger *slog.Logger
+}
+
+// NewMock creates a Mock.
+func NewMock() *Mock {
+	rec := NewRecorder()
+	return &Mock{rec: rec, logger: slog.New(rec)}
+}
+
+// Debug records a call at DEBUG level.
+func (m *Mock) Debug(ctx context.Context, msg string, args ...any) {
+	m.logger.DebugContext(ctx, msg, args...)
+}
+
+// Info records a call at INFO level.
+func (m *Mock) Info(ctx context.Context, msg string, args ...any) {
+	m.logger.InfoContext(ctx, msg, args...)
+}
+
+// Warn records a call at WARN level.
+func (m *Mock) Warn(ctx context.Context, msg string, args ...any) {
+	m.logger.WarnContext(ctx, msg, args...)
+}
+
+// Error records a call at ERROR level.
+func (m *Mock) Error(ctx context.Context, msg string, args ...any) {
+	m.logger.ErrorContext(ctx, msg, args...)
+}
+
+// With returns a Mock that adds args to every call.
+func (m *Mock) With(args ...any) xlog.Interface {
+	return &Mock{rec: m.rec, logger: m.logger.With(args...)}
+}
+
+// WithGroup returns a Mock that qualifies the keys of later attributes
+// with name.
+func (m *Mock) WithGroup(name string) xlog.Interface {
+	return &Mock{rec: m.rec, logger: m.logger.WithGroup(name)}
+}
+
+// Calls returns a copy of all recorded calls in order.
+func (m *Mock) Calls() []Entry {
+	return m.rec.Records()
+}
+
+// Has reports whether a call with the given message contains all of the
+// given attributes, as Recorder.Has does.
+func (m *Mock) Has(msg string, args ...any) bool {
+	return m.rec.Has(msg, args...)
+}
+
+// Reset discards all recorded calls.
+func (m *Mock) Reset() {
+	m.rec.Reset()
+}
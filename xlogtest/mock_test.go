@@ -0,0 +1,38 @@
+package xlogtest_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogtest"
+)
+
+func TestMock(t *testing.T) {
+	mock := xlogtest.NewMock()
+	var l xlog.Interface = mock
+
+	ctx := context.Background()
+	l.With("service", "api").Info(ctx, "started", "port", 8080)
+	l.WithGroup("db").Error(ctx, "query failed", "table", "users")
+
+	calls := mock.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[1].Level != slog.LevelError {
+		t.Errorf("expected ERROR, got %v", calls[1].Level)
+	}
+	if !mock.Has("started", "service", "api", "port", 8080) {
+		t.Error("expected started call with service and port")
+	}
+	if !mock.Has("query failed", "db.table", "users") {
+		t.Error("expected grouped attribute to be qualified")
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 {
+		t.Error("expected Reset to discard calls")
+	}
+}
@@ -0,0 +1,206 @@
+// Package xlogtest provides helpers for asserting on log output in tests.
+package xlogtest
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a record captured by a Recorder. Attribute keys are qualified
+// by their groups, joined with dots (e.g. "http.method").
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   []slog.Attr
+}
+
+// Attr returns the value of the attribute with the given qualified key.
+func (e Entry) Attr(key string) (slog.Value, bool) {
+	for _, a := range e.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+// Recorder is a slog.Handler that keeps every record in memory.
+// Handlers derived through WithAttrs and WithGroup share its entries.
+type Recorder struct {
+	store  *recorderStore
+	level  slog.Leveler
+	attrs  []slog.Attr
+	groups []string
+}
+
+type recorderStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewRecorder creates a Recorder that captures records at every level.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		store: &recorderStore{},
+		level: slog.Level(-1 << 31),
+	}
+}
+
+// WithLevel returns a Recorder sharing r's entries that only captures
+// records at or above level.
+func (r *Recorder) WithLevel(level slog.Leveler) *Recorder {
+	r2 := *r
+	r2.level = level
+	return &r2
+}
+
+// Enabled reports whether the recorder captures records at the given level.
+func (r *Recorder) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= r.level.Level()
+}
+
+// Handle captures the record.
+func (r *Recorder) Handle(_ context.Context, rec slog.Record) error {
+	attrs := make([]slog.Attr, len(r.attrs), len(r.attrs)+rec.NumAttrs())
+	copy(attrs, r.attrs)
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlat(attrs, r.groups, a)
+		return true
+	})
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.entries = append(r.store.entries, Entry{
+		Time:    rec.Time,
+		Level:   rec.Level,
+		Message: rec.Message,
+		Attrs:   attrs,
+	})
+	return nil
+}
+
+// WithAttrs returns a new Recorder with the given attributes.
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	r2 := *r
+	r2.attrs = make([]slog.Attr, len(r.attrs), len(r.attrs)+len(attrs))
+	copy(r2.attrs, r.attrs)
+	for _, a := range attrs {
+		r2.attrs = appendFlat(r2.attrs, r.groups, a)
+	}
+	return &r2
+}
+
+// WithGroup returns a new Recorder with the given group name.
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return r
+	}
+	r2 := *r
+	r2.groups = append(r.groups[:len(r.groups):len(r.groups)], name)
+	return &r2
+}
+
+// Records returns a copy of all captured entries in order.
+func (r *Recorder) Records() []Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	entries := make([]Entry, len(r.store.entries))
+	copy(entries, r.store.entries)
+	return entries
+}
+
+// Filter returns the captured entries at exactly the given level.
+func (r *Recorder) Filter(level slog.Level) []Entry {
+	var entries []Entry
+	for _, e := range r.Records() {
+		if e.Level == level {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Has reports whether an entry with the given message contains all of the
+// given attributes. Attributes are key-value pairs as accepted by
+// slog.Logger.Info, or slog.Attr values.
+func (r *Recorder) Has(msg string, args ...any) bool {
+	want := argsToAttrs(args)
+	for _, e := range r.Records() {
+		if e.Message == msg && hasAttrs(e, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// LastEntry returns the most recently captured entry, if any.
+func (r *Recorder) LastEntry() (Entry, bool) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if len(r.store.entries) == 0 {
+		return Entry{}, false
+	}
+	return r.store.entries[len(r.store.entries)-1], true
+}
+
+// Len returns the number of captured entries.
+func (r *Recorder) Len() int {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return len(r.store.entries)
+}
+
+// Reset discards all captured entries.
+func (r *Recorder) Reset() {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.entries = nil
+}
+
+// appendFlat appends a, expanding groups into dot-qualified keys.
+func appendFlat(attrs []slog.Attr, groups []string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		sub := groups
+		if a.Key != "" {
+			sub = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendFlat(attrs, sub, ga)
+		}
+		return attrs
+	}
+	if len(groups) > 0 {
+		a.Key = strings.Join(groups, ".") + "." + a.Key
+	}
+	return append(attrs, a)
+}
+
+// argsToAttrs converts slog-style arguments to flattened attributes.
+func argsToAttrs(args []any) []slog.Attr {
+	var rec slog.Record
+	rec.Add(args...)
+	var attrs []slog.Attr
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = appendFlat(attrs, nil, a)
+		return true
+	})
+	return attrs
+}
+
+func hasAttrs(e Entry, want []slog.Attr) bool {
+	for _, w := range want {
+		v, ok := e.Attr(w.Key)
+		if !ok || !v.Equal(w.Value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package xlogtest_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/taro33333/xlog"
+	"github.com/taro33333/xlog/xlogtest"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := xlogtest.NewRecorder()
+	logger := slog.New(rec).With("service", "api")
+
+	logger.Info("started", "port", 8080)
+	logger.WithGroup("http").Warn("slow request", "method", "GET")
+	logger.Error("failed", slog.Group("db", "table", "users"))
+
+	if got := len(rec.Records()); got != 3 {
+		t.Fatalf("expected 3 records, got %d", got)
+	}
+	if !rec.Has("started", "service", "api", "port", 8080) {
+		t.Error("expected started record with service and port")
+	}
+	if rec.Has("started", "port", 9090) {
+		t.Error("did not expect a match for a different port")
+	}
+	if !rec.Has("slow request", "http.method", "GET") {
+		t.Error("expected grouped attribute to be qualified")
+	}
+	if got := rec.Filter(slog.LevelWarn); len(got) != 1 || got[0].Message != "slow request" {
+		t.Errorf("unexpected warn records: %v", got)
+	}
+
+	last, ok := rec.LastEntry()
+	if !ok || last.Message != "failed" {
+		t.Fatalf("unexpected last entry: %v", last)
+	}
+	if v, ok := last.Attr("db.table"); !ok || v.String() != "users" {
+		t.Errorf("expected db.table=users, got %v", v)
+	}
+
+	rec.Reset()
+	if rec.Len() != 0 {
+		t.Error("expected Reset to discard entries")
+	}
+}
+
+func TestRecorderWithLevel(t *testing.T) {
+	rec := xlogtest.NewRecorder()
+	logger := slog.New(rec.WithLevel(slog.LevelWarn))
+
+	logger.Info("ignored")
+	logger.Warn("kept")
+
+	if rec.Len() != 1 || !rec.Has("kept") {
+		t.Errorf("expected only the warn record, got: %v", rec.Records())
+	}
+}
+
+func TestRecorderWithInit(t *testing.T) {
+	rec := xlogtest.NewRecorder()
+	_ = xlog.Init(xlog.WithHandler(rec))
+
+	ctx := xlog.WithTraceID(context.Background(), "trace-123")
+	xlog.Info(ctx, "through xlog", "key", "value")
+
+	if !rec.Has("through xlog", "trace_id", "trace-123", "key", "value") {
+		t.Errorf("expected record with context attrs, got: %v", rec.Records())
+	}
+}
@@ -0,0 +1,45 @@
+package xlogtest
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"testing"
+)
+
+// NewTestHandler returns a handler that writes each record to t.Output,
+// so output is attributed to the test that produced it and only shown on
+// failure or with -v. Unlike t.Log, it adds no file:line prefix, which
+// would point into slog rather than at the logging call. Records logged
+// after the test completes are dropped instead of panicking. Records at
+// every level are written, without the time attribute since the test
+// runner orders output already.
+func NewTestHandler(t testing.TB) slog.Handler {
+	w := &testWriter{t: t}
+	t.Cleanup(func() {
+		w.done.Store(true)
+	})
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: slog.Level(-1 << 31),
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+// testWriter writes to the output of a testing.TB while its test runs.
+// slog.TextHandler writes each record with a single call, so every Write
+// is one line.
+type testWriter struct {
+	t    testing.TB
+	done atomic.Bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	if w.done.Load() {
+		return len(p), nil
+	}
+	return w.t.Output().Write(p)
+}
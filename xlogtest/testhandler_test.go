@@ -0,0 +1,51 @@
+package xlogtest_test
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog/xlogtest"
+)
+
+// fakeTB captures output lines and Cleanup functions.
+type fakeTB struct {
+	testing.TB
+	logs     []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Output() io.Writer { return f }
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+func (f *fakeTB) Write(p []byte) (int, error) {
+	f.logs = append(f.logs, string(p))
+	return len(p), nil
+}
+
+func TestTestHandler(t *testing.T) {
+	tb := &fakeTB{}
+	logger := slog.New(xlogtest.NewTestHandler(tb))
+
+	logger.Debug("visible", "key", "value")
+	if len(tb.logs) != 1 {
+		t.Fatalf("expected 1 log line, got %d", len(tb.logs))
+	}
+	if line := tb.logs[0]; !strings.Contains(line, "msg=visible key=value") || strings.Contains(line, "time=") {
+		t.Errorf("unexpected log line: %q", line)
+	}
+
+	// After the test completes, records are dropped
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	logger.Info("after test")
+	if len(tb.logs) != 1 {
+		t.Errorf("expected records after completion to be dropped, got: %v", tb.logs)
+	}
+}
+
+func TestTestHandlerRealT(t *testing.T) {
+	slog.New(xlogtest.NewTestHandler(t)).Info("shown with -v")
+}
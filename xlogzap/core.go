@@ -0,0 +1,207 @@
+// Package xlogzap connects zap and xlog in both directions: NewCore is a
+// zapcore.Core writing zap entries through xlog's handlers, and NewHandler
+// an slog.Handler writing xlog records to a zapcore.Core. It is a separate
+// module, so xlog itself does not depend on zap.
+package xlogzap
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/taro33333/xlog"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core writing entries through an slog.Handler, so zap
+// loggers share xlog's outputs, context enrichment and redaction while
+// call sites migrate:
+//
+//	logger := zap.New(xlogzap.NewCore(nil), zap.AddCaller())
+//
+// Fields map to attributes of the matching kind without re-encoding,
+// namespaces to groups, and the caller of the entry to the source of the
+// record. Levels map to their slog counterparts, and DPanic, Panic and
+// Fatal to xlog.LevelCritical.
+type Core struct {
+	h    slog.Handler
+	goas []groupOrAttrs
+}
+
+// groupOrAttrs is a namespace opened by With, or the fields it added.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewCore creates a Core writing to h, or to the handler of the default
+// logger at the time of each entry if h is nil. Entries are enabled by the
+// level of the handler.
+func NewCore(h slog.Handler) *Core {
+	return &Core{h: h}
+}
+
+// Enabled reports whether the handler handles records at level.
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.handler().Enabled(context.Background(), slogLevel(level))
+}
+
+// With returns a core adding fields to every entry.
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	c2 := *c
+	c2.goas = slices.Clip(c.goas)
+	for len(fields) > 0 {
+		i := slices.IndexFunc(fields, func(f zapcore.Field) bool { return f.Type == zapcore.NamespaceType })
+		if i < 0 {
+			i = len(fields)
+		}
+		if attrs := appendFields(nil, fields[:i]); len(attrs) > 0 {
+			c2.goas = append(c2.goas, groupOrAttrs{attrs: attrs})
+		}
+		if i < len(fields) {
+			c2.goas = append(c2.goas, groupOrAttrs{group: fields[i].Key})
+			i++
+		}
+		fields = fields[i:]
+	}
+	return &c2
+}
+
+// Check adds the core to ce if the entry is enabled.
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write writes ent with fields as a record. The logger name is logged
+// under xlog.LoggerKey and the stack, if any, under "stack".
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var pc uintptr
+	if ent.Caller.Defined && ent.Caller.PC != 0 {
+		// The caller's PC points into the call instruction, while records
+		// hold return addresses, as runtime.Callers reports them.
+		pc = ent.Caller.PC + 1
+	}
+	// The logger name and stack describe the entry, so they stay at the
+	// top level rather than in the namespaces opened by With
+	var top []slog.Attr
+	if ent.LoggerName != "" {
+		top = append(top, slog.String(xlog.LoggerKey, ent.LoggerName))
+	}
+	if ent.Stack != "" {
+		top = append(top, slog.String("stack", ent.Stack))
+	}
+	r := slog.NewRecord(ent.Time, slogLevel(ent.Level), ent.Message, pc)
+	r.AddAttrs(appendFields(nil, fields)...)
+	return c.handler(top...).Handle(context.Background(), r)
+}
+
+// Sync flushes the outputs of the default logger if the core writes to
+// it. zap calls it before exiting on Fatal.
+func (c *Core) Sync() error {
+	if c.h != nil {
+		return nil
+	}
+	return xlog.Flush(context.Background())
+}
+
+// handler returns the handler of c with top, then the fields and
+// namespaces added by With.
+func (c *Core) handler(top ...slog.Attr) slog.Handler {
+	h := c.h
+	if h == nil {
+		h = xlog.Default().Handler()
+	}
+	if len(top) > 0 {
+		h = h.WithAttrs(top)
+	}
+	for _, goa := range c.goas {
+		if goa.group != "" {
+			h = h.WithGroup(goa.group)
+		} else {
+			h = h.WithAttrs(goa.attrs)
+		}
+	}
+	return h
+}
+
+// slogLevel returns the slog level of a zap level.
+func slogLevel(level zapcore.Level) slog.Level {
+	switch {
+	case level < zapcore.InfoLevel:
+		return slog.LevelDebug
+	case level == zapcore.InfoLevel:
+		return slog.LevelInfo
+	case level == zapcore.WarnLevel:
+		return slog.LevelWarn
+	case level == zapcore.ErrorLevel:
+		return slog.LevelError
+	default:
+		return xlog.LevelCritical
+	}
+}
+
+// appendFields appends fields to attrs as attributes. A namespace groups
+// the fields after it.
+func appendFields(attrs []slog.Attr, fields []zapcore.Field) []slog.Attr {
+	for i, f := range fields {
+		if f.Type == zapcore.NamespaceType {
+			if rest := appendFields(nil, fields[i+1:]); len(rest) > 0 {
+				attrs = append(attrs, slog.Attr{Key: f.Key, Value: slog.GroupValue(rest...)})
+			}
+			return attrs
+		}
+		attrs = appendField(attrs, f)
+	}
+	return attrs
+}
+
+// appendField appends f to attrs. Marshalers and other fields without a
+// direct counterpart are encoded with a zapcore.MapObjectEncoder.
+func appendField(attrs []slog.Attr, f zapcore.Field) []slog.Attr {
+	switch f.Type {
+	case zapcore.SkipType:
+		return attrs
+	case zapcore.BoolType:
+		return append(attrs, slog.Bool(f.Key, f.Integer == 1))
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return append(attrs, slog.Int64(f.Key, f.Integer))
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return append(attrs, slog.Uint64(f.Key, uint64(f.Integer)))
+	case zapcore.Float64Type:
+		return append(attrs, slog.Float64(f.Key, math.Float64frombits(uint64(f.Integer))))
+	case zapcore.Float32Type:
+		return append(attrs, slog.Float64(f.Key, float64(math.Float32frombits(uint32(f.Integer)))))
+	case zapcore.StringType:
+		return append(attrs, slog.String(f.Key, f.String))
+	case zapcore.ByteStringType:
+		return append(attrs, slog.String(f.Key, string(f.Interface.([]byte))))
+	case zapcore.DurationType:
+		return append(attrs, slog.Duration(f.Key, time.Duration(f.Integer)))
+	case zapcore.TimeType:
+		t := time.Unix(0, f.Integer)
+		if loc, ok := f.Interface.(*time.Location); ok {
+			t = t.In(loc)
+		}
+		return append(attrs, slog.Time(f.Key, t))
+	case zapcore.TimeFullType:
+		return append(attrs, slog.Time(f.Key, f.Interface.(time.Time)))
+	case zapcore.ErrorType, zapcore.ReflectType, zapcore.BinaryType:
+		return append(attrs, slog.Any(f.Key, f.Interface))
+	case zapcore.StringerType:
+		return append(attrs, slog.String(f.Key, fmt.Sprint(f.Interface)))
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	for _, k := range slices.Sorted(maps.Keys(enc.Fields)) {
+		attrs = append(attrs, slog.Any(k, enc.Fields[k]))
+	}
+	return attrs
+}
@@ -0,0 +1,52 @@
+package xlogzap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/taro33333/xlog/xlogzap"
+	"go.uber.org/zap"
+)
+
+func TestCore(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})
+	logger := zap.New(xlogzap.NewCore(h), zap.AddCaller(), zap.AddStacktrace(zap.WarnLevel)).Named("db")
+
+	logger.Debug("hidden")
+	logger.With(zap.String("service", "api"), zap.Namespace("query")).Warn("slow query",
+		zap.Int("rows", 3), zap.Duration("took", time.Second), zap.Error(errors.New("timeout")))
+
+	var got struct {
+		Level   string `json:"level"`
+		Msg     string `json:"msg"`
+		Logger  string `json:"logger"`
+		Service string `json:"service"`
+		Stack   string `json:"stack"`
+		Query   struct {
+			Rows  int           `json:"rows"`
+			Took  time.Duration `json:"took"`
+			Error string        `json:"error"`
+		} `json:"query"`
+		Source struct {
+			File string `json:"file"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected one record, got %q: %v", buf.String(), err)
+	}
+	if got.Level != "WARN" || got.Msg != "slow query" || got.Logger != "db" || got.Service != "api" || got.Stack == "" {
+		t.Errorf("unexpected record: %+v", got)
+	}
+	if got.Query.Rows != 3 || got.Query.Took != time.Second || got.Query.Error != "timeout" {
+		t.Errorf("expected the fields in the namespace, got %+v", got.Query)
+	}
+	if !strings.HasSuffix(got.Source.File, "core_test.go") {
+		t.Errorf("expected the caller as source, got %q", got.Source.File)
+	}
+}
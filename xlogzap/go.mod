@@ -0,0 +1,10 @@
+module github.com/taro33333/xlog/xlogzap
+
+go 1.25.5
+
+require (
+	github.com/taro33333/xlog v0.1.0
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
@@ -0,0 +1,150 @@
+package xlogzap
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"github.com/taro33333/xlog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Handler is an slog.Handler writing records to a zapcore.Core, so xlog
+// can log through zap's encoders and outputs while a codebase still
+// depends on them:
+//
+//	xlog.Init(xlog.WithHandler(xlogzap.NewHandler(logger.Core())))
+//
+// Attributes map to fields of the matching type, groups to objects and
+// the groups of WithGroup to namespaces. Levels map to their zap
+// counterparts, and xlog.LevelCritical and above to zapcore.FatalLevel,
+// written without exiting.
+type Handler struct {
+	core zapcore.Core
+}
+
+// NewHandler creates a Handler writing to core.
+func NewHandler(core zapcore.Core) *Handler {
+	return &Handler{core: core}
+}
+
+// Enabled reports whether the core writes entries at level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+// Handle writes r to the core.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	ent := zapcore.Entry{
+		Level:   zapLevel(r.Level),
+		Time:    r.Time,
+		Message: r.Message,
+	}
+	if r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(r.PC, f.File, f.Line, true)
+		ent.Caller.Function = f.Function
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+	fields := make([]zapcore.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := attrFields(attrs)
+	if len(fields) == 0 {
+		return h
+	}
+	return &Handler{core: h.core.With(fields)}
+}
+
+// WithGroup returns a new handler with the given group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &Handler{core: h.core.With([]zapcore.Field{zap.Namespace(name)})}
+}
+
+// zapLevel returns the zap level of an slog level.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return zapcore.DebugLevel
+	case level < slog.LevelWarn:
+		return zapcore.InfoLevel
+	case level < slog.LevelError:
+		return zapcore.WarnLevel
+	case level < xlog.LevelCritical:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.FatalLevel
+	}
+}
+
+// appendAttr appends a to fields as a zap field, skipping empty attributes
+// and inlining groups with empty keys.
+func appendAttr(fields []zapcore.Field, a slog.Attr) []zapcore.Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(a.Key, a.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(a.Key, a.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(a.Key, a.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(a.Key, a.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(a.Key, a.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(a.Key, a.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(a.Key, a.Value.Time()))
+	case slog.KindGroup:
+		if a.Key == "" {
+			for _, ga := range a.Value.Group() {
+				fields = appendAttr(fields, ga)
+			}
+			return fields
+		}
+		return append(fields, zap.Object(a.Key, group(a.Value.Group())))
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		return append(fields, zap.NamedError(a.Key, err))
+	}
+	return append(fields, zap.Any(a.Key, a.Value.Any()))
+}
+
+// group is a group of attributes marshaled as a zap object.
+type group []slog.Attr
+
+// MarshalLogObject adds the attributes of g to enc.
+func (g group) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range attrFields(g) {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// attrFields returns attrs as zap fields.
+func attrFields(attrs []slog.Attr) []zapcore.Field {
+	var fields []zapcore.Field
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	return fields
+}
@@ -0,0 +1,40 @@
+package xlogzap_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/taro33333/xlog/xlogzap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	l := slog.New(xlogzap.NewHandler(core))
+
+	l.Debug("hidden")
+	l.With("service", "api").WithGroup("req").Error("request failed", "status", 500, slog.Group("peer", "host", "db1"))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Level != zapcore.ErrorLevel || e.Message != "request failed" {
+		t.Errorf("unexpected entry: %v %q", e.Level, e.Message)
+	}
+	if !e.Caller.Defined || !strings.HasSuffix(e.Caller.File, "handler_test.go") {
+		t.Errorf("expected the caller of the record, got %+v", e.Caller)
+	}
+	fields := e.ContextMap()
+	if fields["service"] != "api" {
+		t.Errorf("expected the attributes of With, got %v", fields)
+	}
+	req, _ := fields["req"].(map[string]any)
+	peer, _ := req["peer"].(map[string]any)
+	if req["status"] != int64(500) || peer["host"] != "db1" {
+		t.Errorf("expected the attributes in the req namespace, got %v", req)
+	}
+}